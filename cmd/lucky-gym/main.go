@@ -0,0 +1,23 @@
+// Command lucky-gym serves reinforcement learning environments (see gameboy/env) to clients
+// in other languages, e.g. Python, with a JSON bridge on the standard input and output
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/env"
+)
+
+func main() {
+	flag.Usage = func() {
+		log.Printf("Usage: %s < requests > responses (one JSON object per line)", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := env.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+// Command lucky-runner runs suites of test ROMs described by manifests (see gameboy/runner)
+// in parallel, prints a summary and optionally writes a JUnit XML report. It exits with
+// status 1 if a test did not pass
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/runner"
+)
+
+var (
+	workers     = flag.Int("j", 0, "Tests run in parallel (number of CPUs by default)")
+	junitPath   = flag.String("junit", "", "Write a JUnit XML report to a file")
+	screenshots = flag.String("screenshots", "", "Save the last frame of each test as PNG in a directory")
+)
+
+func main() {
+	flag.Usage = func() {
+		log.Printf("Usage: %s [flags] manifest.json...", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var manifests []*runner.Manifest
+	for _, path := range flag.Args() {
+		m, err := runner.LoadManifest(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manifests = append(manifests, m)
+	}
+
+	start := time.Now()
+	suites := make([]runner.Suite, len(manifests))
+	for i, m := range manifests {
+		suites[i] = runner.Suite{Manifest: m, Results: runner.Run(m, *workers)}
+	}
+	elapsed := time.Since(start)
+
+	if *screenshots != "" {
+		if err := saveScreenshots(*screenshots, suites); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *junitPath != "" {
+		if err := writeJUnit(*junitPath, suites); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if runner.WriteSummary(os.Stdout, suites, elapsed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func writeJUnit(path string, suites []runner.Suite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := runner.WriteJUnit(f, suites); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// saveScreenshots saves the frames as <dir>/<suite>/<test>.png
+func saveScreenshots(dir string, suites []runner.Suite) error {
+	for _, s := range suites {
+		suiteDir := filepath.Join(dir, unsafeChars.ReplaceAllString(s.Manifest.Name, "_"))
+		if err := os.MkdirAll(suiteDir, 0755); err != nil {
+			return err
+		}
+		for i, r := range s.Results {
+			if r.Frame == nil {
+				continue
+			}
+			name := fmt.Sprintf("%03d_%s.png", i, unsafeChars.ReplaceAllString(r.Test.Name, "_"))
+			f, err := os.Create(filepath.Join(suiteDir, name))
+			if err != nil {
+				return err
+			}
+			if err := png.Encode(f, r.Frame); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
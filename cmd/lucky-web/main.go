@@ -0,0 +1,86 @@
+// Command lucky-web runs a ROM without a window and streams it to web browsers (see
+// gameboy/remote): open the address in a browser to watch, one viewer controls the game
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/remote"
+)
+
+const (
+	sampleRate = 44100
+
+	// Duration of a frame (70224 T-cycles at 4.194304 MHz)
+	frameDuration = time.Second * 70224 / 4194304
+)
+
+var (
+	addr        = flag.String("addr", ":8080", "Address of the web server")
+	romPath     = flag.String("rom", "", "ROM filename")
+	bootROMPath = flag.String("boot-rom", "", "Boot ROM filename")
+	model       = flag.String("model", "auto", "GameBoy model (auto, dmg, cgb, sgb)")
+)
+
+func main() {
+	flag.Parse()
+	if *romPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rom, err := os.ReadFile(*romPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var bootROM []uint8
+	if *bootROMPath != "" {
+		if bootROM, err = os.ReadFile(*bootROMPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	m, err := gameboy.ParseModel(*model)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	emu, err := emulator.New(rom,
+		emulator.WithModel(m),
+		emulator.WithBootROM(bootROM),
+		emulator.WithSampleRate(sampleRate),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := remote.New(sampleRate)
+	listenAddr, err := server.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+	emu.GameBoy().SetInputProvider(server)
+	log.Printf("Streaming %s on %s", *romPath, listenAddr)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			emu.RunFrame()
+			server.PushFrame(emu.Frame())
+			server.PushAudio(emu.Audio())
+		case <-interrupt:
+			return
+		}
+	}
+}
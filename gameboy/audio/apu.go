@@ -16,17 +16,20 @@ type APU struct {
 
 	// Buffer to store samples
 	sampleRate    float64
-	sampleCounter float64 // Counter used to produce samples at correct rate
-	sampleBuffer  chan float32
+	sampleCounter float64      // Counter used to produce samples at correct rate
+	sampleBuffer  chan float32 `snapshot:"-"`
 
 	// CGB flag
 	isCGB bool
 
 	// Control manually channels
-	Ch1Enabled bool
-	Ch2Enabled bool
-	Ch3Enabled bool
-	Ch4Enabled bool
+	Ch1Enabled bool `snapshot:"-"`
+	Ch2Enabled bool `snapshot:"-"`
+	Ch3Enabled bool `snapshot:"-"`
+	Ch4Enabled bool `snapshot:"-"`
+
+	// If true, samples are not sent to the buffer (e.g. while re-executing instructions for reverse debugging)
+	Muted bool `snapshot:"-"`
 }
 
 func New(sampleRate float64, sampleBuffer chan float32, isCGB bool) *APU {
@@ -59,6 +62,9 @@ func (apu *APU) Tick(ticks int) {
 	for apu.sampleCounter >= ticksPerSample {
 		apu.sampleCounter -= ticksPerSample
 
+		if apu.Muted {
+			continue
+		}
 		left, right := apu.sample()
 		apu.sampleBuffer <- left
 		apu.sampleBuffer <- right
@@ -0,0 +1,124 @@
+package cartridge
+
+// readROMBank reads the byte at offset addr inside the specified 16 KiB ROM bank
+func readROMBank(rom []uint8, bank uint, addr uint16) uint8 {
+	romAddress := bank<<14 | uint(addr&0x3FFF)
+	if romAddress >= uint(len(rom)) {
+		return 0xFF
+	}
+	return rom[romAddress]
+}
+
+func (mbc *MBC0) ROMBank(addr uint16) uint {
+	if addr < 0x4000 {
+		return 0
+	}
+	return 1
+}
+
+func (mbc *MBC0) RAMBank() uint {
+	return 0
+}
+
+func (mbc *MBC0) ReadROMBank(bank uint, addr uint16) uint8 {
+	return readROMBank(mbc.ROM, bank, addr)
+}
+
+func (mbc *MBC0) ROMData() []uint8 {
+	return mbc.ROM
+}
+
+func (mbc *MBC0) RAMData() []uint8 {
+	return nil
+}
+
+func (mbc *MBC1) ROMBank(addr uint16) uint {
+	return mbc.computeRomAddress(addr&0x7FFF) >> 14
+}
+
+func (mbc *MBC1) RAMBank() uint {
+	if mbc.RAMBanks == 0 {
+		return 0
+	}
+	return mbc.computeRamAddress(0xA000) >> 13
+}
+
+func (mbc *MBC1) ReadROMBank(bank uint, addr uint16) uint8 {
+	return readROMBank(mbc.ROM, bank, addr)
+}
+
+func (mbc *MBC1) ROMData() []uint8 {
+	return mbc.ROM
+}
+
+func (mbc *MBC1) RAMData() []uint8 {
+	return mbc.RAM
+}
+
+func (mbc *MBC2) ROMBank(addr uint16) uint {
+	return mbc.computeRomAddress(addr&0x7FFF) >> 14
+}
+
+func (mbc *MBC2) RAMBank() uint {
+	// Built-in RAM has a single bank
+	return 0
+}
+
+func (mbc *MBC2) ReadROMBank(bank uint, addr uint16) uint8 {
+	return readROMBank(mbc.ROM, bank, addr)
+}
+
+func (mbc *MBC2) ROMData() []uint8 {
+	return mbc.ROM
+}
+
+func (mbc *MBC2) RAMData() []uint8 {
+	return mbc.RAM[:]
+}
+
+func (mbc *MBC3) ROMBank(addr uint16) uint {
+	return mbc.computeRomAddress(addr&0x7FFF) >> 14
+}
+
+func (mbc *MBC3) RAMBank() uint {
+	// RTC registers are mapped instead of RAM for values 08-0C
+	if mbc.RAMBanks == 0 || mbc.ramBankNumber >= 0x08 {
+		return 0
+	}
+	return mbc.computeRamAddress(0xA000) >> 13
+}
+
+func (mbc *MBC3) ReadROMBank(bank uint, addr uint16) uint8 {
+	return readROMBank(mbc.ROM, bank, addr)
+}
+
+func (mbc *MBC3) ROMData() []uint8 {
+	return mbc.ROM
+}
+
+func (mbc *MBC3) RAMData() []uint8 {
+	return mbc.RAM
+}
+
+func (mbc *MBC5) ROMBank(addr uint16) uint {
+	return mbc.computeRomAddress(addr&0x7FFF) >> 14
+}
+
+func (mbc *MBC5) RAMBank() uint {
+	if mbc.RAMBanks == 0 {
+		return 0
+	}
+	return mbc.computeRamAddress(0xA000) >> 13
+}
+
+func (mbc *MBC5) ReadROMBank(bank uint, addr uint16) uint8 {
+	return readROMBank(mbc.ROM, bank, addr)
+}
+
+func (mbc *MBC5) ROMData() []uint8 {
+	return mbc.ROM
+}
+
+func (mbc *MBC5) RAMData() []uint8 {
+	return mbc.RAM
+}
@@ -0,0 +1,36 @@
+package cartridge
+
+import "testing"
+
+func TestMBC5DebugBanks(t *testing.T) {
+	// 2 MiB ROM: every bank starts with its own number
+	rom := make([]uint8, 128*0x4000)
+	for bank := range 128 {
+		rom[bank*0x4000] = uint8(bank)
+	}
+	rom[cartridgeType] = 0x1B
+	rom[romSize] = 6
+	rom[ramSize] = 3
+
+	mbc := NewCartridge(rom, nil)
+
+	mbc.Write(0x2000, 0x42)
+	mbc.Write(0x4000, 0x02)
+	if bank := mbc.ROMBank(0x4123); bank != 0x42 {
+		t.Errorf("ROM bank at 4123: got %02X, want 42", bank)
+	}
+	if bank := mbc.ROMBank(0x0123); bank != 0 {
+		t.Errorf("ROM bank at 0123: got %02X, want 00", bank)
+	}
+	if bank := mbc.RAMBank(); bank != 2 {
+		t.Errorf("RAM bank: got %d, want 2", bank)
+	}
+
+	// Reading another bank does not change the mapping
+	if v := mbc.ReadROMBank(0x10, 0x4000); v != 0x10 {
+		t.Errorf("read bank 10: got %02X, want 10", v)
+	}
+	if v := mbc.Read(0x4000); v != 0x42 {
+		t.Errorf("read mapped bank: got %02X, want 42", v)
+	}
+}
@@ -1,6 +1,7 @@
 package cartridge
 
 import (
+	"errors"
 	"fmt"
 	"log"
 )
@@ -15,6 +16,7 @@ const (
 
 const (
 	cgbFlag       = 0x0143
+	sgbFlag       = 0x0146
 	cartridgeType = 0x0147
 	romSize       = 0x0148
 	ramSize       = 0x0149
@@ -26,8 +28,13 @@ const (
 	oldLicenseeCode = 0x014B
 	newLicenseeCode = 0x0144
 	gameVersion     = 0x014C
+
+	headerEnd = 0x0150
 )
 
+// ErrMissingHeader is returned when the ROM is too small to have a header
+var ErrMissingHeader = errors.New("invalid ROM: missing header")
+
 type Header struct {
 	// ROMSize = 16 KiB * ROMBanks
 	ROMBanks uint
@@ -46,6 +53,44 @@ type Header struct {
 
 	// Byte 0143
 	CgbMode CGBMode
+
+	// Byte 0146 (SGB functions are enabled only if old licensee code is 33)
+	SgbSupport bool
+}
+
+// CheckHeader returns an error if the cartridge of the ROM is not supported
+// (missing header, unknown cartridge type, ROM size or RAM size)
+func CheckHeader(data []byte) error {
+	if len(data) < headerEnd {
+		return ErrMissingHeader
+	}
+	if !supportedType(data[cartridgeType]) {
+		return fmt.Errorf("unsupported cartridge type: %02X", data[cartridgeType])
+	}
+	// MBC5 has up to 512 banks (8 MiB), the others up to 128 (2 MiB)
+	maxROMSize := uint8(0x06)
+	if data[cartridgeType] >= 0x19 {
+		maxROMSize = 0x08
+	}
+	if data[romSize] > maxROMSize {
+		return fmt.Errorf("unsupported ROM size: %02X", data[romSize])
+	}
+	if _, ok := ramBanks(data[ramSize]); !ok {
+		return fmt.Errorf("unsupported RAM size: %02X", data[ramSize])
+	}
+	return nil
+}
+
+// supportedType reports whether NewCartridge emulates the cartridge type
+func supportedType(v uint8) bool {
+	switch v {
+	case 0x00, 0x01, 0x02, 0x03, 0x05, 0x06,
+		0x0F, 0x10, 0x11, 0x12, 0x13,
+		0x19, 0x1A, 0x1B, 0x1C, 0x1D, 0x1E:
+		return true
+	default:
+		return false
+	}
 }
 
 func parseHeader(data []byte) *Header {
@@ -80,6 +125,7 @@ func parseHeader(data []byte) *Header {
 		Destination: data[destinationCode],
 		GameVersion: data[gameVersion],
 		CgbMode:     cgbMode,
+		SgbSupport:  data[sgbFlag] == 0x03 && data[oldLicenseeCode] == 0x33,
 	}
 }
 
@@ -99,19 +145,27 @@ func computeROMBanks(v uint8) uint {
 }
 
 func computeRAMSize(v uint8) uint {
+	banks, ok := ramBanks(v)
+	if !ok {
+		log.Fatalf("Unsupported RAM size: %02X\n", v)
+	}
+	return banks
+}
+
+// ramBanks returns the number of RAM banks of the header value
+func ramBanks(v uint8) (uint, bool) {
 	switch v {
 	case 0x00:
-		return 0
+		return 0, true
 	case 0x02:
-		return 1
+		return 1, true
 	case 0x03:
-		return 4
+		return 4, true
 	case 0x04:
-		return 16
+		return 16, true
 	case 0x05:
-		return 8
+		return 8, true
 	default:
-		log.Fatalf("Unsupported RAM size: %02X\n", v)
-		return 0
+		return 0, false
 	}
 }
@@ -0,0 +1,30 @@
+package cartridge
+
+import "testing"
+
+func TestCheckHeader(t *testing.T) {
+	for _, test := range []struct {
+		name                       string
+		cartType, romSize, ramSize uint8
+		valid                      bool
+	}{
+		{"ROM only", 0x00, 0x00, 0x00, true},
+		{"MBC1 2 MiB", 0x03, 0x06, 0x03, true},
+		{"MBC5 8 MiB", 0x1B, 0x08, 0x04, true},
+		{"MBC3 4 MiB", 0x13, 0x07, 0x03, false},
+		{"MBC5 16 MiB", 0x19, 0x09, 0x00, false},
+		{"Pocket Camera", 0xFC, 0x00, 0x00, false},
+		{"HuC1", 0xFF, 0x00, 0x00, false},
+		{"RAM size", 0x00, 0x00, 0x06, false},
+	} {
+		rom := make([]uint8, 0x8000)
+		rom[cartridgeType], rom[romSize], rom[ramSize] = test.cartType, test.romSize, test.ramSize
+		if err := CheckHeader(rom); (err == nil) != test.valid {
+			t.Errorf("%s: got error %v", test.name, err)
+		}
+	}
+
+	if err := CheckHeader(make([]uint8, 0x14F)); err != ErrMissingHeader {
+		t.Errorf("missing header: got error %v", err)
+	}
+}
@@ -3,7 +3,7 @@ package cartridge
 type MBC0 struct {
 	header *Header
 
-	ROM []uint8
+	ROM []uint8 `snapshot:"-"`
 }
 
 func (mbc *MBC0) RAMDump() []uint8 {
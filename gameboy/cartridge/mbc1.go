@@ -9,7 +9,7 @@ type MBC1 struct {
 	ROMBanks uint8
 	RAMBanks uint8
 
-	ROM []uint8
+	ROM []uint8 `snapshot:"-"`
 	RAM []uint8
 
 	// Registers
@@ -15,7 +15,7 @@ type MBC2 struct {
 
 	ROMBanks uint8
 
-	ROM []uint8
+	ROM []uint8           `snapshot:"-"`
 	RAM [mbc2RAMLen]uint8 // 512 half-bytes builtin RAM
 
 	// Registers 0000-3FFF
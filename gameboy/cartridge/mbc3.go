@@ -25,7 +25,7 @@ type MBC3 struct {
 	ROMBanks uint8
 	RAMBanks uint8
 
-	ROM []uint8
+	ROM []uint8 `snapshot:"-"`
 	RAM []uint8
 
 	// Registers
@@ -10,10 +10,14 @@ type MBC5 struct {
 	battery bool // If battery is present RAM should be stored
 	rumble  bool // If rumble motor is present on cartridge
 
+	// Rumble motor state and callback notified when it changes
+	motorOn        bool
+	rumbleCallback func(on bool) `snapshot:"-"`
+
 	ROMBanks uint // Up to 512
 	RAMBanks uint8
 
-	ROM []uint8
+	ROM []uint8 `snapshot:"-"`
 	RAM []uint8
 
 	// Registers
@@ -34,6 +38,10 @@ func (mbc *MBC5) Header() *Header {
 	return mbc.header
 }
 
+func (mbc *MBC5) SetRumbleCallback(callback func(on bool)) {
+	mbc.rumbleCallback = callback
+}
+
 func NewMBC5(rom []uint8, ram bool, savData []uint8, header *Header, battery bool, rumble bool) *MBC5 {
 	mbc := &MBC5{
 		header:        header,
@@ -84,11 +92,16 @@ func (mbc *MBC5) Write(addr uint16, value uint8) {
 		// Only lower 4 bits are used
 		mbc.ramBankNumber = value & 0xF
 
+		// On rumble cartridges bit 3 drives the motor instead of selecting the RAM bank
 		if mbc.rumble {
-			if util.ReadBit(value, 3) == 1 {
-				// Rumble
-			} else {
-				// Stop rumble
+			mbc.ramBankNumber &= 0x7
+
+			motorOn := util.ReadBit(value, 3) == 1
+			if motorOn != mbc.motorOn {
+				mbc.motorOn = motorOn
+				if mbc.rumbleCallback != nil {
+					mbc.rumbleCallback(motorOn)
+				}
 			}
 		}
 
@@ -0,0 +1,46 @@
+package cartridge
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMBC5Rumble(t *testing.T) {
+	rom := make([]uint8, 4*0x4000)
+	rom[cartridgeType] = 0x1D // MBC5 + RUMBLE + RAM
+	rom[romSize] = 1
+	rom[ramSize] = 3
+
+	mbc := NewCartridge(rom, nil)
+	r, ok := mbc.(Rumbler)
+	if !ok {
+		t.Fatal("rumble cartridge does not implement Rumbler")
+	}
+
+	var calls []bool
+	r.SetRumbleCallback(func(on bool) { calls = append(calls, on) })
+
+	// Bit 3 of the RAM bank register drives the motor, the callback is called on changes only
+	for _, value := range []uint8{0x00, 0x08, 0x08, 0x09, 0x01, 0x00, 0x08} {
+		mbc.Write(0x4000, value)
+	}
+	if want := []bool{true, false, true}; !slices.Equal(calls, want) {
+		t.Errorf("rumble callback: got %v, want %v", calls, want)
+	}
+
+	// The motor bit does not select the RAM bank
+	mbc.Write(0x4000, 0x0B)
+	if bank := mbc.(*MBC5).ramBankNumber; bank != 3 {
+		t.Errorf("RAM bank: got %d, want 3", bank)
+	}
+
+	// Cartridges without the motor use bit 3 for the RAM bank
+	rom[cartridgeType] = 0x1B // MBC5 + RAM + BATTERY
+	rom[ramSize] = 4
+	mbc = NewCartridge(rom, nil)
+	mbc.(Rumbler).SetRumbleCallback(func(bool) { t.Error("rumble callback called without motor") })
+	mbc.Write(0x4000, 0x0B)
+	if bank := mbc.(*MBC5).ramBankNumber; bank != 11 {
+		t.Errorf("RAM bank without motor: got %d, want 11", bank)
+	}
+}
@@ -0,0 +1,328 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+var (
+	ipsMagic = []byte("PATCH")
+	upsMagic = []byte("UPS1")
+	bpsMagic = []byte("BPS1")
+
+	ErrUnknownPatch = errors.New("unknown patch format")
+	errPatchEOF     = errors.New("unexpected end of patch")
+	errPatchNumber  = errors.New("number too large in patch")
+)
+
+// Largest patched ROM (8 MiB, the largest MBC5 ROM), patches declaring
+// bigger targets are rejected instead of allocating them
+const maxPatchTarget = 8 << 20
+
+// PatchExtensions lists the file extensions of the supported patch formats
+var PatchExtensions = []string{".ips", ".ups", ".bps"}
+
+// ApplyPatch applies an IPS, UPS or BPS patch to the ROM and returns the patched ROM
+// (the format is detected from the patch header)
+func ApplyPatch(rom, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, ipsMagic):
+		return applyIPS(rom, patch)
+	case bytes.HasPrefix(patch, upsMagic):
+		return applyUPS(rom, patch)
+	case bytes.HasPrefix(patch, bpsMagic):
+		return applyBPS(rom, patch)
+	default:
+		return nil, ErrUnknownPatch
+	}
+}
+
+func applyIPS(rom, patch []byte) ([]byte, error) {
+	out := bytes.Clone(rom)
+	p := patchReader{data: patch, pos: len(ipsMagic)}
+
+	for {
+		header, err := p.read(3)
+		if err != nil {
+			return nil, err
+		}
+		if string(header) == "EOF" {
+			break
+		}
+		offset := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+
+		sizeBytes, err := p.read(2)
+		if err != nil {
+			return nil, err
+		}
+		size := int(binary.BigEndian.Uint16(sizeBytes))
+
+		// Size 0 means run-length encoded record
+		var data []byte
+		if size == 0 {
+			rle, err := p.read(3)
+			if err != nil {
+				return nil, err
+			}
+			data = bytes.Repeat(rle[2:], int(binary.BigEndian.Uint16(rle)))
+		} else if data, err = p.read(size); err != nil {
+			return nil, err
+		}
+
+		end := offset + len(data)
+		if end > maxPatchTarget {
+			return nil, fmt.Errorf("IPS: target larger than %d bytes", maxPatchTarget)
+		}
+		if end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	// Optional truncation extension
+	if truncate, err := p.read(3); err == nil {
+		size := int(truncate[0])<<16 | int(truncate[1])<<8 | int(truncate[2])
+		if size < len(out) {
+			out = out[:size]
+		}
+	}
+
+	return out, nil
+}
+
+func applyUPS(rom, patch []byte) ([]byte, error) {
+	if err := checkPatchCRC(rom, patch); err != nil {
+		return nil, err
+	}
+
+	p := patchReader{data: patch[:len(patch)-12], pos: len(upsMagic)}
+	sourceSize, err := p.readNumber()
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := p.readNumber()
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize != len(rom) {
+		return nil, fmt.Errorf("UPS: expected ROM size %d, got %d", sourceSize, len(rom))
+	}
+	if targetSize < 0 || targetSize > maxPatchTarget {
+		return nil, fmt.Errorf("UPS: target size %d larger than %d bytes", targetSize, maxPatchTarget)
+	}
+
+	out := make([]byte, targetSize)
+	copy(out, rom)
+
+	offset := 0
+	for !p.done() {
+		skip, err := p.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		if skip > len(out)-offset {
+			return nil, errors.New("UPS: write past the end of the target")
+		}
+		offset += skip
+
+		// XOR bytes until a 0 is found
+		for {
+			x, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			if offset < len(out) {
+				out[offset] ^= x
+			}
+			offset++
+
+			if x == 0 {
+				break
+			}
+		}
+	}
+
+	return out, checkTargetCRC(out, patch)
+}
+
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+func applyBPS(rom, patch []byte) ([]byte, error) {
+	if err := checkPatchCRC(rom, patch); err != nil {
+		return nil, err
+	}
+
+	p := patchReader{data: patch[:len(patch)-12], pos: len(bpsMagic)}
+	sourceSize, err := p.readNumber()
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := p.readNumber()
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := p.readNumber()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = p.read(metadataSize); err != nil {
+		return nil, err
+	}
+	if sourceSize != len(rom) {
+		return nil, fmt.Errorf("BPS: expected ROM size %d, got %d", sourceSize, len(rom))
+	}
+	if targetSize < 0 || targetSize > maxPatchTarget {
+		return nil, fmt.Errorf("BPS: target size %d larger than %d bytes", targetSize, maxPatchTarget)
+	}
+
+	out := make([]byte, targetSize)
+	outOffset, sourceOffset, targetOffset := 0, 0, 0
+
+	for !p.done() {
+		action, err := p.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		length := action>>2 + 1
+		if length <= 0 || outOffset+length > len(out) {
+			return nil, errors.New("BPS: write past the end of the target")
+		}
+
+		switch action & 0b11 {
+		case bpsSourceRead:
+			if outOffset+length > len(rom) {
+				return nil, errors.New("BPS: read past the end of the source")
+			}
+			copy(out[outOffset:], rom[outOffset:outOffset+length])
+
+		case bpsTargetRead:
+			data, err := p.read(length)
+			if err != nil {
+				return nil, err
+			}
+			copy(out[outOffset:], data)
+
+		case bpsSourceCopy:
+			delta, err := p.readSignedNumber()
+			if err != nil {
+				return nil, err
+			}
+			sourceOffset += delta
+			if sourceOffset < 0 || sourceOffset+length > len(rom) {
+				return nil, errors.New("BPS: read past the end of the source")
+			}
+			copy(out[outOffset:], rom[sourceOffset:sourceOffset+length])
+			sourceOffset += length
+
+		case bpsTargetCopy:
+			delta, err := p.readSignedNumber()
+			if err != nil {
+				return nil, err
+			}
+			targetOffset += delta
+			if targetOffset < 0 || targetOffset >= outOffset {
+				return nil, errors.New("BPS: invalid target copy")
+			}
+			// Byte by byte, since source and destination can overlap
+			for i := range length {
+				out[outOffset+i] = out[targetOffset]
+				targetOffset++
+			}
+		}
+
+		outOffset += length
+	}
+
+	return out, checkTargetCRC(out, patch)
+}
+
+// checkPatchCRC verifies the UPS/BPS footer checksums of the patch itself and of the source ROM
+func checkPatchCRC(rom, patch []byte) error {
+	if len(patch) < 4+12 {
+		return errPatchEOF
+	}
+
+	footer := patch[len(patch)-12:]
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != binary.LittleEndian.Uint32(footer[8:]) {
+		return errors.New("patch checksum mismatch (corrupted patch)")
+	}
+	if crc32.ChecksumIEEE(rom) != binary.LittleEndian.Uint32(footer[0:]) {
+		return errors.New("ROM checksum mismatch (patch is meant for a different ROM)")
+	}
+	return nil
+}
+
+func checkTargetCRC(out, patch []byte) error {
+	footer := patch[len(patch)-12:]
+	if crc32.ChecksumIEEE(out) != binary.LittleEndian.Uint32(footer[4:]) {
+		return errors.New("patched ROM checksum mismatch")
+	}
+	return nil
+}
+
+type patchReader struct {
+	data []byte
+	pos  int
+}
+
+func (p *patchReader) done() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *patchReader) read(n int) ([]byte, error) {
+	if n < 0 || p.pos+n > len(p.data) {
+		return nil, errPatchEOF
+	}
+	b := p.data[p.pos : p.pos+n]
+	p.pos += n
+	return b, nil
+}
+
+func (p *patchReader) readByte() (byte, error) {
+	b, err := p.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readNumber reads a variable length number (UPS/BPS encoding)
+func (p *patchReader) readNumber() (int, error) {
+	n, shift := 0, 1
+	for {
+		x, err := p.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if int(x&0x7F) > (math.MaxInt-n)/shift {
+			return 0, errPatchNumber
+		}
+		n += int(x&0x7F) * shift
+		if x&0x80 != 0 {
+			return n, nil
+		}
+		if shift > math.MaxInt>>7 || n > math.MaxInt-shift<<7 {
+			return 0, errPatchNumber
+		}
+		shift <<= 7
+		n += shift
+	}
+}
+
+// readSignedNumber reads a number whose bit 0 is the sign (BPS copy offsets)
+func (p *patchReader) readSignedNumber() (int, error) {
+	n, err := p.readNumber()
+	if n&1 != 0 {
+		return -(n >> 1), err
+	}
+	return n >> 1, err
+}
@@ -0,0 +1,156 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// encodeNumber encodes a number with the UPS/BPS variable length encoding
+func encodeNumber(n int) []byte {
+	var out []byte
+	for {
+		x := byte(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(out, x|0x80)
+		}
+		out = append(out, x)
+		n--
+	}
+}
+
+// withFooter appends source, target and patch checksums
+func withFooter(patch, source, target []byte) []byte {
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(source))
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(patch))
+}
+
+func TestApplyPatch_IPS(t *testing.T) {
+	rom := []byte{0, 1, 2, 3, 4, 5}
+	patch := []byte("PATCH")
+	patch = append(patch, 0, 0, 1, 0, 2, 0xAA, 0xBB) // 2 bytes at offset 1
+	patch = append(patch, 0, 0, 5, 0, 0, 0, 3, 0xCC) // RLE: 3 bytes at offset 5
+	patch = append(patch, []byte("EOF")...)
+
+	got, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0, 0xAA, 0xBB, 3, 4, 0xCC, 0xCC, 0xCC}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("got %X, expected %X", got, expected)
+	}
+}
+
+func TestApplyPatch_UPS(t *testing.T) {
+	rom := []byte{0, 1, 2, 3}
+	target := []byte{0, 1, 0xF2, 3, 0x10}
+
+	patch := []byte("UPS1")
+	patch = append(patch, encodeNumber(len(rom))...)
+	patch = append(patch, encodeNumber(len(target))...)
+	patch = append(patch, encodeNumber(2)...)
+	patch = append(patch, 0xF0, 0x00) // XOR byte 2
+	patch = append(patch, encodeNumber(0)...)
+	patch = append(patch, 0x10, 0x00) // Append byte 4 (terminator also skips byte 3)
+	patch = withFooter(patch, rom, target)
+
+	got, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Errorf("got %X, expected %X", got, target)
+	}
+
+	// Wrong source ROM
+	if _, err = ApplyPatch([]byte{9, 9, 9, 9}, patch); err == nil {
+		t.Errorf("expected checksum error")
+	}
+}
+
+func TestApplyPatch_BPS(t *testing.T) {
+	rom := []byte{1, 2, 3, 4, 5, 6}
+	target := []byte{1, 2, 0xAA, 5, 6, 0xAA, 5}
+
+	patch := []byte("BPS1")
+	patch = append(patch, encodeNumber(len(rom))...)
+	patch = append(patch, encodeNumber(len(target))...)
+	patch = append(patch, encodeNumber(0)...)                      // No metadata
+	patch = append(patch, encodeNumber((2-1)<<2|bpsSourceRead)...) // 1, 2
+	patch = append(patch, encodeNumber((1-1)<<2|bpsTargetRead)...) // AA
+	patch = append(patch, 0xAA)
+	patch = append(patch, encodeNumber((2-1)<<2|bpsSourceCopy)...) // 5, 6
+	patch = append(patch, encodeNumber(4<<1)...)                   // source offset +4
+	patch = append(patch, encodeNumber((2-1)<<2|bpsTargetCopy)...) // AA, 5
+	patch = append(patch, encodeNumber(2<<1)...)                   // target offset +2
+	patch = withFooter(patch, rom, target)
+
+	got, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Errorf("got %X, expected %X", got, target)
+	}
+
+	// Corrupted patch
+	patch[len(patch)-13] ^= 0xFF
+	if _, err = ApplyPatch(rom, patch); err == nil {
+		t.Errorf("expected checksum error")
+	}
+}
+
+func TestApplyPatch_TargetTooLarge(t *testing.T) {
+	rom := []byte{0, 1, 2, 3}
+
+	ips := []byte("PATCH")
+	ips = append(ips, 0xFF, 0xFF, 0xFF, 0, 1, 0xAA) // 1 byte at offset FFFFFF
+	ips = append(ips, []byte("EOF")...)
+
+	for _, magic := range []string{"UPS1", "BPS1"} {
+		patch := []byte(magic)
+		patch = append(patch, encodeNumber(len(rom))...)
+		patch = append(patch, encodeNumber(1<<40)...)
+		patch = append(patch, encodeNumber(0)...)
+		patch = withFooter(patch, rom, nil)
+
+		if _, err := ApplyPatch(rom, patch); err == nil {
+			t.Errorf("%s: expected target size error", magic)
+		}
+	}
+
+	if _, err := ApplyPatch(rom, ips); err == nil {
+		t.Errorf("IPS: expected target size error")
+	}
+}
+
+func TestApplyPatch_Malformed(t *testing.T) {
+	rom := []byte{0, 1, 2, 3}
+
+	// Action number overflowing int (a negative length without the check)
+	bps := []byte("BPS1")
+	bps = append(bps, encodeNumber(len(rom))...)
+	bps = append(bps, encodeNumber(len(rom))...)
+	bps = append(bps, encodeNumber(0)...)
+	bps = append(bps, bytes.Repeat([]byte{0x7F}, 9)...)
+	bps = append(bps, 0xFF)
+
+	// Skips moving the offset past the end of the target (and overflowing it)
+	ups := []byte("UPS1")
+	ups = append(ups, encodeNumber(len(rom))...)
+	ups = append(ups, encodeNumber(len(rom))...)
+	for range 2 {
+		ups = append(ups, encodeNumber(1<<62)...)
+		ups = append(ups, 0x01, 0x00)
+	}
+
+	for name, patch := range map[string][]byte{"BPS": bps, "UPS": ups} {
+		if _, err := ApplyPatch(rom, withFooter(patch, rom, rom)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -10,8 +10,28 @@ type Cartridge interface {
 
 	RAMDump() []uint8
 	Header() *Header
+
+	// Debug access to the memory banks (see debug.go)
+
+	// ROMBank returns the ROM bank currently mapped at addr (0000-7FFF)
+	ROMBank(addr uint16) uint
+	// RAMBank returns the external RAM bank currently mapped at A000-BFFF
+	RAMBank() uint
+	// ReadROMBank reads addr (0000-7FFF) as if the specified ROM bank was mapped
+	ReadROMBank(bank uint, addr uint16) uint8
+	// ROMData returns the whole ROM (the debugger can edit it in place)
+	ROMData() []uint8
+	// RAMData returns the whole external RAM (nil if there is none)
+	RAMData() []uint8
+}
+
+// Rumbler is implemented by cartridges with a rumble motor
+type Rumbler interface {
+	// SetRumbleCallback sets the function called when the motor is turned on or off
+	SetRumbleCallback(func(on bool))
 }
 
+// NewCartridge returns the cartridge of the ROM, which must pass CheckHeader
 func NewCartridge(romData []uint8, savData []uint8) Cartridge {
 	header := parseHeader(romData)
 
@@ -0,0 +1,130 @@
+// Package cdl implements a code/data logger, recording how each byte of ROM and RAM was accessed.
+package cdl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// Flags describe how a byte was accessed.
+// Code and Data have the same value used by Mesen's CDL files, the other bits are ignored by it
+type Flags uint8
+
+const (
+	Code    Flags = 0x01 // Executed (opcode or operand)
+	Data    Flags = 0x02 // Read as data by the CPU
+	Opcode  Flags = 0x10 // Executed as the opcode of an instruction
+	Operand Flags = 0x20 // Read as the operand of an instruction
+	DMA     Flags = 0x40 // Source of an OAM DMA or HDMA transfer
+)
+
+// Region identifies a memory area tracked by the logger
+type Region int
+
+const (
+	ROM  Region = iota // Offsets are bank << 14 | (addr & 0x3FFF)
+	SRAM               // External cartridge RAM, offsets are bank << 13 | (addr & 0x1FFF)
+	WRAM               // Work RAM, offsets are bank << 12 | (addr & 0x0FFF)
+	HRAM               // High RAM, offsets are addr - FF80
+)
+
+const (
+	wRAMSize = 0x8000
+	hRAMSize = 0x7F
+)
+
+var (
+	romMagic = []byte("CDLv2")
+	ramMagic = []byte("CDLRAM")
+
+	ErrInvalidFile   = errors.New("invalid CDL file")
+	ErrROMMismatch   = errors.New("CDL file was created for a different ROM")
+	errUnexpectedEOF = errors.New("unexpected end of CDL file")
+)
+
+type Logger struct {
+	regions [4][]Flags
+	romCRC  uint32
+}
+
+// New returns a logger for the ROM (used to size the log and to identify it) and sramSize bytes of cartridge RAM
+func New(rom []uint8, sramSize int) *Logger {
+	l := &Logger{romCRC: crc32.ChecksumIEEE(rom)}
+	l.regions[ROM] = make([]Flags, len(rom))
+	l.regions[SRAM] = make([]Flags, sramSize)
+	l.regions[WRAM] = make([]Flags, wRAMSize)
+	l.regions[HRAM] = make([]Flags, hRAMSize)
+	return l
+}
+
+// Mark adds flags to the byte at offset in region (offsets outside the region are ignored)
+func (l *Logger) Mark(region Region, offset uint, flags Flags) {
+	if r := l.regions[region]; offset < uint(len(r)) {
+		r[offset] |= flags
+	}
+}
+
+// Flags returns the flags of the byte at offset in region
+func (l *Logger) Flags(region Region, offset uint) Flags {
+	if r := l.regions[region]; offset < uint(len(r)) {
+		return r[offset]
+	}
+	return 0
+}
+
+// Coverage returns the number of bytes accessed in region and its size
+func (l *Logger) Coverage(region Region) (accessed, total int) {
+	for _, f := range l.regions[region] {
+		if f != 0 {
+			accessed++
+		}
+	}
+	return accessed, len(l.regions[region])
+}
+
+// MarshalROM encodes the ROM log in the Mesen format:
+// "CDLv2", CRC32 of the ROM (little endian) and the flags of every ROM byte
+func (l *Logger) MarshalROM() []byte {
+	var buf bytes.Buffer
+	buf.Write(romMagic)
+	binary.Write(&buf, binary.LittleEndian, l.romCRC)
+	for _, f := range l.regions[ROM] {
+		buf.WriteByte(byte(f))
+	}
+	return buf.Bytes()
+}
+
+// UnmarshalROM merges a ROM log encoded by MarshalROM, so that coverage accumulates across sessions
+func (l *Logger) UnmarshalROM(data []byte) error {
+	if !bytes.HasPrefix(data, romMagic) {
+		return ErrInvalidFile
+	}
+	data = data[len(romMagic):]
+	if len(data) < 4 {
+		return errUnexpectedEOF
+	}
+	if binary.LittleEndian.Uint32(data) != l.romCRC || len(data)-4 != len(l.regions[ROM]) {
+		return ErrROMMismatch
+	}
+
+	for i, f := range data[4:] {
+		l.regions[ROM][i] |= Flags(f)
+	}
+	return nil
+}
+
+// MarshalRAM encodes the RAM log: "CDLRAM" followed by the cartridge RAM, work RAM and high RAM
+// flags, each preceded by its length (32 bit little endian)
+func (l *Logger) MarshalRAM() []byte {
+	var buf bytes.Buffer
+	buf.Write(ramMagic)
+	for _, region := range []Region{SRAM, WRAM, HRAM} {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(l.regions[region])))
+		for _, f := range l.regions[region] {
+			buf.WriteByte(byte(f))
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,54 @@
+package cdl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMarkAndMarshal(t *testing.T) {
+	rom := make([]uint8, 0x8000)
+	l := New(rom, 0x2000)
+
+	l.Mark(ROM, 0x0150, Code|Opcode)
+	l.Mark(ROM, 0x0151, Code|Operand)
+	l.Mark(ROM, 0x4000, Data)
+	l.Mark(ROM, 0x4000, DMA)
+	l.Mark(ROM, 0x8000, Data) // Out of range
+	l.Mark(HRAM, 0x7E, Code|Opcode)
+
+	if f := l.Flags(ROM, 0x4000); f != Data|DMA {
+		t.Errorf("flags at 4000: got %02X, want %02X", f, Data|DMA)
+	}
+	if accessed, total := l.Coverage(ROM); accessed != 3 || total != 0x8000 {
+		t.Errorf("coverage: got %d/%d, want 3/32768", accessed, total)
+	}
+
+	data := l.MarshalROM()
+	if len(data) != 5+4+len(rom) || !bytes.HasPrefix(data, []byte("CDLv2")) {
+		t.Fatalf("invalid ROM log header (%d bytes)", len(data))
+	}
+	if f := Flags(data[9+0x0151]); f != Code|Operand {
+		t.Errorf("encoded flags at 0151: got %02X, want %02X", f, Code|Operand)
+	}
+
+	// Coverage accumulates when loading a previous log
+	other := New(rom, 0x2000)
+	other.Mark(ROM, 0x0200, Data)
+	if err := other.UnmarshalROM(data); err != nil {
+		t.Fatal(err)
+	}
+	if other.Flags(ROM, 0x0150) != Code|Opcode || other.Flags(ROM, 0x0200) != Data {
+		t.Error("flags were not merged")
+	}
+
+	// Logs of other ROMs are rejected
+	rom[0x0134] = 'X'
+	if err := New(rom, 0).UnmarshalROM(data); !errors.Is(err, ErrROMMismatch) {
+		t.Errorf("different ROM: got %v, want %v", err, ErrROMMismatch)
+	}
+
+	if n := len(l.MarshalRAM()); n != 6+3*4+0x2000+0x8000+0x7F {
+		t.Errorf("RAM log size: got %d", n)
+	}
+}
@@ -0,0 +1,203 @@
+package cheats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Memory is written by GameShark codes
+type Memory interface {
+	DebugWrite(addr uint16, value uint8)
+	DebugWriteBank(bank uint, addr uint16, value uint8)
+}
+
+// Cheat is a named group of codes enabled together
+type Cheat struct {
+	Name    string
+	Codes   []Code
+	Text    string // Codes as entered
+	Enabled bool
+}
+
+// ParseCheat parses codes separated by '+' or spaces followed by an optional name,
+// e.g. "01FF38CD+01FF39CD Infinite lives"
+func ParseCheat(s string) (*Cheat, error) {
+	fields := strings.Fields(strings.ReplaceAll(s, "+", " + "))
+
+	c := &Cheat{Enabled: true}
+	var texts []string
+	for len(fields) > 0 {
+		if fields[0] == "+" {
+			fields = fields[1:]
+			continue
+		}
+		code, err := ParseCode(fields[0])
+		if err != nil {
+			// The rest is the name
+			if len(c.Codes) == 0 {
+				return nil, err
+			}
+			break
+		}
+		c.Codes = append(c.Codes, code)
+		texts = append(texts, strings.ToUpper(fields[0]))
+		fields = fields[1:]
+	}
+	if len(c.Codes) == 0 {
+		return nil, fmt.Errorf("no codes in %q", s)
+	}
+
+	c.Text = strings.Join(texts, "+")
+	c.Name = strings.Join(fields, " ")
+	return c, nil
+}
+
+func (c *Cheat) String() string {
+	if c.Name == "" {
+		return c.Text
+	}
+	return c.Text + " " + c.Name
+}
+
+// activeCodes are the codes of the enabled cheats, replaced as a whole when cheats change
+// so that the emulation can read them without locking
+type activeCodes struct {
+	gameShark []Code
+	gameGenie []Code
+}
+
+// List holds the cheats of a ROM
+type List struct {
+	mu     sync.Mutex
+	cheats []*Cheat
+
+	active atomic.Pointer[activeCodes]
+}
+
+func NewList() *List {
+	l := new(List)
+	l.active.Store(new(activeCodes))
+	return l
+}
+
+// Cheats returns a copy of the cheats
+func (l *List) Cheats() []Cheat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cheats := make([]Cheat, len(l.cheats))
+	for i, c := range l.cheats {
+		cheats[i] = *c
+	}
+	return cheats
+}
+
+func (l *List) Add(c *Cheat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cheats = append(l.cheats, c)
+	l.update()
+}
+
+func (l *List) Remove(i int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if 0 <= i && i < len(l.cheats) {
+		l.cheats = append(l.cheats[:i], l.cheats[i+1:]...)
+		l.update()
+	}
+}
+
+// SetEnabled enables or disables the i-th cheat
+func (l *List) SetEnabled(i int, enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if 0 <= i && i < len(l.cheats) {
+		l.cheats[i].Enabled = enabled
+		l.update()
+	}
+}
+
+// update rebuilds the active codes (mu must be locked)
+func (l *List) update() {
+	active := new(activeCodes)
+	for _, c := range l.cheats {
+		if !c.Enabled {
+			continue
+		}
+		for _, code := range c.Codes {
+			if code.Kind == GameGenie {
+				active.gameGenie = append(active.gameGenie, code)
+			} else {
+				active.gameShark = append(active.gameShark, code)
+			}
+		}
+	}
+	l.active.Store(active)
+}
+
+// ApplyRAM applies the GameShark codes (called every frame)
+func (l *List) ApplyRAM(mem Memory) {
+	for _, code := range l.active.Load().gameShark {
+		if code.Bank >= 0 {
+			mem.DebugWriteBank(uint(code.Bank), code.Address, code.Value)
+		} else {
+			mem.DebugWrite(code.Address, code.Value)
+		}
+	}
+}
+
+// PatchROM returns the value read from ROM at addr after applying the Game Genie codes
+func (l *List) PatchROM(addr uint16, value uint8) uint8 {
+	for _, code := range l.active.Load().gameGenie {
+		if code.Address == addr && (!code.HasCompare || code.Compare == value) {
+			return code.Value
+		}
+	}
+	return value
+}
+
+// Marshal encodes the cheats, one per line: "+" or "-" (enabled or not), codes and name
+func (l *List) Marshal() []byte {
+	var buf bytes.Buffer
+	for _, c := range l.Cheats() {
+		state := "-"
+		if c.Enabled {
+			state = "+"
+		}
+		fmt.Fprintf(&buf, "%s %s\n", state, c.String())
+	}
+	return buf.Bytes()
+}
+
+// Unmarshal adds the cheats encoded by Marshal (empty lines and lines starting with # are skipped)
+func (l *List) Unmarshal(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		enabled := true
+		if state, rest, ok := strings.Cut(line, " "); ok && (state == "+" || state == "-") {
+			enabled = state == "+"
+			line = rest
+		}
+
+		c, err := ParseCheat(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		c.Enabled = enabled
+		l.Add(c)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,113 @@
+package cheats
+
+import (
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
+)
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want Code
+	}{
+		{"010238CD", Code{Kind: GameShark, Value: 0x02, Address: 0xCD38, Bank: -1}},
+		{"9263A4D2", Code{Kind: GameShark, Value: 0x63, Address: 0xD2A4, Bank: 2}},
+		{"00A-17B", Code{Kind: GameGenie, Value: 0x00, Address: 0x4A17, Bank: -1}},
+		{"3EA-17B-C49", Code{Kind: GameGenie, Value: 0x3E, Address: 0x4A17, Bank: -1, Compare: 0xC8, HasCompare: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCode(tt.code)
+		if err != nil {
+			t.Errorf("%s: %v", tt.code, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.code, got, tt.want)
+		}
+	}
+
+	// The last ones write ROM, VRAM and I/O registers
+	for _, code := range []string{"0102", "X10238CD", "9263A4C2", "00A-17", "01FF0040", "00FF0080", "01FFFF9F", "010040FF"} {
+		if _, err := ParseCode(code); err == nil {
+			t.Errorf("%s: invalid code accepted", code)
+		}
+	}
+
+	if s := GameSharkCode(2, 0xD2A4, 0x63); s != "9263A4D2" {
+		t.Errorf("GameShark code: got %s, want 9263A4D2", s)
+	}
+}
+
+type fakeMemory map[uint16]uint8
+
+func (m fakeMemory) DebugWrite(addr uint16, value uint8)             { m[addr] = value }
+func (m fakeMemory) DebugWriteBank(_ uint, addr uint16, value uint8) { m[addr] = value }
+
+func TestList(t *testing.T) {
+	l := NewList()
+	if err := l.Unmarshal([]byte("+ 010238CD+010339CD Infinite lives\n- 3EA-17B-C49 Skip intro\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	mem := fakeMemory{}
+	l.ApplyRAM(mem)
+	if mem[0xCD38] != 0x02 || mem[0xCD39] != 0x03 {
+		t.Errorf("GameShark codes not applied: %v", mem)
+	}
+
+	// Disabled Game Genie code
+	if v := l.PatchROM(0x4A17, 0xC8); v != 0xC8 {
+		t.Errorf("disabled code patched ROM: %02X", v)
+	}
+	l.SetEnabled(1, true)
+	if v := l.PatchROM(0x4A17, 0xC8); v != 0x3E {
+		t.Errorf("patched ROM: got %02X, want 3E", v)
+	}
+	if v := l.PatchROM(0x4A17, 0x00); v != 0x00 {
+		t.Errorf("compare value ignored: got %02X, want 00", v)
+	}
+
+	want := "+ 010238CD+010339CD Infinite lives\n+ 3EA-17B-C49 Skip intro\n"
+	if got := string(l.Marshal()); got != want {
+		t.Errorf("marshal: got %q, want %q", got, want)
+	}
+}
+
+type fakeRAM struct {
+	wRAM []uint8
+}
+
+func (r *fakeRAM) DebugRegionSize(region mmu.Region) int {
+	if region == mmu.RegionWRAM {
+		return len(r.wRAM)
+	}
+	return 0
+}
+
+func (r *fakeRAM) DebugReadRegion(_ mmu.Region, offset uint) uint8 {
+	return r.wRAM[offset]
+}
+
+func TestFinder(t *testing.T) {
+	ram := &fakeRAM{wRAM: make([]uint8, 0x2000)}
+	ram.wRAM[0x1234] = 3 // Lives
+
+	f := NewFinder(ram)
+
+	ram.wRAM[0x1234] = 2
+	ram.wRAM[0x0010] = 1
+	f.Filter(Decreased, 0)
+	if f.Len() != 1 {
+		t.Fatalf("candidates after decrease: got %d, want 1", f.Len())
+	}
+
+	f.Filter(EqualTo, 2)
+	locs, values := f.Results(10)
+	if len(locs) != 1 || values[0] != 2 {
+		t.Fatalf("results: got %v %v", locs, values)
+	}
+	if bank, addr := locs[0].Address(); bank != 1 || addr != 0xD234 {
+		t.Errorf("address: got %02X:%04X, want 01:D234", bank, addr)
+	}
+}
@@ -0,0 +1,131 @@
+// Package cheats implements GameShark and Game Genie codes and a RAM search to find new ones.
+package cheats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Kind int
+
+const (
+	GameShark Kind = iota // RAM write applied every frame
+	GameGenie             // ROM read patch
+)
+
+// Code is a single decoded cheat code
+type Code struct {
+	Kind    Kind
+	Address uint16
+	Value   uint8
+
+	// GameShark: bank of the address (-1 for the one mapped)
+	Bank int
+
+	// Game Genie: the value is replaced only if the ROM contains Compare (if HasCompare)
+	Compare    uint8
+	HasCompare bool
+}
+
+// ParseCode decodes a GameShark code (TTVVAAAA) or a Game Genie code (ABC-DEF or ABC-DEF-GHI)
+func ParseCode(s string) (Code, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if strings.Contains(s, "-") {
+		return parseGameGenie(s)
+	}
+	return parseGameShark(s)
+}
+
+// parseGameShark decodes TTVVAAAA: type TT, value VV and little endian address AAAA.
+// Type 01 writes the bank mapped, 8X writes external RAM bank X and 9X wRAM bank X (CGB).
+// Only RAM ($A000-$DFFF) can be written, not ROM or I/O registers
+func parseGameShark(s string) (Code, error) {
+	if len(s) != 8 {
+		return Code{}, fmt.Errorf("invalid code %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Code{}, fmt.Errorf("invalid code %q", s)
+	}
+
+	code := Code{
+		Kind:    GameShark,
+		Value:   uint8(v >> 16),
+		Address: uint16(v>>8&0xFF) | uint16(v&0xFF)<<8,
+		Bank:    -1,
+	}
+
+	switch t := uint8(v >> 24); {
+	case (t == 0x00 || t == 0x01) && 0xA000 <= code.Address && code.Address < 0xE000:
+	case t&0xF0 == 0x80 && 0xA000 <= code.Address && code.Address < 0xC000:
+		code.Bank = int(t & 0x0F)
+	case t&0xF0 == 0x90 && 0xD000 <= code.Address && code.Address < 0xE000:
+		code.Bank = int(t & 0x07)
+	default:
+		return Code{}, fmt.Errorf("unsupported GameShark code type %02X", t)
+	}
+	return code, nil
+}
+
+// parseGameGenie decodes ABC-DEF[-GHI]: value AB, address FCDE ^ F000 and
+// compare value GI rotated right by 2 and XORed with BA (H is not used)
+func parseGameGenie(s string) (Code, error) {
+	digits := strings.ReplaceAll(s, "-", "")
+	if len(digits) != 6 && len(digits) != 9 {
+		return Code{}, fmt.Errorf("invalid code %q", s)
+	}
+
+	var n [9]uint8
+	for i, c := range digits {
+		v, err := strconv.ParseUint(string(c), 16, 8)
+		if err != nil {
+			return Code{}, fmt.Errorf("invalid code %q", s)
+		}
+		n[i] = uint8(v)
+	}
+
+	code := Code{
+		Kind:    GameGenie,
+		Value:   n[0]<<4 | n[1],
+		Address: (uint16(n[5])<<12 | uint16(n[2])<<8 | uint16(n[3])<<4 | uint16(n[4])) ^ 0xF000,
+		Bank:    -1,
+	}
+	if code.Address >= 0x8000 {
+		return Code{}, fmt.Errorf("Game Genie code %q does not patch ROM", s)
+	}
+
+	if len(digits) == 9 {
+		compare := n[6]<<4 | n[8]
+		code.Compare = (compare>>2 | compare<<6) ^ 0xBA
+		code.HasCompare = true
+	}
+	return code, nil
+}
+
+func (c Code) String() string {
+	if c.Kind == GameGenie {
+		s := fmt.Sprintf("%02X %04X", c.Value, c.Address)
+		if c.HasCompare {
+			s += fmt.Sprintf(" (if %02X)", c.Compare)
+		}
+		return s
+	}
+
+	if c.Bank >= 0 {
+		return fmt.Sprintf("%02X:%04X = %02X", c.Bank, c.Address, c.Value)
+	}
+	return fmt.Sprintf("%04X = %02X", c.Address, c.Value)
+}
+
+// GameSharkCode encodes a RAM write as a GameShark code (bank is -1 for the bank mapped)
+func GameSharkCode(bank int, addr uint16, value uint8) string {
+	t := uint8(0x01)
+	switch {
+	case bank >= 0 && 0xA000 <= addr && addr < 0xC000:
+		t = 0x80 | uint8(bank&0x0F)
+	case bank >= 0 && 0xD000 <= addr && addr < 0xE000:
+		t = 0x90 | uint8(bank&0x07)
+	}
+	return fmt.Sprintf("%02X%02X%02X%02X", t, value, uint8(addr), uint8(addr>>8))
+}
@@ -0,0 +1,106 @@
+package cheats
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
+)
+
+// RAM is the memory searched by the finder
+type RAM interface {
+	DebugRegionSize(r mmu.Region) int
+	DebugReadRegion(r mmu.Region, offset uint) uint8
+}
+
+// Comparison between the current value of an address and the previous snapshot
+type Comparison int
+
+const (
+	Equal Comparison = iota
+	Changed
+	Increased
+	Decreased
+	EqualTo // Compared with a value instead of the snapshot
+)
+
+// Location is a byte of wRAM or external RAM
+type Location struct {
+	Region mmu.Region
+	Offset uint
+}
+
+// Address returns the bank and the CPU address of the location
+// (bank is -1 for addresses that are not switchable)
+func (loc Location) Address() (bank int, addr uint16) {
+	if loc.Region == mmu.RegionSRAM {
+		return int(loc.Offset >> 13), 0xA000 | uint16(loc.Offset&0x1FFF)
+	}
+
+	if loc.Offset < 0x1000 {
+		return -1, 0xC000 | uint16(loc.Offset)
+	}
+	return int(loc.Offset >> 12), 0xD000 | uint16(loc.Offset&0x0FFF)
+}
+
+// Finder narrows the RAM addresses holding a value (e.g. lives or money),
+// comparing them between snapshots taken while the value changes in game
+type Finder struct {
+	ram RAM
+
+	candidates []Location
+	previous   []uint8
+}
+
+// NewFinder starts a search taking the first snapshot: every wRAM and external RAM byte is a candidate
+func NewFinder(ram RAM) *Finder {
+	f := &Finder{ram: ram}
+	for _, region := range []mmu.Region{mmu.RegionWRAM, mmu.RegionSRAM} {
+		for offset := range uint(ram.DebugRegionSize(region)) {
+			loc := Location{Region: region, Offset: offset}
+			f.candidates = append(f.candidates, loc)
+			f.previous = append(f.previous, ram.DebugReadRegion(region, offset))
+		}
+	}
+	return f
+}
+
+// Filter keeps the candidates whose current value satisfies the comparison with the
+// previous snapshot (or with value for EqualTo) and takes a new snapshot
+func (f *Finder) Filter(cmp Comparison, value uint8) {
+	n := 0
+	for i, loc := range f.candidates {
+		old := f.previous[i]
+		current := f.ram.DebugReadRegion(loc.Region, loc.Offset)
+
+		var keep bool
+		switch cmp {
+		case Equal:
+			keep = current == old
+		case Changed:
+			keep = current != old
+		case Increased:
+			keep = current > old
+		case Decreased:
+			keep = current < old
+		case EqualTo:
+			keep = current == value
+		}
+
+		if keep {
+			f.candidates[n] = loc
+			f.previous[n] = current
+			n++
+		}
+	}
+	f.candidates = f.candidates[:n]
+	f.previous = f.previous[:n]
+}
+
+// Len returns the number of candidates left
+func (f *Finder) Len() int {
+	return len(f.candidates)
+}
+
+// Results returns the first limit candidates with their value in the last snapshot
+func (f *Finder) Results(limit int) ([]Location, []uint8) {
+	n := min(limit, len(f.candidates))
+	return f.candidates[:n], f.previous[:n]
+}
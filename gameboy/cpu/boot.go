@@ -9,6 +9,15 @@ func (cpu *CPU) SkipDMGBoot() {
 	cpu.PC = 0x100
 }
 
+func (cpu *CPU) SkipSGBBoot() {
+	cpu.writeAF(0x0100)
+	cpu.writeBC(0x0014)
+	cpu.writeDE(0x0000)
+	cpu.writeHL(0xC060)
+	cpu.SP = 0xFFFE
+	cpu.PC = 0x100
+}
+
 func (cpu *CPU) SkipCGBBoot(bRegister uint8) {
 	cpu.writeAF(0x1180)
 	cpu.writeB(bRegister)
@@ -0,0 +1,112 @@
+package cpu
+
+// maxCallDepth limits the frames tracked (runaway recursion drops the outermost ones)
+const maxCallDepth = 1024
+
+// FrameKind tells how a routine was entered
+type FrameKind uint8
+
+const (
+	FrameCall      FrameKind = iota // CALL
+	FrameRST                        // RST
+	FrameInterrupt                  // Interrupt dispatch
+)
+
+func (k FrameKind) String() string {
+	return [...]string{FrameCall: "CALL", FrameRST: "RST", FrameInterrupt: "INT"}[k]
+}
+
+// Frame is a routine entered and not returned from yet
+type Frame struct {
+	Kind FrameKind
+
+	// Entry point of the routine and bank mapped there when it was entered
+	Target     uint16
+	TargetBank uint
+
+	// Instruction that called the routine (the interrupted one for interrupts),
+	// address it returns to and bank mapped there
+	CallPC     uint16
+	Return     uint16
+	ReturnBank uint
+
+	// Stack address of the return address
+	SP uint16
+}
+
+// TrackCalls enables (or disables) the call stack tracking, clearing the stack
+func (cpu *CPU) TrackCalls(enabled bool) {
+	cpu.trackCalls = enabled
+	cpu.callStack = nil
+}
+
+// CallStack returns the routines entered and not returned from yet (innermost last).
+// Frames whose return address was popped or overwritten by the game (e.g. jump tables
+// popping the address after an RST, or a stack reset) are discarded
+func (cpu *CPU) CallStack() []Frame {
+	cpu.unwind()
+	return cpu.callStack
+}
+
+// CallDepth returns the number of frames in the call stack
+func (cpu *CPU) CallDepth() int {
+	cpu.unwind()
+	return len(cpu.callStack)
+}
+
+// enterFrame records a routine entered, called when the return address
+// has been pushed and PC is the routine entry point
+func (cpu *CPU) enterFrame(kind FrameKind, callPC uint16) {
+	if !cpu.trackCalls {
+		return
+	}
+	cpu.unwind()
+
+	ret := cpu.readStack(cpu.SP)
+	if len(cpu.callStack) == maxCallDepth {
+		cpu.callStack = append(cpu.callStack[:0], cpu.callStack[1:]...)
+	}
+	cpu.callStack = append(cpu.callStack, Frame{
+		Kind:       kind,
+		Target:     cpu.PC,
+		TargetBank: cpu.mmu.DebugBank(cpu.PC),
+		CallPC:     callPC,
+		Return:     ret,
+		ReturnBank: cpu.mmu.DebugBank(ret),
+		SP:         cpu.SP,
+	})
+}
+
+// leaveFrame is called by RET when the return address has been popped: the frame
+// it was pushed by returned. A return address pushed by the game (PUSH then RET
+// to dispatch, or to return somewhere else) is a jump and leaves the frames as they are
+func (cpu *CPU) leaveFrame() {
+	if !cpu.trackCalls {
+		return
+	}
+
+	sp := cpu.SP - 2
+	n := len(cpu.callStack)
+	for n > 0 && cpu.callStack[n-1].SP <= sp {
+		n--
+	}
+	cpu.callStack = cpu.callStack[:n]
+}
+
+// unwind discards the innermost frames whose return address is not on the stack anymore
+func (cpu *CPU) unwind() {
+	n := len(cpu.callStack)
+	for n > 0 {
+		f := cpu.callStack[n-1]
+		if f.SP >= cpu.SP && cpu.readStack(f.SP) == f.Return {
+			break
+		}
+		n--
+	}
+	cpu.callStack = cpu.callStack[:n]
+}
+
+// readStack reads a word from the stack without side effects
+func (cpu *CPU) readStack(addr uint16) uint16 {
+	return uint16(cpu.mmu.DebugRead(addr+1))<<8 | uint16(cpu.mmu.DebugRead(addr))
+}
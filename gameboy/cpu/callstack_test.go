@@ -0,0 +1,83 @@
+package cpu
+
+import "testing"
+
+func TestCallStack(t *testing.T) {
+	cpu := mockCPU()
+	cpu.TrackCalls(true)
+
+	cpu.PC = 0x0100
+	writeTestProgram(cpu, 0xCD, 0x00, 0x02) // CALL $0200
+	cpu.PC = 0x0200
+	writeTestProgram(cpu, 0xCF, 0xC9) // RST $08; RET
+	cpu.PC = 0x0008
+	writeTestProgram(cpu, 0x21, 0x10, 0x00, 0xE5, 0xC9) // LD HL, $0010; PUSH HL; RET
+	cpu.PC = 0x0010
+	writeTestProgram(cpu, 0xE1, 0xE9) // POP HL; JP HL
+	cpu.PC = 0x0100
+
+	steps := []struct {
+		name  string
+		pc    uint16
+		depth int
+	}{
+		{"CALL", 0x0200, 1},
+		{"RST", 0x0008, 2},
+		{"LD HL", 0x000B, 2},
+		{"PUSH HL", 0x000C, 2},
+		{"RET to pushed address", 0x0010, 2},
+		{"POP return address", 0x0011, 1},
+		{"JP HL", 0x0201, 1},
+		{"RET", 0x0103, 0},
+	}
+	for _, step := range steps {
+		cpu.ExecuteInstruction()
+		if cpu.PC != step.pc {
+			t.Fatalf("%s: PC got %04X, expected %04X", step.name, cpu.PC, step.pc)
+		}
+		if depth := cpu.CallDepth(); depth != step.depth {
+			t.Fatalf("%s: depth got %d, expected %d", step.name, depth, step.depth)
+		}
+
+		if step.name == "RST" {
+			stack := cpu.CallStack()
+			expected := []Frame{
+				{Kind: FrameCall, Target: 0x0200, CallPC: 0x0100, Return: 0x0103, SP: 0xFFFC},
+				{Kind: FrameRST, Target: 0x0008, CallPC: 0x0200, Return: 0x0201, SP: 0xFFFA},
+			}
+			for i, f := range expected {
+				if stack[i] != f {
+					t.Errorf("frame %d: got %+v, expected %+v", i, stack[i], f)
+				}
+			}
+		}
+	}
+}
+
+func TestCallStackInterrupt(t *testing.T) {
+	cpu := mockCPU()
+	cpu.TrackCalls(true)
+
+	cpu.PC = 0x1234
+	cpu.IME = true
+	cpu.mmu.Write(ieAddr, VBlankInterruptMask)
+	cpu.mmu.Write(ifAddr, VBlankInterruptMask)
+	cpu.handleInterrupts()
+
+	stack := cpu.CallStack()
+	if len(stack) != 1 {
+		t.Fatalf("depth: got %d, expected 1", len(stack))
+	}
+	expected := Frame{Kind: FrameInterrupt, Target: 0x0040, CallPC: 0x1234, Return: 0x1234, SP: 0xFFFC}
+	if stack[0] != expected {
+		t.Errorf("got %+v, expected %+v", stack[0], expected)
+	}
+
+	// RETI
+	cpu.PC = 0x0040
+	writeTestProgram(cpu, 0xD9)
+	cpu.ExecuteInstruction()
+	if depth := cpu.CallDepth(); depth != 0 {
+		t.Errorf("depth after RETI: got %d, expected 0", depth)
+	}
+}
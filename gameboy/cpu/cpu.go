@@ -3,6 +3,7 @@ package cpu
 import (
 	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 )
 
 // Ticker describes hardware components that needs clock synchronization
@@ -51,12 +52,16 @@ type CPU struct {
 	tickers []Ticker
 
 	// Used for debugger
-	callHook func()
-	retHook  func()
+	trackCalls    bool
+	callStack     []Frame
+	traceHook     func()        `snapshot:"-"` // Called before executing each instruction
+	eventHook     timeline.Hook `snapshot:"-"` // Called on interrupts and HALT
+	instructionPC uint16
+	cycles        uint64 // T-cycles elapsed since reset
 
 	// Opcodes tables
-	opcodesTable         [256]func()
-	prefixedOpcodesTable [32]func(uint8)
+	opcodesTable         [256]func()     `snapshot:"-"`
+	prefixedOpcodesTable [32]func(uint8) `snapshot:"-"`
 }
 
 func New(mmu *mmu.MMU, ppu *ppu.PPU, isCGB bool) *CPU {
@@ -76,6 +81,7 @@ func (cpu *CPU) AddTicker(tickers ...Ticker) {
 }
 
 func (cpu *CPU) Tick(ticks int) {
+	cpu.cycles += uint64(ticks)
 	cpu.interruptCancelled = false
 
 	// Cancel interrupt one cycle later
@@ -97,7 +103,11 @@ func (cpu *CPU) Tick(ticks int) {
 
 func (cpu *CPU) ExecuteInstruction() {
 	if !cpu.halted && !cpu.mmu.VDMAActive() {
-		opcode := cpu.ReadNextByte()
+		if cpu.traceHook != nil {
+			cpu.traceHook()
+		}
+		cpu.instructionPC = cpu.PC
+		opcode := cpu.readNextByte(true)
 
 		// Execute opcode
 		cpu.opcodesTable[opcode]()
@@ -111,6 +121,7 @@ func (cpu *CPU) ExecuteInstruction() {
 			if cpu.speedSwitchHaltedTicks <= 0 {
 				cpu.halted = false
 				cpu.speedSwitchHaltedTicks = 0
+				cpu.event(timeline.Resume, 0)
 			}
 		}
 	}
@@ -122,4 +133,5 @@ func (cpu *CPU) ExecuteInstruction() {
 func (cpu *CPU) SwitchSpeed(doubleSpeed bool) {
 	cpu.speedSwitchHaltedTicks = 0x20000
 	cpu.halted = true
+	cpu.event(timeline.Halt, 0)
 }
@@ -1,6 +1,31 @@
 package cpu
 
-func (cpu *CPU) SetHooks(callHook func(), retHook func()) {
-	cpu.callHook = callHook
-	cpu.retHook = retHook
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
+// SetTraceHook sets a function called before executing each instruction (nil to remove it)
+func (cpu *CPU) SetTraceHook(hook func()) {
+	cpu.traceHook = hook
+}
+
+// SetEventHook sets a function called on interrupt requests and services and when the CPU
+// halts or resumes (nil to remove it)
+func (cpu *CPU) SetEventHook(hook timeline.Hook) {
+	cpu.eventHook = hook
+}
+
+// event calls the event hook, if set
+func (cpu *CPU) event(kind timeline.Kind, value uint8) {
+	if cpu.eventHook != nil {
+		cpu.eventHook(kind, 0, value)
+	}
+}
+
+// Cycles returns the number of T-cycles elapsed since reset
+func (cpu *CPU) Cycles() uint64 {
+	return cpu.cycles
+}
+
+// InstructionPC returns the address of the instruction being executed (or last executed)
+func (cpu *CPU) InstructionPC() uint16 {
+	return cpu.instructionPC
 }
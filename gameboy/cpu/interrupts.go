@@ -1,5 +1,7 @@
 package cpu
 
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
 const (
 	ifAddr = 0xFF0F
 	ieAddr = 0xFFFF
@@ -37,6 +39,7 @@ func (cpu *CPU) handleInterrupts() {
 	// Awake if halted
 	if cpu.halted && triggered > 0 {
 		cpu.halted = false
+		cpu.event(timeline.Resume, 0)
 	}
 
 	if !cpu.IME || triggered == 0 {
@@ -60,21 +63,21 @@ func (cpu *CPU) RequestInterrupt(interruptMask uint8) {
 	IF := cpu.mmu.Read(ifAddr)
 	IF |= interruptMask
 	cpu.mmu.Write(ifAddr, IF)
+	cpu.event(timeline.Request, interruptMask)
 }
 
 // serveInterrupt return false if interrupt is cancelled, true otherwise
 func (cpu *CPU) serveInterrupt(interruptMask uint8) bool {
 	cpu.interruptMaskRequested = interruptMask
 	cpu.IME = false
-	if cpu.callHook != nil {
-		cpu.callHook()
-	}
 
 	// 2 NOP cycles (one is executed in cpu.PUSH_STACK)
 	cpu.Tick(4)
+	interruptedPC := cpu.PC
 	cpu.PUSH_STACK(cpu.PC)
 
 	defer cpu.Tick(4) // Internal (set PC)
+	defer cpu.enterFrame(FrameInterrupt, interruptedPC)
 
 	// Check if interrupt is still requested, otherwise set PC to 0000
 	if !cpu.interruptCancelled {
@@ -83,6 +86,7 @@ func (cpu *CPU) serveInterrupt(interruptMask uint8) bool {
 		IF := cpu.mmu.Read(ifAddr)
 		IF &= ^interruptMask
 		cpu.mmu.Write(ifAddr, IF)
+		cpu.event(timeline.Service, interruptMask)
 		return true
 	} else {
 		cpu.PC = 0
@@ -158,8 +158,20 @@ func (cpu *CPU) WriteByte(addr uint16, v uint8) {
 	cpu.Tick(4)
 }
 
+// fetchByte reads a byte of the instruction being executed, uses 1 M-cycle
+func (cpu *CPU) fetchByte(addr uint16, opcode bool) uint8 {
+	defer cpu.Tick(4)
+	return cpu.mmu.Fetch(addr, opcode)
+}
+
+// ReadNextByte reads an operand of the current instruction
 func (cpu *CPU) ReadNextByte() uint8 {
-	b := cpu.ReadByte(cpu.PC)
+	return cpu.readNextByte(false)
+}
+
+// readNextByte reads the byte at PC as an opcode or as an operand
+func (cpu *CPU) readNextByte(opcode bool) uint8 {
+	b := cpu.fetchByte(cpu.PC, opcode)
 	if cpu.haltBug {
 		// Do not increment PC
 		cpu.haltBug = false
@@ -1,6 +1,7 @@
 package cpu
 
 import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/util"
 	"log"
 )
@@ -583,6 +584,7 @@ func (cpu *CPU) HALT() {
 	}
 
 	cpu.halted = true
+	cpu.event(timeline.Halt, 0)
 }
 
 // ADD A R8
@@ -975,9 +977,7 @@ func (cpu *CPU) RET() {
 	cpu.PC = cpu.POP_STACK()
 	cpu.Tick(4) // Internal (set PC)
 
-	if cpu.retHook != nil {
-		cpu.retHook()
-	}
+	cpu.leaveFrame()
 }
 
 // RETI
@@ -1023,9 +1023,7 @@ func (cpu *CPU) CALL(addr uint16) {
 	cpu.PUSH_STACK(cpu.PC)
 	cpu.PC = addr
 
-	if cpu.callHook != nil {
-		cpu.callHook()
-	}
+	cpu.enterFrame(FrameCall, cpu.instructionPC)
 }
 
 // CALL COND N16
@@ -1055,29 +1053,35 @@ func (cpu *CPU) CALL_N16() {
 }
 
 // RST VEC
+func (cpu *CPU) RST(vec uint16) {
+	cpu.PUSH_STACK(cpu.PC)
+	cpu.PC = vec
+
+	cpu.enterFrame(FrameRST, cpu.instructionPC)
+}
 func (cpu *CPU) RST_00() {
-	cpu.CALL(0x00)
+	cpu.RST(0x00)
 }
 func (cpu *CPU) RST_08() {
-	cpu.CALL(0x08)
+	cpu.RST(0x08)
 }
 func (cpu *CPU) RST_10() {
-	cpu.CALL(0x10)
+	cpu.RST(0x10)
 }
 func (cpu *CPU) RST_18() {
-	cpu.CALL(0x18)
+	cpu.RST(0x18)
 }
 func (cpu *CPU) RST_20() {
-	cpu.CALL(0x20)
+	cpu.RST(0x20)
 }
 func (cpu *CPU) RST_28() {
-	cpu.CALL(0x28)
+	cpu.RST(0x28)
 }
 func (cpu *CPU) RST_30() {
-	cpu.CALL(0x30)
+	cpu.RST(0x30)
 }
 func (cpu *CPU) RST_38() {
-	cpu.CALL(0x38)
+	cpu.RST(0x38)
 }
 
 // LDH_C_A
@@ -1319,7 +1323,7 @@ func (cpu *CPU) SET_B3_R8(bit uint8, opcode uint8) {
 
 // CB prefixed opcodes
 func (cpu *CPU) PREFIX() {
-	opcode := cpu.ReadNextByte()
+	opcode := cpu.readNextByte(true)
 
 	// Execute opcode
 	cpu.prefixedOpcodesTable[opcode>>3](opcode)
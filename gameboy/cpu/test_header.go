@@ -3,6 +3,7 @@ package cpu
 import (
 	"github.com/danielecanzoneri/lucky-boy/gameboy/audio"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/infrared"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
@@ -14,7 +15,7 @@ func mockCPU() *CPU {
 	p := ppu.New(false)
 	a := audio.New(48000, make(chan float32, 10), false)
 	c := &cartridge.MBC1{ROM: make([]uint8, 0x8000), RAM: make([]uint8, 0x2000), RAMBanks: 1, ROMBanks: 1}
-	mem := mmu.New(p, a, timer.New(a), joypad.New(), serial.NewPort(), false)
+	mem := mmu.New(p, a, timer.New(a), joypad.New(), serial.NewPort(), infrared.NewPort(), false)
 	mem.Cartridge = c
 	mem.BootRomDisabled = true
 	mem.Write(0, 0x0A) // Enable RAM
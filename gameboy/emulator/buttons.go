@@ -0,0 +1,50 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+var buttonNames = []struct {
+	name   string
+	button Buttons
+}{
+	{"a", ButtonA}, {"b", ButtonB}, {"select", ButtonSelect}, {"start", ButtonStart},
+	{"right", ButtonRight}, {"left", ButtonLeft}, {"up", ButtonUp}, {"down", ButtonDown},
+}
+
+// ParseButtons parses buttons joined by "+" (e.g. "up+a"), "" and "none" are no button
+func ParseButtons(s string) (Buttons, error) {
+	var buttons Buttons
+	if s == "" || s == "none" {
+		return 0, nil
+	}
+
+	for _, name := range strings.Split(strings.ToLower(s), "+") {
+		found := false
+		for _, b := range buttonNames {
+			if strings.TrimSpace(name) == b.name {
+				buttons |= b.button
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid button %q", name)
+		}
+	}
+	return buttons, nil
+}
+
+// String returns the buttons joined by "+" ("none" if there is none)
+func (b Buttons) String() string {
+	var names []string
+	for _, bn := range buttonNames {
+		if b&bn.button != 0 {
+			names = append(names, bn.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
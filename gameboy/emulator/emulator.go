@@ -0,0 +1,203 @@
+// Package emulator embeds the Game Boy in other programs (bots, test runners, services)
+// without a user interface: it runs frames or cycles, returns the screen as an image and
+// the audio samples produced, and takes the buttons pressed.
+//
+//	emu, err := emulator.New(rom, emulator.WithModel(gameboy.CGB))
+//	if err != nil {
+//		return err
+//	}
+//	emu.SetButtons(emulator.ButtonStart)
+//	emu.RunFrame()
+//	png.Encode(w, emu.Frame())
+//
+// An Emulator must be used from one goroutine at a time
+package emulator
+
+import (
+	"image"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
+)
+
+const (
+	// T-cycles of a frame (at normal speed)
+	frameCycles = 70224
+
+	// Samples buffered while executing an instruction
+	sampleBufferSize = 64
+)
+
+// ErrInvalidROM is returned when the ROM is too small to have a header
+var ErrInvalidROM = cartridge.ErrMissingHeader
+
+// Buttons is a set of joypad buttons
+type Buttons uint8
+
+const (
+	ButtonStart  Buttons = 1 << joypad.KeyStart
+	ButtonSelect Buttons = 1 << joypad.KeySelect
+	ButtonB      Buttons = 1 << joypad.KeyB
+	ButtonA      Buttons = 1 << joypad.KeyA
+	ButtonDown   Buttons = 1 << joypad.KeyDown
+	ButtonUp     Buttons = 1 << joypad.KeyUp
+	ButtonLeft   Buttons = 1 << joypad.KeyLeft
+	ButtonRight  Buttons = 1 << joypad.KeyRight
+)
+
+// Emulator is a Game Boy running a ROM
+type Emulator struct {
+	gb      *gameboy.GameBoy
+	config  config
+	buttons Buttons
+
+	samples chan float32
+	audio   []float32
+
+	frame *image.RGBA
+}
+
+// New returns an emulator running the ROM (after the boot ROM, if set). It fails
+// if the cartridge is not supported (see cartridge.CheckHeader)
+func New(rom []uint8, opts ...Option) (*Emulator, error) {
+	if err := cartridge.CheckHeader(rom); err != nil {
+		return nil, err
+	}
+
+	c := defaultConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	e := &Emulator{config: c, samples: make(chan float32, sampleBufferSize)}
+	e.gb = gameboy.New(e.samples, c.sampleRate)
+	e.gb.Model = c.model
+	e.gb.SetInputProvider(e)
+	e.gb.Load(cartridge.NewCartridge(rom, c.saveData))
+	e.gb.LoadBootROM(c.bootROM)
+	e.gb.APU.Muted = c.sampleRate == 0
+	return e, nil
+}
+
+// GameBoy returns the emulated Game Boy, to access the components, hooks and states
+func (e *Emulator) GameBoy() *gameboy.GameBoy {
+	return e.gb
+}
+
+// Reset restarts the ROM (the battery-backed RAM is kept)
+func (e *Emulator) Reset() {
+	e.gb.Reset()
+	e.gb.APU.Muted = e.config.sampleRate == 0
+}
+
+// SetButtons sets the buttons pressed from the next instruction on
+func (e *Emulator) SetButtons(buttons Buttons) {
+	e.buttons = buttons
+}
+
+// IsKeyPressed implements joypad.InputProvider with the buttons set
+func (e *Emulator) IsKeyPressed(key joypad.Key) bool {
+	return e.buttons&(1<<key) != 0
+}
+
+// RunFrame runs until the next frame is completed (VBlank). With the LCD off,
+// it returns after the time of a frame
+func (e *Emulator) RunFrame() {
+	cycles := uint64(frameCycles)
+	if e.gb.Memory.DoubleSpeed {
+		cycles *= 2
+	}
+
+	e.audio = e.audio[:0]
+	frame := e.gb.PPU.DebugGetFrameCount()
+	start := e.gb.CPU.Cycles()
+	for e.gb.PPU.DebugGetFrameCount() == frame && e.gb.CPU.Cycles()-start < cycles {
+		e.step()
+	}
+}
+
+// RunCycles runs at least the specified number of CPU T-cycles (whole instructions,
+// the CPU executes twice as many cycles per frame at CGB double speed)
+func (e *Emulator) RunCycles(n uint64) {
+	e.audio = e.audio[:0]
+	start := e.gb.CPU.Cycles()
+	for e.gb.CPU.Cycles()-start < n {
+		e.step()
+	}
+}
+
+func (e *Emulator) step() {
+	e.gb.Step()
+	if e.config.sampleRate == 0 {
+		return
+	}
+
+	for {
+		select {
+		case sample := <-e.samples:
+			e.audio = append(e.audio, sample)
+		default:
+			return
+		}
+	}
+}
+
+// Audio returns the samples produced by the last run (stereo interleaved, left first,
+// at the sample rate set). The slice is reused by the next run
+func (e *Emulator) Audio() []float32 {
+	return e.audio
+}
+
+// Frame returns the last frame completed (with the border in SGB mode).
+// The image is reused by the next calls
+func (e *Emulator) Frame() *image.RGBA {
+	if e.gb.SGB != nil {
+		e.frame = e.resize(sgb.ScreenWidth, sgb.ScreenHeight)
+		buffer := e.gb.SGB.GetFrame()
+		for y := range sgb.ScreenHeight {
+			for x := range sgb.ScreenWidth {
+				e.setCGBPixel(x, y, buffer[y][x])
+			}
+		}
+		return e.frame
+	}
+
+	e.frame = e.resize(ppu.FrameWidth, ppu.FrameHeight)
+	buffer, _ := e.gb.PPU.GetFrame()
+	for y := range ppu.FrameHeight {
+		for x := range ppu.FrameWidth {
+			if e.gb.EmulationModel == gameboy.DMG {
+				e.frame.SetRGBA(x, y, e.config.palette[buffer[y][x]&3])
+			} else {
+				e.setCGBPixel(x, y, buffer[y][x])
+			}
+		}
+	}
+	return e.frame
+}
+
+// resize returns the frame image with the specified size
+func (e *Emulator) resize(width, height int) *image.RGBA {
+	if e.frame == nil || e.frame.Rect.Dx() != width || e.frame.Rect.Dy() != height {
+		return image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	return e.frame
+}
+
+// setCGBPixel sets a pixel from an RGB555 color
+func (e *Emulator) setCGBPixel(x, y int, c uint16) {
+	i := e.frame.PixOffset(x, y)
+	for ch := range 3 {
+		v := uint8(c>>(5*ch)) & 0x1F
+		e.frame.Pix[i+ch] = v<<3 | v>>2
+	}
+	e.frame.Pix[i+3] = 0xFF
+}
+
+// SaveData returns the battery-backed RAM of the cartridge (nil if it has none)
+func (e *Emulator) SaveData() []uint8 {
+	return e.gb.Memory.Cartridge.RAMDump()
+}
@@ -0,0 +1,135 @@
+package emulator
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+)
+
+func TestFrame(t *testing.T) {
+	// Background color 0 shown black
+	rom := testrom.New(
+		0x3E, 0x03, // LD A, $03
+		0xE0, 0x47, // LDH (BGP), A
+		0x18, 0xFE, // JR @
+	)
+	emu, err := New(rom, WithModel(gameboy.DMG))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		start := emu.GameBoy().PPU.DebugGetFrameCount()
+		emu.RunFrame()
+		if frames := emu.GameBoy().PPU.DebugGetFrameCount() - start; frames != 1 {
+			t.Fatalf("RunFrame ran %d frames", frames)
+		}
+	}
+
+	frame := emu.Frame()
+	if frame.Rect.Dx() != 160 || frame.Rect.Dy() != 144 {
+		t.Fatalf("frame size %v", frame.Rect)
+	}
+	if c := frame.RGBAAt(80, 72); c != (color.RGBA{A: 0xFF}) {
+		t.Errorf("got color %v, expected black", c)
+	}
+
+	// About a frame of stereo samples
+	if n := len(emu.Audio()); n%2 != 0 || n < 1400 || n > 1560 {
+		t.Errorf("got %d samples in a frame", n)
+	}
+}
+
+func TestCGBFrame(t *testing.T) {
+	// Background palette 0 color 0 set to red
+	rom := testrom.NewCGB(
+		0x3E, 0x80, // LD A, $80
+		0xE0, 0x68, // LDH (BGPI), A
+		0x3E, 0x1F, // LD A, $1F
+		0xE0, 0x69, // LDH (BGPD), A
+		0xAF,       // XOR A
+		0xE0, 0x69, // LDH (BGPD), A
+		0x18, 0xFE, // JR @
+	)
+	emu, err := New(rom, WithSampleRate(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emu.RunFrame()
+	emu.RunFrame()
+
+	if c := emu.Frame().RGBAAt(0, 0); c != (color.RGBA{R: 0xFF, A: 0xFF}) {
+		t.Errorf("got color %v, expected red", c)
+	}
+	if len(emu.Audio()) != 0 {
+		t.Error("samples produced with audio disabled")
+	}
+}
+
+func TestButtons(t *testing.T) {
+	// Copies the d-pad state to $FF80
+	rom := testrom.New(
+		0x3E, 0x20, // loop: LD A, $20
+		0xE0, 0x00, // LDH (P1), A
+		0xF0, 0x00, // LDH A, (P1)
+		0xE0, 0x80, // LDH ($80), A
+		0x18, 0xF6, // JR loop
+	)
+	emu, err := New(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emu.SetButtons(ButtonRight | ButtonA)
+	emu.RunCycles(1000)
+	if v := emu.GameBoy().Memory.DebugRead(0xFF80) & 0x0F; v != 0b1110 {
+		t.Errorf("d-pad with right pressed: got %04b", v)
+	}
+
+	emu.SetButtons(ButtonUp)
+	start := emu.GameBoy().CPU.Cycles()
+	emu.RunCycles(1000)
+	if v := emu.GameBoy().Memory.DebugRead(0xFF80) & 0x0F; v != 0b1011 {
+		t.Errorf("d-pad with up pressed: got %04b", v)
+	}
+	if cycles := emu.GameBoy().CPU.Cycles() - start; cycles < 1000 || cycles >= 1024 {
+		t.Errorf("RunCycles(1000) ran %d cycles", cycles)
+	}
+}
+
+func TestInvalidROM(t *testing.T) {
+	if _, err := New(make([]uint8, 0x100)); err != ErrInvalidROM {
+		t.Errorf("got error %v", err)
+	}
+
+	// Unsupported headers are errors instead of panics
+	for _, header := range []struct{ addr, value uint8 }{
+		{0x47, 0xFC}, // Cartridge type (Pocket Camera)
+		{0x48, 0x20}, // ROM size
+		{0x49, 0x07}, // RAM size
+	} {
+		rom := make([]uint8, 0x8000)
+		rom[0x100|uint16(header.addr)] = header.value
+		if _, err := New(rom); err == nil {
+			t.Errorf("$01%02X=$%02X: no error", header.addr, header.value)
+		}
+	}
+}
+
+func TestParseButtons(t *testing.T) {
+	b, err := ParseButtons("Up+A")
+	if err != nil || b != ButtonUp|ButtonA {
+		t.Errorf("got %v, %v", b, err)
+	}
+	if b.String() != "a+up" {
+		t.Errorf("got %q", b.String())
+	}
+	if b, err := ParseButtons("none"); err != nil || b != 0 || b.String() != "none" {
+		t.Errorf("got %v, %v", b, err)
+	}
+	if _, err := ParseButtons("a+x"); err == nil {
+		t.Error("invalid button accepted")
+	}
+}
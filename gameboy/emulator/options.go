@@ -0,0 +1,56 @@
+package emulator
+
+import (
+	"image/color"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+)
+
+type config struct {
+	model      gameboy.SystemModel
+	bootROM    []uint8
+	saveData   []uint8
+	sampleRate float64
+	palette    [4]color.RGBA
+}
+
+func defaultConfig() config {
+	return config{
+		model:      gameboy.Auto,
+		sampleRate: 44100,
+		palette: [4]color.RGBA{
+			{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF},
+			{R: 0xAA, G: 0xAA, B: 0xAA, A: 0xFF},
+			{R: 0x55, G: 0x55, B: 0x55, A: 0xFF},
+			{A: 0xFF},
+		},
+	}
+}
+
+// Option configures an emulator
+type Option func(*config)
+
+// WithModel sets the model emulated (by default detected from the cartridge header)
+func WithModel(model gameboy.SystemModel) Option {
+	return func(c *config) { c.model = model }
+}
+
+// WithBootROM runs the boot ROM before the game (by default it is skipped)
+func WithBootROM(bootROM []uint8) Option {
+	return func(c *config) { c.bootROM = bootROM }
+}
+
+// WithSaveData loads the battery-backed RAM of the cartridge (see Emulator.SaveData)
+func WithSaveData(data []uint8) Option {
+	return func(c *config) { c.saveData = data }
+}
+
+// WithSampleRate sets the audio sample rate (44100 Hz by default, 0 disables audio)
+func WithSampleRate(rate float64) Option {
+	return func(c *config) { c.sampleRate = rate }
+}
+
+// WithPalette sets the colors of the DMG shades, from the lightest (grayscale by default)
+func WithPalette(palette [4]color.RGBA) Option {
+	return func(c *config) { c.palette = palette }
+}
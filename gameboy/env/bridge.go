@@ -0,0 +1,183 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+)
+
+// Requests of the JSON bridge, one per line:
+//
+//	{"cmd": "make", "rom": "game.gb", "model": "auto", "count": 8, "config": {...}}
+//	    creates count environments (1 by default) with the Config, replacing the previous ones:
+//	    {"envs": 8, "actions": 9, "shape": [72, 80]}
+//	{"cmd": "reset", "env": 0}                  {"observation": "<base64>"}
+//	{"cmd": "step", "env": 0, "action": 1}      {"observation": "<base64>", "reward": 0, "done": false}
+//	{"cmd": "reset_all"}                        {"observations": ["<base64>", ...]}
+//	{"cmd": "step_all", "actions": [1, 2, ...]} {"results": [{"observation": ...}, ...]} (in parallel)
+//	{"cmd": "save", "env": 0}                   {"state": 1}
+//	{"cmd": "load", "env": 0, "state": 1}       {}
+//	{"cmd": "close"}                            {} and the bridge returns
+//
+// Observations are the bytes of the Shape (row by row for the screen) encoded in base64.
+// Errors are returned as {"error": "..."}
+type request struct {
+	Cmd     string `json:"cmd"`
+	ROM     string `json:"rom"`
+	Model   string `json:"model"`
+	Count   int    `json:"count"`
+	Config  Config `json:"config"`
+	Env     int    `json:"env"`
+	Action  int    `json:"action"`
+	Actions []int  `json:"actions"`
+	State   int    `json:"state"`
+}
+
+type response struct {
+	Error string `json:"error,omitempty"`
+
+	// make
+	Envs    int   `json:"envs,omitempty"`
+	Actions int   `json:"actions,omitempty"`
+	Shape   []int `json:"shape,omitempty"`
+
+	// reset and step
+	*Result
+	Observations [][]uint8 `json:"observations,omitempty"`
+	Results      []Result  `json:"results,omitempty"`
+
+	// save
+	State int `json:"state,omitempty"`
+}
+
+// bridge serves the requests of a client
+type bridge struct {
+	vec *Vec
+
+	// Saved states of each environment by id
+	states map[int]*State
+	owners map[int]int
+	nextID int
+}
+
+// Serve runs the JSON bridge for clients in other languages, reading the requests
+// from r and writing the responses to w, until a close request or the end of r
+func Serve(r io.Reader, w io.Writer) error {
+	b := &bridge{states: make(map[int]*State), owners: make(map[int]int)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		var req request
+		resp := &response{}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = err.Error()
+		} else if err := b.handle(&req, resp); err != nil {
+			resp = &response{Error: err.Error()}
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return err
+		}
+		if req.Cmd == "close" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func (b *bridge) handle(req *request, resp *response) error {
+	if req.Cmd == "make" {
+		return b.make(req, resp)
+	}
+	if req.Cmd == "close" {
+		return nil
+	}
+
+	if b.vec == nil {
+		return errors.New("no environment: send make first")
+	}
+	var e *Env
+	switch req.Cmd {
+	case "reset", "step", "save", "load":
+		if req.Env < 0 || req.Env >= len(b.vec.Envs) {
+			return fmt.Errorf("invalid environment %d", req.Env)
+		}
+		e = b.vec.Envs[req.Env]
+	}
+
+	switch req.Cmd {
+	case "reset":
+		obs, err := e.Reset()
+		if err != nil {
+			return err
+		}
+		resp.Result = &Result{Observation: obs}
+	case "step":
+		result, err := e.Step(req.Action)
+		if err != nil {
+			return err
+		}
+		resp.Result = &result
+	case "reset_all":
+		observations, err := b.vec.Reset()
+		if err != nil {
+			return err
+		}
+		resp.Observations = observations
+	case "step_all":
+		results, err := b.vec.Step(req.Actions)
+		if err != nil {
+			return err
+		}
+		resp.Results = results
+	case "save":
+		b.nextID++
+		b.states[b.nextID] = e.Save()
+		b.owners[b.nextID] = req.Env
+		resp.State = b.nextID
+	case "load":
+		s, ok := b.states[req.State]
+		if !ok || b.owners[req.State] != req.Env {
+			return fmt.Errorf("invalid state %d for environment %d", req.State, req.Env)
+		}
+		return e.Load(s)
+	default:
+		return fmt.Errorf("unknown command %q", req.Cmd)
+	}
+	return nil
+}
+
+func (b *bridge) make(req *request, resp *response) error {
+	rom, err := os.ReadFile(req.ROM)
+	if err != nil {
+		return err
+	}
+	if req.Model == "" {
+		req.Model = "auto"
+	}
+	model, err := gameboy.ParseModel(req.Model)
+	if err != nil {
+		return err
+	}
+
+	vec, err := NewVec(max(req.Count, 1), rom, req.Config, emulator.WithModel(model))
+	if err != nil {
+		return err
+	}
+	b.vec = vec
+	clear(b.states)
+	clear(b.owners)
+
+	resp.Envs = len(vec.Envs)
+	resp.Actions = vec.Envs[0].Actions()
+	resp.Shape = vec.Envs[0].Shape()
+	return nil
+}
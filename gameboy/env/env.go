@@ -0,0 +1,237 @@
+// Package env wraps the emulator in a reinforcement learning environment (like Gym):
+// Step presses an action for some frames and returns the observation (the screen
+// downscaled in grayscale or the RAM), the reward computed from memory values and
+// whether the episode is done. States can be saved and loaded to branch episodes.
+//
+// Vec steps many environments in parallel, Serve exposes them to other languages (e.g.
+// Python) with JSON lines, as cmd/lucky-gym does on the standard input and output
+package env
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
+)
+
+const (
+	ScreenObservation = "screen"
+	RAMObservation    = "ram"
+
+	// RAM observed: WRAM (as mapped) and HRAM
+	ramSize = 0x2000 + 0x7F
+)
+
+// DefaultActions are pressed by the actions 0 to 8
+var DefaultActions = []string{"none", "a", "b", "up", "down", "left", "right", "start", "select"}
+
+// ErrInvalidAction is returned when stepping with an action out of range
+var ErrInvalidAction = errors.New("invalid action")
+
+// Config of an environment, the zero value uses the defaults
+type Config struct {
+	FrameSkip   int         `json:"frame_skip"`  // Frames run by each step (4 by default)
+	Observation string      `json:"observation"` // "screen" (default) or "ram"
+	Width       int         `json:"width"`       // Size of the screen observation (80x72 by default)
+	Height      int         `json:"height"`
+	Actions     []string    `json:"actions"`   // Buttons of each action (e.g. "up+a", DefaultActions if empty)
+	Rewards     []Reward    `json:"rewards"`   // Summed to compute the reward of a step
+	Done        []Condition `json:"done"`      // Any of them ends the episode
+	MaxSteps    int         `json:"max_steps"` // Steps after which the episode is done (0 for no limit)
+}
+
+// Result of a step
+type Result struct {
+	Observation []uint8 `json:"observation"`
+	Reward      float64 `json:"reward"`
+	Done        bool    `json:"done"`
+}
+
+// Env is an environment running a ROM. Different environments can run in parallel
+type Env struct {
+	emu     *emulator.Emulator
+	config  Config
+	actions []emulator.Buttons
+
+	// Steps since the reset and last values of the rewards
+	steps  int
+	values []float64
+
+	initial *State
+}
+
+// State is a saved environment (see Save)
+type State struct {
+	gb     *gameboy.State
+	steps  int
+	values []float64
+}
+
+// New returns an environment running the ROM, reset restores the state after the boot.
+// Audio is disabled unless an option sets the sample rate
+func New(rom []uint8, config Config, opts ...emulator.Option) (*Env, error) {
+	if config.FrameSkip <= 0 {
+		config.FrameSkip = 4
+	}
+	if config.Observation == "" {
+		config.Observation = ScreenObservation
+	}
+	if config.Observation != ScreenObservation && config.Observation != RAMObservation {
+		return nil, fmt.Errorf("invalid observation %q", config.Observation)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		config.Width, config.Height = ppu.FrameWidth/2, ppu.FrameHeight/2
+	}
+	if len(config.Actions) == 0 {
+		config.Actions = DefaultActions
+	}
+
+	e := &Env{config: config}
+	for _, a := range config.Actions {
+		buttons, err := emulator.ParseButtons(a)
+		if err != nil {
+			return nil, err
+		}
+		e.actions = append(e.actions, buttons)
+	}
+
+	emu, err := emulator.New(rom, append([]emulator.Option{emulator.WithSampleRate(0)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	e.emu = emu
+	e.values = e.rewardValues()
+	e.initial = e.Save()
+	return e, nil
+}
+
+// Emulator returns the emulator of the environment
+func (e *Env) Emulator() *emulator.Emulator {
+	return e.emu
+}
+
+// Actions returns the number of actions
+func (e *Env) Actions() int {
+	return len(e.actions)
+}
+
+// Shape returns the size of the observations: height and width of the screen or RAM size
+func (e *Env) Shape() []int {
+	if e.config.Observation == RAMObservation {
+		return []int{ramSize}
+	}
+	return []int{e.config.Height, e.config.Width}
+}
+
+// Reset restores the initial state and returns the observation. It fails
+// if the emulator was reset since the environment was created
+func (e *Env) Reset() ([]uint8, error) {
+	if err := e.Load(e.initial); err != nil {
+		return nil, err
+	}
+	return e.Observe(), nil
+}
+
+// Step presses the action for the frames to skip and returns the result
+func (e *Env) Step(action int) (Result, error) {
+	if action < 0 || action >= len(e.actions) {
+		return Result{}, ErrInvalidAction
+	}
+
+	e.emu.SetButtons(e.actions[action])
+	for range e.config.FrameSkip {
+		e.emu.RunFrame()
+	}
+	e.steps++
+
+	values := e.rewardValues()
+	var reward float64
+	for i, r := range e.config.Rewards {
+		reward += r.scale() * (values[i] - e.values[i])
+	}
+	e.values = values
+
+	done := e.config.MaxSteps > 0 && e.steps >= e.config.MaxSteps
+	for _, c := range e.config.Done {
+		done = done || c.met(e.read)
+	}
+
+	return Result{Observation: e.Observe(), Reward: reward, Done: done}, nil
+}
+
+// Save saves the state of the environment
+func (e *Env) Save() *State {
+	return &State{gb: e.emu.GameBoy().SaveState(), steps: e.steps, values: e.values}
+}
+
+// Load restores a state saved by this environment
+func (e *Env) Load(s *State) error {
+	if err := e.emu.GameBoy().LoadState(s.gb); err != nil {
+		return err
+	}
+	e.steps, e.values = s.steps, s.values
+	return nil
+}
+
+func (e *Env) read(addr uint16) uint8 {
+	return e.emu.GameBoy().Memory.DebugRead(addr)
+}
+
+func (e *Env) rewardValues() []float64 {
+	values := make([]float64, len(e.config.Rewards))
+	for i, r := range e.config.Rewards {
+		values[i] = r.value(e.read)
+	}
+	return values
+}
+
+// Observe returns the current observation
+func (e *Env) Observe() []uint8 {
+	if e.config.Observation == RAMObservation {
+		obs := make([]uint8, 0, ramSize)
+		for addr := 0xC000; addr < 0xE000; addr++ {
+			obs = append(obs, e.read(uint16(addr)))
+		}
+		for addr := 0xFF80; addr < 0xFFFF; addr++ {
+			obs = append(obs, e.read(uint16(addr)))
+		}
+		return obs
+	}
+	return e.screen()
+}
+
+// screen returns the Game Boy screen in grayscale, averaging the pixels of each cell
+func (e *Env) screen() []uint8 {
+	frame := e.emu.Frame()
+	if e.emu.GameBoy().SGB != nil {
+		frame = frame.SubImage(image.Rect(
+			sgb.GameX, sgb.GameY, sgb.GameX+ppu.FrameWidth, sgb.GameY+ppu.FrameHeight,
+		)).(*image.RGBA)
+	}
+	bounds := frame.Bounds()
+	width, height := e.config.Width, e.config.Height
+
+	obs := make([]uint8, width*height)
+	for y := range height {
+		y0, y1 := y*bounds.Dy()/height, max((y+1)*bounds.Dy()/height, y*bounds.Dy()/height+1)
+		for x := range width {
+			x0, x1 := x*bounds.Dx()/width, max((x+1)*bounds.Dx()/width, x*bounds.Dx()/width+1)
+
+			var sum, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := frame.PixOffset(bounds.Min.X+sx, bounds.Min.Y+sy)
+					r, g, b := int(frame.Pix[i]), int(frame.Pix[i+1]), int(frame.Pix[i+2])
+					sum += (299*r + 587*g + 114*b) / 1000
+					n++
+				}
+			}
+			obs[y*width+x] = uint8(sum / n)
+		}
+	}
+	return obs
+}
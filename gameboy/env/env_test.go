@@ -0,0 +1,217 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+)
+
+// Counts the frames at $C000 and copies the buttons to $C001
+func testROM() []uint8 {
+	rom := testrom.New(
+		0xAF,             // XOR A
+		0xEA, 0x00, 0xC0, // LD ($C000), A
+		0x3C,       // INC A
+		0xE0, 0xFF, // LDH (IE), A (VBlank interrupt)
+		0xFB,       // EI
+		0x76,       // loop: HALT
+		0x3E, 0x10, // LD A, $10 (buttons)
+		0xE0, 0x00, // LDH (P1), A
+		0xF0, 0x00, // LDH A, (P1)
+		0xEA, 0x01, 0xC0, // LD ($C001), A
+		0x18, 0xF3, // JR loop
+	)
+	copy(rom[0x40:], []uint8{
+		0x21, 0x00, 0xC0, // LD HL, $C000
+		0x34, // INC (HL)
+		0xD9, // RETI
+	})
+	return rom
+}
+
+func TestStep(t *testing.T) {
+	config := Config{
+		Rewards: []Reward{{Addr: 0xC000, Scale: 0.5}},
+		Done:    []Condition{{Addr: 0xC001, Value: 0, Mask: 0x01}}, // A pressed
+	}
+	e, err := New(testROM(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Actions() != len(DefaultActions) || !slices.Equal(e.Shape(), []int{72, 80}) {
+		t.Errorf("got %d actions, shape %v", e.Actions(), e.Shape())
+	}
+
+	obs, err := e.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(obs) != 80*72 {
+		t.Fatalf("observation of %d bytes", len(obs))
+	}
+
+	// 4 frames per step
+	r, err := e.Step(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ = e.Step(0)
+	if r.Reward != 2 || r.Done {
+		t.Errorf("got reward %v, done %v", r.Reward, r.Done)
+	}
+
+	// Branch: pressing A ends the episode
+	s := e.Save()
+	r, _ = e.Step(1)
+	if !r.Done {
+		t.Error("episode not done with A pressed")
+	}
+	if err := e.Load(s); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = e.Step(0)
+	if r.Reward != 2 || r.Done {
+		t.Errorf("after loading: got reward %v, done %v", r.Reward, r.Done)
+	}
+
+	if _, err := e.Step(len(DefaultActions)); err != ErrInvalidAction {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestRAMObservation(t *testing.T) {
+	e, err := New(testROM(), Config{Observation: RAMObservation, FrameSkip: 1, MaxSteps: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r Result
+	for i := range 3 {
+		r, _ = e.Step(0)
+		if r.Done != (i == 2) {
+			t.Errorf("step %d: done %v", i, r.Done)
+		}
+	}
+	if len(r.Observation) != ramSize || r.Observation[0] != 3 {
+		t.Errorf("got %d bytes, frames %d", len(r.Observation), r.Observation[0])
+	}
+}
+
+func TestRewardValue(t *testing.T) {
+	memory := map[uint16]uint8{0xC000: 0x12, 0xC001: 0x34}
+	read := func(addr uint16) uint8 { return memory[addr] }
+
+	for _, test := range []struct {
+		reward Reward
+		value  float64
+	}{
+		{Reward{Addr: 0xC000}, 0x12},
+		{Reward{Addr: 0xC000, Bytes: 2}, 0x3412},
+		{Reward{Addr: 0xC000, Bytes: 2, BigEndian: true}, 0x1234},
+		{Reward{Addr: 0xC000, Bytes: 2, BigEndian: true, BCD: true}, 1234},
+	} {
+		if v := test.reward.value(read); v != test.value {
+			t.Errorf("%+v: got %v, expected %v", test.reward, v, test.value)
+		}
+	}
+}
+
+func TestVec(t *testing.T) {
+	v, err := NewVec(4, testROM(), Config{Observation: RAMObservation, FrameSkip: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := v.Step([]int{0, 1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		// Frames counted, A is the only button read
+		aReleased := r.Observation[1]&1 != 0
+		if r.Observation[0] != 2 || aReleased != (i != 1) {
+			t.Errorf("env %d: got $C000=%d $C001=%02X", i, r.Observation[0], r.Observation[1])
+		}
+	}
+
+	if _, err := v.Step([]int{0}); err != ErrInvalidAction {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	rom := filepath.Join(t.TempDir(), "test.gb")
+	if err := os.WriteFile(rom, testROM(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []string{
+		`{"cmd": "step", "env": 0, "action": 0}`,
+		`{"cmd": "make", "rom": ` + strings.ReplaceAll(`"`+rom+`"`, `\`, `\\`) + `, "count": 2,
+			"config": {"observation": "ram", "frame_skip": 1, "rewards": [{"addr": 49152}]}}`,
+		`{"cmd": "step", "env": 1, "action": 0}`,
+		`{"cmd": "save", "env": 1}`,
+		`{"cmd": "step_all", "actions": [0, 0]}`,
+		`{"cmd": "load", "env": 1, "state": 1}`,
+		`{"cmd": "load", "env": 0, "state": 1}`,
+		`{"cmd": "reset", "env": 1}`,
+		`{"cmd": "close"}`,
+		`{"cmd": "reset", "env": 1}`,
+	}
+	for i, r := range requests {
+		requests[i] = strings.ReplaceAll(r, "\n", "")
+	}
+
+	var out strings.Builder
+	if err := Serve(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []response
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 9 {
+		t.Fatalf("got %d responses, expected 9", len(responses))
+	}
+
+	if responses[0].Error == "" {
+		t.Error("step before make accepted")
+	}
+	if r := responses[1]; r.Error != "" || r.Envs != 2 || r.Actions != len(DefaultActions) || !slices.Equal(r.Shape, []int{ramSize}) {
+		t.Errorf("make: got %+v", r)
+	}
+	if r := responses[2]; r.Result == nil || r.Reward != 1 || len(r.Observation) != ramSize {
+		t.Errorf("step: got %+v", r)
+	}
+	if r := responses[3]; r.State != 1 {
+		t.Errorf("save: got %+v", r)
+	}
+	if r := responses[4]; len(r.Results) != 2 || r.Results[0].Observation[0] != 1 || r.Results[1].Observation[0] != 2 {
+		t.Errorf("step_all: got %+v", r)
+	}
+	if r := responses[5]; r.Error != "" {
+		t.Errorf("load: got %+v", r)
+	}
+	if r := responses[6]; r.Error == "" {
+		t.Error("state of another environment loaded")
+	}
+	if r := responses[7]; r.Result == nil || r.Observation[0] != 0 {
+		t.Errorf("reset: got %+v", r)
+	}
+}
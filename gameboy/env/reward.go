@@ -0,0 +1,58 @@
+package env
+
+// Reward is the change of a value in memory during a step, multiplied by Scale
+type Reward struct {
+	Addr      uint16  `json:"addr"`
+	Bytes     int     `json:"bytes"`      // Size of the value (1 by default)
+	BigEndian bool    `json:"big_endian"` // Most significant byte first (little-endian by default)
+	BCD       bool    `json:"bcd"`        // Two decimal digits per byte (e.g. scores)
+	Scale     float64 `json:"scale"`      // 1 if 0, negative to penalize increments
+}
+
+func (r Reward) scale() float64 {
+	if r.Scale == 0 {
+		return 1
+	}
+	return r.Scale
+}
+
+// value reads the value from memory
+func (r Reward) value(read func(addr uint16) uint8) float64 {
+	n := max(r.Bytes, 1)
+	base := 256.0
+	if r.BCD {
+		base = 100
+	}
+
+	var v float64
+	for i := range n {
+		// From the most significant byte
+		addr := r.Addr + uint16(i)
+		if !r.BigEndian {
+			addr = r.Addr + uint16(n-1-i)
+		}
+
+		b := read(addr)
+		digits := float64(b)
+		if r.BCD {
+			digits = float64(b>>4*10 + b&0xF)
+		}
+		v = v*base + digits
+	}
+	return v
+}
+
+// Condition is met when the byte at Addr, masked, equals Value
+type Condition struct {
+	Addr  uint16 `json:"addr"`
+	Value uint8  `json:"value"`
+	Mask  uint8  `json:"mask"` // 0xFF if 0
+}
+
+func (c Condition) met(read func(addr uint16) uint8) bool {
+	mask := c.Mask
+	if mask == 0 {
+		mask = 0xFF
+	}
+	return read(c.Addr)&mask == c.Value
+}
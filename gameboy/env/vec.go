@@ -0,0 +1,70 @@
+package env
+
+import (
+	"sync"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+)
+
+// Vec is a set of environments stepped in parallel, one goroutine each
+type Vec struct {
+	Envs []*Env
+}
+
+// NewVec returns n environments running the ROM (see New)
+func NewVec(n int, rom []uint8, config Config, opts ...emulator.Option) (*Vec, error) {
+	v := &Vec{Envs: make([]*Env, n)}
+	for i := range v.Envs {
+		e, err := New(rom, config, opts...)
+		if err != nil {
+			return nil, err
+		}
+		v.Envs[i] = e
+	}
+	return v, nil
+}
+
+// Reset resets every environment and returns their observations
+func (v *Vec) Reset() ([][]uint8, error) {
+	observations := make([][]uint8, len(v.Envs))
+	err := v.parallel(func(i int, e *Env) (err error) {
+		observations[i], err = e.Reset()
+		return
+	})
+	return observations, err
+}
+
+// Step steps each environment with its action
+func (v *Vec) Step(actions []int) ([]Result, error) {
+	if len(actions) != len(v.Envs) {
+		return nil, ErrInvalidAction
+	}
+
+	results := make([]Result, len(v.Envs))
+	err := v.parallel(func(i int, e *Env) (err error) {
+		results[i], err = e.Step(actions[i])
+		return
+	})
+	return results, err
+}
+
+// parallel calls f for each environment in its own goroutine and returns the first error
+func (v *Vec) parallel(f func(i int, e *Env) error) error {
+	errs := make([]error, len(v.Envs))
+	var wg sync.WaitGroup
+	for i, e := range v.Envs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = f(i, e)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,16 +1,24 @@
 package gameboy
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy/audio"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cdl"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cheats"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/cpu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/infrared"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/profiler"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/serial"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/timer"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/trace"
 )
 
 type SystemModel int
@@ -19,24 +27,79 @@ const (
 	Auto SystemModel = iota
 	DMG
 	CGB
+	SGB
 )
 
+// ParseModel parses a model name (auto, dmg, cgb or sgb)
+func ParseModel(name string) (SystemModel, error) {
+	switch name {
+	case "auto":
+		return Auto, nil
+	case "dmg":
+		return DMG, nil
+	case "cgb":
+		return CGB, nil
+	case "sgb":
+		return SGB, nil
+	default:
+		return Auto, fmt.Errorf("invalid model type: %s", name)
+	}
+}
+
 type GameBoy struct {
 	CPU        *cpu.CPU
 	SerialPort *serial.Port
+	Infrared   *infrared.Port
 	Timer      *timer.Timer
 	Memory     *mmu.MMU
 	PPU        *ppu.PPU
 	Joypad     *joypad.Joypad
 	APU        *audio.APU
 
-	// DMG or CGB (Auto to automatically detect it based on cartridge)
+	// Super Game Boy (only when emulating SGB)
+	SGB *sgb.SGB
+
+	// DMG, CGB or SGB (Auto to automatically detect it based on cartridge)
 	Model          SystemModel
 	EmulationModel SystemModel // Actual model used to emulate
 
 	// Input provider for detecting key presses
 	inputProvider joypad.InputProvider
 
+	// Transport attached to the infrared port (kept across resets)
+	infraredTransport infrared.Transport
+
+	// Called when the rumble motor of the cartridge is turned on or off (kept across resets)
+	rumbleCallback func(on bool)
+
+	// Code/data logger (nil if disabled, kept across resets of the same ROM)
+	CDL        *cdl.Logger
+	cdlEnabled bool
+
+	// Instruction tracer (nil if disabled, kept across resets)
+	Tracer *trace.Tracer
+
+	// Hardware events recorder (nil if disabled, kept across resets)
+	Timeline *timeline.Recorder
+
+	// CPU profiler (nil if disabled, kept across resets)
+	Profiler *profiler.Profiler
+
+	// Cheats of the loaded ROM (see SetCheats)
+	Cheats *cheats.List
+
+	// Execution history for reverse debugging (nil if disabled, cleared by resets)
+	History        *History
+	historyEnabled bool
+
+	// Script callbacks (kept across resets)
+	hooks Hooks
+
+	// States saved and loaded by hooks while an instruction executes (see SaveState)
+	stepping     bool
+	pendingSaves []*State
+	pendingLoad  *State
+
 	sampleRate float64
 	sampleBuff chan float32
 }
@@ -53,6 +116,56 @@ func New(audioSampleBuffer chan float32, sampleRate float64) *GameBoy {
 // SetInputProvider sets the input provider for detecting key presses
 func (gb *GameBoy) SetInputProvider(provider joypad.InputProvider) {
 	gb.inputProvider = provider
+	if gb.Joypad != nil && gb.History == nil {
+		gb.Joypad.SetInputProvider(provider)
+	}
+}
+
+// SetRumbleCallback sets the function called when the cartridge rumble motor is turned
+// on or off (not while the history replays instructions already executed)
+func (gb *GameBoy) SetRumbleCallback(callback func(on bool)) {
+	gb.rumbleCallback = callback
+}
+
+// rumbleChanged is called by rumble cartridges when the motor state changes
+func (gb *GameBoy) rumbleChanged(on bool) {
+	if gb.replaying() {
+		return
+	}
+	if gb.History != nil {
+		gb.History.recordRumble(on)
+	}
+	if gb.rumbleCallback != nil {
+		gb.rumbleCallback(on)
+	}
+}
+
+// SetInfraredTransport attaches the infrared port to a transport (nil to detach it)
+func (gb *GameBoy) SetInfraredTransport(transport infrared.Transport) {
+	gb.infraredTransport = transport
+	if gb.Infrared != nil {
+		gb.Infrared.Transport = transport
+	}
+}
+
+// EnableCDL starts logging code and data accesses of the loaded ROM (and of the ones loaded next)
+func (gb *GameBoy) EnableCDL() {
+	gb.cdlEnabled = true
+	if gb.Memory != nil && gb.CDL == nil {
+		gb.CDL = newCDL(gb.Memory.Cartridge)
+		gb.Memory.SetCDL(gb.CDL)
+	}
+}
+
+func newCDL(rom cartridge.Cartridge) *cdl.Logger {
+	header := rom.Header()
+	data := make([]uint8, 0, header.ROMBanks*0x4000)
+	for bank := range header.ROMBanks {
+		for addr := range uint16(0x4000) {
+			data = append(data, rom.ReadROMBank(bank, addr))
+		}
+	}
+	return cdl.New(data, int(header.RAMBanks)*0x2000)
 }
 
 func (gb *GameBoy) initComponents(rom cartridge.Cartridge) {
@@ -62,19 +175,29 @@ func (gb *GameBoy) initComponents(rom cartridge.Cartridge) {
 	gb.Joypad = joypad.New()
 	gb.APU = audio.New(gb.sampleRate, gb.sampleBuff, isCGB)
 	gb.SerialPort = serial.NewPort()
+	gb.Infrared = infrared.NewPort()
+	gb.Infrared.Transport = gb.infraredTransport
 	gb.Timer = timer.New(gb.APU)
 
-	gb.Memory = mmu.New(gb.PPU, gb.APU, gb.Timer, gb.Joypad, gb.SerialPort, isCGB)
+	gb.Memory = mmu.New(gb.PPU, gb.APU, gb.Timer, gb.Joypad, gb.SerialPort, gb.Infrared, isCGB)
 	gb.CPU = cpu.New(gb.Memory, gb.PPU, isCGB)
 	gb.Memory.IsCPUHalted = gb.CPU.Halted
 	gb.Timer.DIVGlitched = gb.CPU.SpeedSwitchHalted
-	gb.CPU.AddTicker(gb.SerialPort, gb.Timer, gb.PPU, gb.Memory, gb.APU)
+	gb.CPU.AddTicker(gb.SerialPort, gb.Infrared, gb.Timer, gb.PPU, gb.Memory, gb.APU)
+	gb.installHooks()
+	gb.installTimelineHooks()
 
 	// Load ROM into memory
 	gb.Memory.Cartridge = rom
 
-	// Set input provider
-	gb.Joypad.SetInputProvider(gb.inputProvider)
+	// Set input provider (the history records it)
+	gb.History = nil
+	if gb.historyEnabled {
+		gb.History = newHistory(gb)
+		gb.Joypad.SetInputProvider(gb.History)
+	} else {
+		gb.Joypad.SetInputProvider(gb.inputProvider)
+	}
 
 	// Set interrupts request handler
 	gb.PPU.RequestVBlankInterrupt = func() { gb.CPU.RequestInterrupt(cpu.VBlankInterruptMask) }
@@ -82,6 +205,74 @@ func (gb *GameBoy) initComponents(rom cartridge.Cartridge) {
 	gb.Timer.RequestInterrupt = func() { gb.CPU.RequestInterrupt(cpu.TimerInterruptMask) }
 	gb.SerialPort.RequestInterrupt = func() { gb.CPU.RequestInterrupt(cpu.SerialInterruptMask) }
 	gb.Joypad.RequestInterrupt = func() { gb.CPU.RequestInterrupt(cpu.JoypadInterruptMask) }
+
+	// Super Game Boy receives commands through the joypad and colorizes frames
+	gb.SGB = nil
+	if gb.EmulationModel == SGB {
+		gb.SGB = sgb.New(rom.Header().SgbSupport)
+		gb.Joypad.WriteCallback = gb.SGB.Write
+		gb.Joypad.JoypadID = gb.SGB.JoypadID
+	}
+	gb.PPU.FrameCallback = gb.frameCompleted
+
+	gb.installCheats()
+}
+
+func (gb *GameBoy) frameCompleted() {
+	if gb.SGB != nil {
+		frame, _ := gb.PPU.GetFrame()
+		gb.SGB.FrameCompleted(frame)
+	}
+
+	// GameShark codes are applied every frame
+	if gb.Cheats != nil {
+		gb.Cheats.ApplyRAM(gb.Memory)
+	}
+
+	if hook := gb.hooks.Frame; hook != nil && !gb.replaying() {
+		hook()
+	}
+}
+
+// SetCheats applies the cheats to the emulation (nil to remove them)
+func (gb *GameBoy) SetCheats(list *cheats.List) {
+	gb.Cheats = list
+	if gb.Memory != nil {
+		gb.installCheats()
+	}
+}
+
+func (gb *GameBoy) installCheats() {
+	if gb.Cheats == nil {
+		gb.Memory.SetROMPatch(nil)
+		return
+	}
+	gb.Memory.SetROMPatch(gb.Cheats.PatchROM)
+}
+
+// Step polls the joypad and executes an instruction
+func (gb *GameBoy) Step() {
+	h := gb.History
+	if h != nil {
+		h.beforeStep()
+	}
+
+	gb.Joypad.DetectKeysPressed()
+	gb.stepping = true
+	if p := gb.Profiler; p != nil && (h == nil || !h.replaying) {
+		// Instructions replayed were already counted
+		gb.profileInstruction(p)
+	} else {
+		gb.CPU.ExecuteInstruction()
+	}
+	gb.stepping = false
+
+	if h != nil {
+		h.steps++
+	}
+	if len(gb.pendingSaves) > 0 || gb.pendingLoad != nil {
+		gb.completeStep()
+	}
 }
 
 func (gb *GameBoy) Reset() {
@@ -101,8 +292,8 @@ func (gb *GameBoy) Load(rom cartridge.Cartridge) {
 		} else {
 			gb.EmulationModel = CGB
 		}
-	} else if gb.Model == DMG {
-		gb.EmulationModel = DMG
+	} else if gb.Model == DMG || gb.Model == SGB {
+		gb.EmulationModel = gb.Model
 
 		if rom.Header().CgbMode == cartridge.CgbOnly {
 			log.Println("WARNING: DMG doesn't support CGB only games, running as CGB")
@@ -111,12 +302,22 @@ func (gb *GameBoy) Load(rom cartridge.Cartridge) {
 		gb.EmulationModel = CGB
 	}
 
+	// A new ROM starts a new code/data log
+	if gb.cdlEnabled && (gb.CDL == nil || gb.Memory.Cartridge != rom) {
+		gb.CDL = newCDL(rom)
+	}
+
 	gb.initComponents(rom)
+	gb.Memory.SetCDL(gb.CDL)
 
 	// MBC3 RTC clocking
 	if c, ok := rom.(cpu.Ticker); ok {
 		gb.CPU.AddTicker(c)
 	}
+
+	if r, ok := rom.(cartridge.Rumbler); ok {
+		r.SetRumbleCallback(gb.rumbleChanged)
+	}
 }
 
 func (gb *GameBoy) LoadBootROM(bootRom []uint8) {
@@ -132,7 +333,13 @@ func (gb *GameBoy) LoadBootROM(bootRom []uint8) {
 func (gb *GameBoy) skipBootROM() {
 	gb.Memory.DisableBootROM()
 
-	if gb.EmulationModel == DMG {
+	if gb.EmulationModel == SGB {
+		gb.CPU.SkipSGBBoot()
+		gb.Timer.SkipDMGBoot()
+		gb.Memory.SkipBoot()
+		gb.PPU.SkipDMGBoot()
+		gb.APU.SkipBoot()
+	} else if gb.EmulationModel == DMG {
 		gb.CPU.SkipDMGBoot()
 		gb.Timer.SkipDMGBoot()
 		gb.Memory.SkipBoot()
@@ -0,0 +1,318 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	errReply = "E01"
+	okReply  = "OK"
+)
+
+// handle executes a packet and returns the reply. If resumed is true, the reply is
+// the stop reason sent when the target halts; if quit is true the session ends
+func (s *Server) handle(c *conn, p string) (reply string, resumed, quit bool) {
+	if p == interruptPacket {
+		return s.interrupt(), false, false
+	}
+	if p == "" {
+		return "", false, false
+	}
+
+	args := p[1:]
+	switch p[0] {
+	case '?':
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.stopReason, false, false
+
+	case 'q':
+		return s.query(args), false, false
+
+	case 'Q':
+		if args == "StartNoAckMode" {
+			c.noAck.Store(true)
+			return okReply, false, false
+		}
+		return "", false, false
+
+	case 'H', 'T':
+		// Single thread
+		return okReply, false, false
+
+	case 'g':
+		return s.readRegisters(), false, false
+	case 'G':
+		return s.writeRegisters(args), false, false
+	case 'p':
+		return s.readRegisterPacket(args), false, false
+	case 'P':
+		return s.writeRegisterPacket(args), false, false
+
+	case 'm':
+		return s.readMemoryPacket(args), false, false
+	case 'M':
+		return s.writeMemoryPacket(args), false, false
+
+	case 'Z', 'z':
+		return s.breakpointPacket(p[0] == 'Z', args), false, false
+
+	case 'c', 's':
+		return s.resumePacket(p[0], args)
+
+	case 'v':
+		return s.vPacket(args)
+
+	case 'D':
+		return okReply, false, true
+	case 'k':
+		return "", false, true
+	}
+
+	// Unsupported
+	return "", false, false
+}
+
+func (s *Server) query(args string) string {
+	name, params, _ := strings.Cut(args, ":")
+	switch name {
+	case "Supported":
+		return "PacketSize=4000;qXfer:features:read+;swbreak+;hwbreak+;QStartNoAckMode+;vContSupported+"
+	case "Attached":
+		return "1"
+	case "C":
+		return "QC1"
+	case "fThreadInfo":
+		return "m1"
+	case "sThreadInfo":
+		return "l"
+	case "Symbol":
+		return okReply
+	case "Xfer":
+		return xferFeatures(params)
+	}
+	return ""
+}
+
+// xferFeatures replies to qXfer:features:read:target.xml:offset,length
+func xferFeatures(params string) string {
+	object, rest, _ := strings.Cut(params, ":")
+	if object != "features" || !strings.HasPrefix(rest, "read:") {
+		return ""
+	}
+	annex, rest, _ := strings.Cut(strings.TrimPrefix(rest, "read:"), ":")
+	if annex != "target.xml" {
+		return "E00"
+	}
+
+	offset, length, err := parseAddressLength(rest)
+	if err != nil || offset > uint32(len(targetXML)) {
+		return errReply
+	}
+	data := targetXML[offset:]
+	if len(data) > length {
+		return "m" + data[:length]
+	}
+	return "l" + data
+}
+
+func (s *Server) readRegisters() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b []byte
+	for n := range numRegisters {
+		b = appendRegister(b, s.readRegister(n))
+	}
+	return string(b)
+}
+
+func (s *Server) writeRegisters(args string) string {
+	if len(args) < 4*numRegisters {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for n := range numRegisters {
+		v, err := parseRegister(args[4*n : 4*n+4])
+		if err != nil {
+			return errReply
+		}
+		s.writeRegister(n, v)
+	}
+	return okReply
+}
+
+func (s *Server) readRegisterPacket(args string) string {
+	n, err := strconv.ParseUint(args, 16, 8)
+	if err != nil || n >= numRegisters {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(appendRegister(nil, s.readRegister(int(n))))
+}
+
+func (s *Server) writeRegisterPacket(args string) string {
+	reg, value, _ := strings.Cut(args, "=")
+	n, err := strconv.ParseUint(reg, 16, 8)
+	if err != nil || n >= numRegisters {
+		return errReply
+	}
+	v, err := parseRegister(value)
+	if err != nil {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeRegister(int(n), v)
+	return okReply
+}
+
+// parseAddressLength parses "ADDR,LENGTH" (hexadecimal)
+func parseAddressLength(s string) (uint32, int, error) {
+	a, l, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid address and length %q", s)
+	}
+	addr, err := strconv.ParseUint(a, 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := strconv.ParseUint(l, 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(addr), int(length), nil
+}
+
+func (s *Server) readMemoryPacket(args string) string {
+	addr, length, err := parseAddressLength(args)
+	if err != nil {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = s.readMemory(addr + uint32(i))
+	}
+	return hex.EncodeToString(data)
+}
+
+func (s *Server) writeMemoryPacket(args string) string {
+	header, values, _ := strings.Cut(args, ":")
+	addr, length, err := parseAddressLength(header)
+	if err != nil {
+		return errReply
+	}
+	data, err := hex.DecodeString(values)
+	if err != nil || len(data) != length {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, v := range data {
+		s.writeMemory(addr+uint32(i), v)
+	}
+	return okReply
+}
+
+// breakpointPacket inserts or removes a breakpoint (types 0 and 1)
+// or a watchpoint (2: write, 3: read, 4: access): "TYPE,ADDR,KIND"
+func (s *Server) breakpointPacket(insert bool, args string) string {
+	t, rest, _ := strings.Cut(args, ",")
+	addr, length, err := parseAddressLength(rest)
+	if err != nil {
+		return errReply
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch t {
+	case "0", "1":
+		if insert {
+			s.breakpoints[addr] = struct{}{}
+		} else {
+			delete(s.breakpoints, addr)
+		}
+		return okReply
+
+	case "2", "3", "4":
+		kind := watchKind(t[0] - '0')
+		wp := watchpoint{kind: kind, start: uint16(addr), length: uint16(max(length, 1))}
+		if insert {
+			s.watchpoints = append(s.watchpoints, wp)
+			s.gb.Memory.SetAccessHook(accessHookOwner, s.onMemoryAccess)
+			return okReply
+		}
+
+		for i, w := range s.watchpoints {
+			if w == wp {
+				s.watchpoints = append(s.watchpoints[:i], s.watchpoints[i+1:]...)
+				break
+			}
+		}
+		if len(s.watchpoints) == 0 {
+			s.gb.Memory.SetAccessHook(accessHookOwner, nil)
+		}
+		return okReply
+	}
+	return ""
+}
+
+// resumePacket continues (c) or executes an instruction (s), optionally from a new address
+func (s *Server) resumePacket(cmd byte, args string) (string, bool, bool) {
+	if args != "" {
+		addr, err := strconv.ParseUint(args, 16, 16)
+		if err != nil {
+			return errReply, false, false
+		}
+		s.mu.Lock()
+		s.gb.CPU.PC = uint16(addr)
+		s.mu.Unlock()
+	}
+
+	if cmd == 's' {
+		s.resume(stepping)
+	} else {
+		s.resume(running)
+	}
+	return "", true, false
+}
+
+// vPacket handles vCont (only the first action is used, there is a single thread)
+func (s *Server) vPacket(args string) (string, bool, bool) {
+	switch {
+	case args == "Cont?":
+		return "vCont;c;C;s;S", false, false
+
+	case strings.HasPrefix(args, "Cont;"):
+		action, _, _ := strings.Cut(strings.TrimPrefix(args, "Cont;"), ";")
+		action, _, _ = strings.Cut(action, ":") // Thread
+		switch {
+		case action == "":
+			return errReply, false, false
+		case action[0] == 's' || action[0] == 'S':
+			s.resume(stepping)
+		default:
+			s.resume(running)
+		}
+		return "", true, false
+	}
+
+	// vMustReplyEmpty and the other v packets are not supported
+	return "", false, false
+}
@@ -0,0 +1,162 @@
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+)
+
+// Increments $C000 forever
+var program = []uint8{
+	0x21, 0x00, 0xC0, // 0150: LD HL, $C000
+	0x34,       // 0153: loop: INC (HL)
+	0x00,       // 0154: NOP
+	0x18, 0xFC, // 0155: JR loop
+}
+
+// client is the GDB side of the session
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *client) send(data string) {
+	c.t.Helper()
+	if _, err := fmt.Fprintf(c.conn, "$%s#%02x", data, checksum(data)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// receive returns the next reply, skipping acks
+func (c *client) receive() string {
+	c.t.Helper()
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if b != '$' {
+			continue
+		}
+		data, err := c.r.ReadString('#')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		c.r.Discard(2)
+		return strings.TrimSuffix(data, "#")
+	}
+}
+
+func (c *client) command(data, want string) {
+	c.t.Helper()
+	c.send(data)
+	if got := c.receive(); got != want {
+		c.t.Errorf("%s: got %q, want %q", data, got, want)
+	}
+}
+
+func TestSession(t *testing.T) {
+	gb := gameboy.New(nil, 44100)
+	gb.Load(cartridge.NewCartridge(testrom.New(program...), nil))
+	gb.LoadBootROM(nil)
+	gb.APU.Muted = true
+
+	// The hook of the debugger is kept while watchpoints are set and removed
+	var debuggerWrites atomic.Int64
+	gb.Memory.SetAccessHook("debugger", func(addr uint16, old, new uint8, write bool) {
+		if write && addr == 0xC000 {
+			debuggerWrites.Add(1)
+		}
+	})
+
+	s := New(gb)
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			s.Serve(conn)
+		}
+	}()
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	// Host loop
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				s.Run(gb.Step)
+			}
+		}
+	}()
+
+	c := &client{t: t, conn: clientConn, r: bufio.NewReader(clientConn)}
+	c.command("qSupported:swbreak+", "PacketSize=4000;qXfer:features:read+;swbreak+;hwbreak+;QStartNoAckMode+;vContSupported+")
+	c.command("?", "S05")
+
+	c.send("qXfer:features:read:target.xml:0,20")
+	if got := c.receive(); got != "m"+targetXML[:0x20] {
+		t.Errorf("target description: got %q", got)
+	}
+
+	// Break at the loop, then write and read registers and memory
+	c.command("Z0,153,1", "OK")
+	c.command("c", "T05swbreak:;")
+	c.command("p5", "5301")
+	c.command("P0=00a0", "OK") // A = $A0, F = $00
+	c.command("Mc000,2:1234", "OK")
+	c.command("mc000,2", "1234")
+
+	// Step the INC (HL)
+	c.command("s", "T05")
+	c.command("mc000,1", "13")
+	c.command("g", "00a0"+"1300"+"d800"+"00c0"+"feff"+"5401")
+
+	// Watch the write of the next INC (HL)
+	c.command("z0,153,1", "OK")
+	c.command("Z2,c000,1", "OK")
+	c.command("vCont;c", "T05watch:c000;")
+	c.command("mc000,1", "14")
+	c.command("z2,c000,1", "OK")
+
+	// Interrupt a run without breakpoints
+	c.send("c")
+	if _, err := clientConn.Write([]byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.receive(); got != "T02" {
+		t.Errorf("interrupt: got %q, want T02", got)
+	}
+
+	c.command("D", "OK")
+
+	writes := debuggerWrites.Load()
+	if writes == 0 {
+		t.Error("debugger hook not called while attached")
+	}
+	for deadline := time.Now().Add(5 * time.Second); debuggerWrites.Load() == writes; {
+		if time.Now().After(deadline) {
+			t.Fatal("debugger hook removed on detach")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,118 @@
+package gdb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+)
+
+// interruptPacket is delivered when the client sends Ctrl-C (0x03) outside of a packet
+const interruptPacket = "\x03"
+
+var errChecksum = errors.New("gdb: packet checksum mismatch")
+
+// conn frames the remote serial protocol packets: $data#checksum,
+// acknowledged with + (or - to ask for retransmission) unless no-ack mode is on
+type conn struct {
+	r *bufio.Reader
+	w io.Writer
+
+	noAck atomic.Bool
+}
+
+func newConn(rw io.ReadWriter) *conn {
+	return &conn{r: bufio.NewReader(rw), w: rw}
+}
+
+// readPacket returns the data of the next packet (or interruptPacket)
+func (c *conn) readPacket() (string, error) {
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch b {
+		case '$':
+			data, err := c.readData()
+			if errors.Is(err, errChecksum) {
+				if !c.noAck.Load() {
+					if _, err := c.w.Write([]byte{'-'}); err != nil {
+						return "", err
+					}
+				}
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			if !c.noAck.Load() {
+				if _, err := c.w.Write([]byte{'+'}); err != nil {
+					return "", err
+				}
+			}
+			return data, nil
+
+		case 0x03:
+			return interruptPacket, nil
+		}
+		// Acks and anything else between packets are ignored
+	}
+}
+
+func (c *conn) readData() (string, error) {
+	data, err := c.r.ReadString('#')
+	if err != nil {
+		return "", err
+	}
+	data = data[:len(data)-1]
+
+	var cs [2]byte
+	if _, err := io.ReadFull(c.r, cs[:]); err != nil {
+		return "", err
+	}
+	want, err := strconv.ParseUint(string(cs[:]), 16, 8)
+	if err != nil || uint8(want) != checksum(data) {
+		return "", errChecksum
+	}
+	return data, nil
+}
+
+// writePacket sends a packet (the client acknowledgement is skipped by readPacket)
+func (c *conn) writePacket(data string) error {
+	_, err := fmt.Fprintf(c.w, "$%s#%02x", escape(data), checksum(escape(data)))
+	return err
+}
+
+func checksum(data string) uint8 {
+	var sum uint8
+	for i := range len(data) {
+		sum += data[i]
+	}
+	return sum
+}
+
+// escape escapes the characters that cannot appear in a packet ($, #, } and *)
+func escape(data string) string {
+	var out []byte
+	for i := range len(data) {
+		switch b := data[i]; b {
+		case '$', '#', '}', '*':
+			if out == nil {
+				out = append(out, data[:i]...)
+			}
+			out = append(out, '}', b^0x20)
+		default:
+			if out != nil {
+				out = append(out, b)
+			}
+		}
+	}
+	if out == nil {
+		return data
+	}
+	return string(out)
+}
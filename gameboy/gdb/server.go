@@ -0,0 +1,258 @@
+// Package gdb implements a GDB remote serial protocol stub, so that the emulator can be
+// debugged from GDB (target remote localhost:PORT) and the editors' debug adapters using it.
+//
+// The target has six 16-bit registers (AF, BC, DE, HL, SP, PC) described by an SM83
+// target description. Addresses above FFFF select a bank (BBAAAA, e.g. 34000 for ROM bank 3,
+// 2D000 for wRAM bank 2) in memory accesses and breakpoints.
+//
+// While a client is attached the host executes instructions through Run,
+// which returns false when the client halted the target.
+package gdb
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+)
+
+// Owner of the memory access hook of the watchpoints (the debugger has its own)
+const accessHookOwner = "gdb"
+
+type state int
+
+const (
+	detached state = iota // No client attached: the host runs freely
+	halted
+	running
+	stepping
+)
+
+// Server is a GDB stub for a game boy
+type Server struct {
+	gb *gameboy.GameBoy
+
+	// Held by the host while executing an instruction and by
+	// the client session while it inspects or changes the target
+	mu    sync.Mutex
+	state state
+
+	// Why the target halted (stop reply packet) and notification sent when it happens
+	stopReason string
+	stopped    chan struct{}
+
+	breakpoints map[uint32]struct{}
+	watchpoints []watchpoint
+	watchHit    string
+}
+
+func New(gb *gameboy.GameBoy) *Server {
+	return &Server{
+		gb:          gb,
+		stopReason:  "S05",
+		stopped:     make(chan struct{}, 1),
+		breakpoints: make(map[uint32]struct{}),
+	}
+}
+
+// Listen accepts GDB clients on address (one at a time) in background
+func (s *Server) Listen(address string) error {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	log.Println("[GDB] Listening on", ln.Addr())
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				log.Println("[ERROR] Accepting GDB client:", err)
+				return
+			}
+
+			log.Println("[GDB] Client attached from", c.RemoteAddr())
+			if err := s.Serve(c); err != nil {
+				log.Println("[GDB] Client detached:", err)
+			} else {
+				log.Println("[GDB] Client detached")
+			}
+			c.Close()
+		}
+	}()
+	return nil
+}
+
+// Attached returns true if a client controls the execution
+func (s *Server) Attached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state != detached
+}
+
+// Run executes an instruction with step unless the client halted the target.
+// It returns false if the instruction was not executed
+func (s *Server) Run(step func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case halted:
+		return false
+	case detached:
+		step()
+		return true
+	}
+
+	step()
+
+	pc := s.gb.CPU.ReadPC()
+	switch {
+	case s.watchHit != "":
+		s.halt("T05" + s.watchHit)
+		s.watchHit = ""
+	case s.state == stepping:
+		s.halt("T05")
+	case s.hasBreakpoint(pc):
+		s.halt("T05swbreak:;")
+	}
+	return true
+}
+
+// halt stops the target (mu must be locked)
+func (s *Server) halt(reason string) {
+	s.state = halted
+	s.stopReason = reason
+	select {
+	case s.stopped <- struct{}{}:
+	default:
+	}
+}
+
+// resume lets the host execute (continuing or executing a single instruction)
+func (s *Server) resume(st state) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The access hook is lost when the game boy is reset
+	if len(s.watchpoints) > 0 {
+		s.gb.Memory.SetAccessHook(accessHookOwner, s.onMemoryAccess)
+	}
+	s.state = st
+}
+
+// interrupt halts the target (if it was not halted already) and returns the stop reason
+func (s *Server) interrupt() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != halted {
+		s.halt("T02")
+	}
+	select {
+	case <-s.stopped:
+	default:
+	}
+	return s.stopReason
+}
+
+// Serve runs a client session on rw, returning when the client detaches or disconnects.
+// The target is halted for the whole session unless the client resumes it
+func (s *Server) Serve(rw io.ReadWriter) error {
+	s.mu.Lock()
+	s.state = halted
+	s.stopReason = "S05"
+	s.mu.Unlock()
+	defer s.detach()
+
+	c := newConn(rw)
+
+	// Packets are read in background to receive interrupts while the target runs
+	packets := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			p, err := c.readPacket()
+			if err != nil {
+				readErr <- err
+				close(packets)
+				return
+			}
+			select {
+			case packets <- p:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for p := range packets {
+		reply, resumed, quit := s.handle(c, p)
+		if quit {
+			if reply != "" {
+				return c.writePacket(reply)
+			}
+			return nil
+		}
+
+		if resumed {
+			reply = s.wait(packets)
+			if reply == "" {
+				break // Disconnected
+			}
+		}
+		if err := c.writePacket(reply); err != nil {
+			return err
+		}
+	}
+
+	if err := <-readErr; !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// wait returns the stop reply when the target halts (or is interrupted by the client)
+func (s *Server) wait(packets <-chan string) string {
+	for {
+		select {
+		case <-s.stopped:
+			s.mu.Lock()
+			reason := s.stopReason
+			s.mu.Unlock()
+			return reason
+
+		case p, ok := <-packets:
+			if !ok {
+				return ""
+			}
+			if p == interruptPacket {
+				return s.interrupt()
+			}
+			// Other packets are not expected while the target runs
+		}
+	}
+}
+
+// detach removes the breakpoints and watchpoints and lets the host run freely
+func (s *Server) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.watchpoints) > 0 {
+		s.gb.Memory.SetAccessHook(accessHookOwner, nil)
+	}
+	s.state = detached
+	s.breakpoints = make(map[uint32]struct{})
+	s.watchpoints = nil
+	s.watchHit = ""
+	select {
+	case <-s.stopped:
+	default:
+	}
+}
@@ -0,0 +1,164 @@
+package gdb
+
+import (
+	"fmt"
+)
+
+// Register numbers in the target description and in the g/G packets
+const (
+	regAF = iota
+	regBC
+	regDE
+	regHL
+	regSP
+	regPC
+	numRegisters
+)
+
+const targetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.lucky-boy.sm83.cpu">
+    <reg name="af" bitsize="16" regnum="0" type="int"/>
+    <reg name="bc" bitsize="16" regnum="1" type="int"/>
+    <reg name="de" bitsize="16" regnum="2" type="int"/>
+    <reg name="hl" bitsize="16" regnum="3" type="int"/>
+    <reg name="sp" bitsize="16" regnum="4" type="data_ptr"/>
+    <reg name="pc" bitsize="16" regnum="5" type="code_ptr"/>
+  </feature>
+</target>
+`
+
+// readRegister returns a register (mu must be locked)
+func (s *Server) readRegister(n int) uint16 {
+	c := s.gb.CPU
+	switch n {
+	case regAF:
+		return uint16(c.A)<<8 | uint16(c.F)
+	case regBC:
+		return c.ReadBC()
+	case regDE:
+		return c.ReadDE()
+	case regHL:
+		return c.ReadHL()
+	case regSP:
+		return c.SP
+	default:
+		return c.PC
+	}
+}
+
+// writeRegister changes a register (mu must be locked)
+func (s *Server) writeRegister(n int, v uint16) {
+	c := s.gb.CPU
+	h, l := uint8(v>>8), uint8(v)
+	switch n {
+	case regAF:
+		c.A, c.F = h, l&0xF0 // Low nibble of F is always 0
+	case regBC:
+		c.B, c.C = h, l
+	case regDE:
+		c.D, c.E = h, l
+	case regHL:
+		c.H, c.L = h, l
+	case regSP:
+		c.SP = v
+	default:
+		c.PC = v
+	}
+}
+
+// Registers are sent as little endian hex
+func appendRegister(b []byte, v uint16) []byte {
+	return fmt.Appendf(b, "%02x%02x", uint8(v), uint8(v>>8))
+}
+
+func parseRegister(s string) (uint16, error) {
+	var lo, hi uint8
+	if _, err := fmt.Sscanf(s, "%02x%02x", &lo, &hi); err != nil || len(s) != 4 {
+		return 0, fmt.Errorf("invalid register value %q", s)
+	}
+	return uint16(hi)<<8 | uint16(lo), nil
+}
+
+// splitAddress returns the bank and the CPU address of a GDB address (bank is -1 if not specified)
+func splitAddress(addr uint32) (bank int, cpuAddr uint16) {
+	if addr > 0xFFFF {
+		return int(addr >> 16), uint16(addr)
+	}
+	return -1, uint16(addr)
+}
+
+// readMemory reads a byte (mu must be locked)
+func (s *Server) readMemory(addr uint32) uint8 {
+	bank, a := splitAddress(addr)
+	if bank >= 0 {
+		return s.gb.Memory.DebugReadBank(uint(bank), a)
+	}
+	return s.gb.Memory.DebugRead(a)
+}
+
+// writeMemory writes a byte without side effects (mu must be locked)
+func (s *Server) writeMemory(addr uint32, v uint8) {
+	bank, a := splitAddress(addr)
+	if bank >= 0 {
+		s.gb.Memory.DebugWriteBank(uint(bank), a, v)
+		return
+	}
+	s.gb.Memory.DebugWrite(a, v)
+}
+
+// hasBreakpoint returns true if there is a breakpoint at pc, with or without the bank mapped (mu must be locked)
+func (s *Server) hasBreakpoint(pc uint16) bool {
+	if len(s.breakpoints) == 0 {
+		return false
+	}
+	if _, ok := s.breakpoints[uint32(pc)]; ok {
+		return true
+	}
+	bank := s.gb.Memory.DebugBank(pc)
+	_, ok := s.breakpoints[uint32(bank)<<16|uint32(pc)]
+	return ok
+}
+
+type watchKind int
+
+const (
+	watchWrite  watchKind = iota + 2 // Z2
+	watchRead                        // Z3
+	watchAccess                      // Z4
+)
+
+type watchpoint struct {
+	kind   watchKind
+	start  uint16
+	length uint16
+}
+
+func (wp watchpoint) matches(addr uint16, write bool) bool {
+	if addr-wp.start >= wp.length {
+		return false
+	}
+	switch wp.kind {
+	case watchWrite:
+		return write
+	case watchRead:
+		return !write
+	}
+	return true
+}
+
+// onMemoryAccess is the MMU access hook checking watchpoints (called by the host with mu locked)
+func (s *Server) onMemoryAccess(addr uint16, _, _ uint8, write bool) {
+	if s.watchHit != "" || s.state == detached {
+		return
+	}
+
+	for _, wp := range s.watchpoints {
+		if wp.matches(addr, write) {
+			name := [...]string{watchWrite: "watch", watchRead: "rwatch", watchAccess: "awatch"}[wp.kind]
+			s.watchHit = fmt.Sprintf("%s:%x;", name, addr)
+			return
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package gameboy
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+)
+
+// newTestGameBoy returns a Game Boy running the program of a test ROM, with the audio muted
+func newTestGameBoy(program ...uint8) *GameBoy {
+	gb := New(nil, 44100)
+	gb.Load(cartridge.NewCartridge(testrom.New(program...), nil))
+	gb.LoadBootROM(nil)
+	gb.APU.Muted = true
+	return gb
+}
@@ -0,0 +1,264 @@
+package gameboy
+
+import (
+	"sort"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cpu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
+	"github.com/danielecanzoneri/lucky-boy/util/snapshot"
+)
+
+const (
+	// Instructions between checkpoints (about half a second) and checkpoints kept
+	checkpointInterval = 1 << 18
+	maxCheckpoints     = 120
+)
+
+// machine is the emulation state saved by checkpoints (every component is reachable from the CPU)
+type machine struct {
+	CPU *cpu.CPU
+	SGB *sgb.SGB
+}
+
+type checkpoint struct {
+	step  uint64
+	state *snapshot.Snapshot
+}
+
+// inputChange records the keys pressed from an instruction on
+type inputChange struct {
+	step uint64
+	keys uint8
+}
+
+// RumbleChange records the rumble motor turned on or off by an instruction
+type RumbleChange struct {
+	Step uint64 // Instruction since the history started (see Steps)
+	On   bool
+}
+
+// History records the execution to go back to previous instructions: it takes periodic
+// checkpoints and records the joypad, then restores a checkpoint and executes again
+// the instructions up to the one wanted (the emulation is deterministic given the inputs).
+// The rumble motor changes are recorded as outputs to verify them.
+// Serial and infrared transfers with other emulators are not recorded
+type History struct {
+	gb *GameBoy
+
+	// Instructions executed since the history started
+	steps uint64
+
+	checkpoints []checkpoint
+	interval    uint64
+
+	// Joypad changes and index of the next one to apply when replaying
+	inputs    []inputChange
+	nextInput int
+	keys      uint8
+
+	// Rumble motor changes
+	rumble []RumbleChange
+
+	replaying bool
+}
+
+func newHistory(gb *GameBoy) *History {
+	return &History{gb: gb, interval: checkpointInterval}
+}
+
+// EnableHistory starts recording the execution for reverse debugging (kept across resets, which clear it)
+func (gb *GameBoy) EnableHistory() {
+	gb.historyEnabled = true
+	if gb.Joypad != nil && gb.History == nil {
+		gb.History = newHistory(gb)
+		gb.Joypad.SetInputProvider(gb.History)
+	}
+}
+
+// DisableHistory stops recording the execution and frees the checkpoints
+func (gb *GameBoy) DisableHistory() {
+	gb.historyEnabled = false
+	gb.History = nil
+	if gb.Joypad != nil {
+		gb.Joypad.SetInputProvider(gb.inputProvider)
+	}
+}
+
+// Steps returns the number of instructions executed since the history started
+func (h *History) Steps() uint64 {
+	return h.steps
+}
+
+// Rumble returns the rumble motor changes recorded, oldest first
+func (h *History) Rumble() []RumbleChange {
+	return h.rumble
+}
+
+// recordRumble records a motor change of the instruction executing
+func (h *History) recordRumble(on bool) {
+	h.rumble = append(h.rumble, RumbleChange{Step: h.steps, On: on})
+}
+
+// IsKeyPressed implements joypad.InputProvider with the keys recorded for the current instruction
+func (h *History) IsKeyPressed(key joypad.Key) bool {
+	return h.keys&(1<<key) != 0
+}
+
+// beforeStep takes a checkpoint when due and records (or replays) the joypad
+func (h *History) beforeStep() {
+	if h.replaying {
+		for h.nextInput < len(h.inputs) && h.inputs[h.nextInput].step <= h.steps {
+			h.keys = h.inputs[h.nextInput].keys
+			h.nextInput++
+		}
+		return
+	}
+
+	if n := len(h.checkpoints); n == 0 || h.steps >= h.checkpoints[n-1].step+h.interval {
+		h.takeCheckpoint()
+	}
+
+	var keys uint8
+	if provider := h.gb.inputProvider; provider != nil {
+		for key := joypad.KeyStart; key <= joypad.KeyRight; key++ {
+			if provider.IsKeyPressed(key) {
+				keys |= 1 << key
+			}
+		}
+	}
+	if keys != h.keys {
+		h.inputs = append(h.inputs, inputChange{step: h.steps, keys: keys})
+		h.nextInput = len(h.inputs)
+		h.keys = keys
+	}
+}
+
+func (h *History) takeCheckpoint() {
+	h.checkpoints = append(h.checkpoints, checkpoint{
+		step:  h.steps,
+		state: snapshot.Take(&machine{CPU: h.gb.CPU, SGB: h.gb.SGB}),
+	})
+	if len(h.checkpoints) <= maxCheckpoints {
+		return
+	}
+
+	// Forget the oldest checkpoint and the inputs before the new oldest one (but the keys pressed then)
+	h.checkpoints = h.checkpoints[1:]
+	i := h.inputIndex(h.checkpoints[0].step)
+	if i > 0 {
+		h.inputs = h.inputs[i-1:]
+		h.nextInput -= i - 1
+	}
+	h.rumble = h.rumble[h.rumbleIndex(h.checkpoints[0].step):]
+}
+
+// inputIndex returns the index of the first input change at or after step
+func (h *History) inputIndex(step uint64) int {
+	return sort.Search(len(h.inputs), func(i int) bool { return h.inputs[i].step >= step })
+}
+
+// rumbleIndex returns the index of the first rumble change at or after step
+func (h *History) rumbleIndex(step uint64) int {
+	return sort.Search(len(h.rumble), func(i int) bool { return h.rumble[i].Step >= step })
+}
+
+// restore goes back to the i-th checkpoint
+func (h *History) restore(i int) {
+	cp := h.checkpoints[i]
+	cp.state.Restore()
+	h.steps = cp.step
+
+	h.nextInput = h.inputIndex(cp.step)
+	h.keys = 0
+	if h.nextInput > 0 {
+		h.keys = h.inputs[h.nextInput-1].keys
+	}
+}
+
+// replay executes instructions up to target, calling stop after each one.
+// It returns the last step after which stop returned true
+func (h *History) replay(target uint64, stop func() bool) (hit uint64, found bool) {
+	gb := h.gb
+
+	// Samples and trace lines were already produced the first time
+	muted := gb.APU.Muted
+	h.replaying = true
+	gb.APU.Muted = true
+	gb.CPU.SetTraceHook(nil)
+	defer func() {
+		h.replaying = false
+		gb.APU.Muted = muted
+		gb.installTraceHook()
+	}()
+
+	for h.steps < target {
+		gb.Step()
+		if stop != nil && stop() {
+			hit, found = h.steps, true
+		}
+	}
+	return
+}
+
+// truncate forgets what was recorded after the current step: execution continues from here
+func (h *History) truncate() {
+	h.inputs = h.inputs[:h.nextInput]
+	h.rumble = h.rumble[:h.rumbleIndex(h.steps)]
+	n := sort.Search(len(h.checkpoints), func(i int) bool { return h.checkpoints[i].step > h.steps })
+	h.checkpoints = h.checkpoints[:n]
+}
+
+// lastCheckpoint returns the index of the last checkpoint at or before step (-1 if there is none)
+func (h *History) lastCheckpoint(step uint64) int {
+	return sort.Search(len(h.checkpoints), func(i int) bool { return h.checkpoints[i].step > step }) - 1
+}
+
+// StepBack goes back to the state before the last instruction executed.
+// It returns false if there is no recorded instruction to go back to
+func (h *History) StepBack() bool {
+	if h.steps == 0 {
+		return false
+	}
+	target := h.steps - 1
+
+	i := h.lastCheckpoint(target)
+	if i < 0 {
+		return false
+	}
+	h.restore(i)
+	h.replay(target, nil)
+	h.truncate()
+	return true
+}
+
+// ContinueBack goes back to the last state (before the current one) after which stop returns true,
+// e.g. because a breakpoint or a watchpoint was hit. If there is none, it goes back to the
+// oldest state recorded and returns false
+func (h *History) ContinueBack(stop func() bool) bool {
+	if len(h.checkpoints) == 0 || h.steps == 0 {
+		return false
+	}
+	end := h.steps - 1
+
+	// Search backwards one checkpoint interval at a time
+	for i := h.lastCheckpoint(end); i >= 0; i-- {
+		segmentEnd := end
+		if i+1 < len(h.checkpoints) {
+			segmentEnd = min(segmentEnd, h.checkpoints[i+1].step)
+		}
+
+		h.restore(i)
+		hit, found := h.replay(segmentEnd, stop)
+		if found {
+			h.restore(i)
+			h.replay(hit, stop)
+			h.truncate()
+			return true
+		}
+	}
+
+	h.restore(0)
+	h.truncate()
+	return false
+}
@@ -0,0 +1,151 @@
+package gameboy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+)
+
+// Increments $C000 and copies the d-pad state to $C001 forever
+var historyProgram = []uint8{
+	0x3E, 0x20, // LD A, $20 (select d-pad)
+	0xE0, 0x00, // LDH ($00), A
+	0x21, 0x00, 0xC0, // LD HL, $C000
+	0x7E,       // loop: LD A, (HL)
+	0x3C,       // INC A
+	0x77,       // LD (HL), A
+	0xF0, 0x00, // LDH A, ($00)
+	0xEA, 0x01, 0xC0, // LD ($C001), A
+	0x18, 0xF6, // JR loop
+}
+
+type testInput struct {
+	keys uint8
+}
+
+func (in *testInput) IsKeyPressed(key joypad.Key) bool {
+	return in.keys&(1<<key) != 0
+}
+
+type historyState struct {
+	pc         uint16
+	a          uint8
+	cycles     uint64
+	ram0, ram1 uint8
+}
+
+func currentState(gb *GameBoy) historyState {
+	return historyState{
+		pc:     gb.CPU.ReadPC(),
+		a:      gb.CPU.A,
+		cycles: gb.CPU.Cycles(),
+		ram0:   gb.Memory.DebugRead(0xC000),
+		ram1:   gb.Memory.DebugRead(0xC001),
+	}
+}
+
+func TestHistory(t *testing.T) {
+	input := new(testInput)
+	gb := newTestGameBoy(historyProgram...)
+	gb.SetInputProvider(input)
+	gb.EnableHistory()
+	gb.History.interval = 100
+
+	// states[i] is the state after i instructions
+	const steps = 1000
+	var states []historyState
+	for i := range steps {
+		switch i {
+		case 300:
+			input.keys = 1 << joypad.KeyRight
+		case 600:
+			input.keys = 0
+		}
+		states = append(states, currentState(gb))
+		gb.Step()
+	}
+	states = append(states, currentState(gb))
+
+	for i := steps - 1; i >= steps-150; i-- {
+		if !gb.History.StepBack() {
+			t.Fatalf("step back to %d failed", i)
+		}
+		if got := currentState(gb); got != states[i] || gb.History.Steps() != uint64(i) {
+			t.Fatalf("step back to %d: got %+v, want %+v", i, got, states[i])
+		}
+	}
+
+	// Back to the last time d-pad right was pressed
+	stop := func() bool { return gb.Memory.DebugRead(0xC001)&0x01 == 0 }
+	want := 0
+	for i := range int(gb.History.Steps()) {
+		if states[i].ram1&0x01 == 0 {
+			want = i
+		}
+	}
+	if !gb.History.ContinueBack(stop) {
+		t.Fatal("continue back did not stop")
+	}
+	if got := currentState(gb); got != states[want] {
+		t.Fatalf("continue back: got %+v, want %+v (step %d)", got, states[want], want)
+	}
+
+	// Executing again with the same inputs gives the same states
+	for i := want; i < want+50; i++ {
+		input.keys = 0
+		if 300 <= i && i < 600 {
+			input.keys = 1 << joypad.KeyRight
+		}
+		if got := currentState(gb); got != states[i] {
+			t.Fatalf("execution after going back, step %d: got %+v, want %+v", i, got, states[i])
+		}
+		gb.Step()
+	}
+}
+
+func TestHistoryRumble(t *testing.T) {
+	rom := testrom.New(
+		0x3E, 0x08, // $0150: loop: LD A, $08
+		0xEA, 0x00, 0x40, // $0152: LD ($4000), A (motor on)
+		0xAF,             // $0155: XOR A
+		0xEA, 0x00, 0x40, // $0156: LD ($4000), A (motor off)
+		0x18, 0xF5, // $0159: JR loop
+	)
+	rom[0x147] = 0x1C // MBC5 + RUMBLE
+
+	gb := New(nil, 44100)
+	gb.EnableHistory()
+	gb.Load(cartridge.NewCartridge(rom, nil))
+	gb.LoadBootROM(nil)
+	gb.APU.Muted = true
+	gb.History.interval = 4
+
+	var calls []bool
+	gb.SetRumbleCallback(func(on bool) { calls = append(calls, on) })
+
+	// NOP, JP, then the loop: the motor is turned on by steps 3 and 8 and off by step 5
+	for range 10 {
+		gb.Step()
+	}
+	want := []RumbleChange{{3, true}, {5, false}, {8, true}}
+	if got := gb.History.Rumble(); !slices.Equal(got, want) {
+		t.Errorf("recorded: got %v, want %v", got, want)
+	}
+
+	// Going back forgets the changes after the current step, replaying does not call the callback
+	gb.History.StepBack()
+	gb.History.StepBack()
+	if got := gb.History.Rumble(); !slices.Equal(got, want[:2]) {
+		t.Errorf("after going back: got %v, want %v", got, want[:2])
+	}
+	gb.Step()
+	if got := gb.History.Rumble(); !slices.Equal(got, want) {
+		t.Errorf("after executing again: got %v, want %v", got, want)
+	}
+	if wantCalls := []bool{true, false, true, true}; !slices.Equal(calls, wantCalls) {
+		t.Errorf("callback: got %v, want %v", calls, wantCalls)
+	}
+}
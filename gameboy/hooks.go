@@ -0,0 +1,37 @@
+package gameboy
+
+// Hooks are the callbacks of a script, called on the goroutine running the emulation.
+// They are not called while the history replays instructions already executed
+type Hooks struct {
+	Frame       func()                                     // After each frame (VBlank)
+	Instruction func(pc uint16)                            // Before executing each instruction
+	Access      func(addr uint16, value uint8, write bool) // On each memory access made by the CPU
+}
+
+// SetHooks sets the script callbacks (kept across resets), nil fields are not called
+func (gb *GameBoy) SetHooks(hooks Hooks) {
+	gb.hooks = hooks
+	if gb.CPU != nil {
+		gb.installHooks()
+	}
+}
+
+func (gb *GameBoy) installHooks() {
+	gb.installTraceHook()
+	if gb.hooks.Access == nil {
+		gb.Memory.SetScriptHook(nil)
+		return
+	}
+	gb.Memory.SetScriptHook(gb.memoryAccessed)
+}
+
+func (gb *GameBoy) memoryAccessed(addr uint16, value uint8, write bool) {
+	if hook := gb.hooks.Access; hook != nil && !gb.replaying() {
+		hook(addr, value, write)
+	}
+}
+
+// replaying reports whether the history is executing again instructions already executed
+func (gb *GameBoy) replaying() bool {
+	return gb.History != nil && gb.History.replaying
+}
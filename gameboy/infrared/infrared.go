@@ -0,0 +1,51 @@
+package infrared
+
+// The IR receiver adapts to constant light: after being lit for this many ticks
+// it stops reporting the signal (about 2 ms at normal speed)
+const saturationTicks = 8192
+
+// Transport carries the LED state between two infrared ports
+type Transport interface {
+	// SetLED turns on or off the LED on this side of the transport
+	SetLED(on bool)
+	// Light reports whether light coming from the other side is received
+	Light() bool
+}
+
+type Port struct {
+	// Where LED light is sent and received (nil means nothing in front of the port)
+	Transport Transport `snapshot:"-"`
+
+	// Infrared communication register (bit 0: LED on, bits 6-7: read enable)
+	RP uint8
+
+	// Number of ticks the receiver has been exposed to light
+	lightTicks int
+}
+
+func NewPort() *Port {
+	return new(Port)
+}
+
+func (port *Port) Tick(ticks int) {
+	if port.Transport != nil && port.Transport.Light() {
+		port.lightTicks += ticks
+	} else {
+		port.lightTicks = 0
+	}
+}
+
+// LEDOn returns true if the LED is emitting light
+func (port *Port) LEDOn() bool {
+	return port.RP&ledMask != 0
+}
+
+// readEnabled returns true if both read enable bits are set
+func (port *Port) readEnabled() bool {
+	return port.RP&readEnableMask == readEnableMask
+}
+
+// signalDetected returns true if the receiver is currently sensing light
+func (port *Port) signalDetected() bool {
+	return port.lightTicks > 0 && port.lightTicks < saturationTicks
+}
@@ -0,0 +1,61 @@
+package infrared
+
+import "testing"
+
+func TestPort_ReadEnable(t *testing.T) {
+	port := NewPort()
+	port.Transport = new(Loopback)
+
+	// LED on but reading disabled: bit 1 stays high
+	port.Write(0x01)
+	port.Tick(4)
+	if got := port.Read(); got != 0x3F {
+		t.Errorf("got %02X, expected %02X", got, 0x3F)
+	}
+
+	// Reading enabled: light is detected
+	port.Write(0xC1)
+	port.Tick(4)
+	if got := port.Read(); got != 0xFD {
+		t.Errorf("got %02X, expected %02X", got, 0xFD)
+	}
+
+	// LED off: no more light
+	port.Write(0xC0)
+	port.Tick(4)
+	if got := port.Read(); got != 0xFE {
+		t.Errorf("got %02X, expected %02X", got, 0xFE)
+	}
+}
+
+func TestPort_Saturation(t *testing.T) {
+	port := NewPort()
+	port.Transport = new(Loopback)
+	port.Write(0xC1)
+
+	port.Tick(saturationTicks - 4)
+	if port.Read()&receiveMask != 0 {
+		t.Fatalf("light should be detected")
+	}
+
+	port.Tick(4)
+	if port.Read()&receiveMask == 0 {
+		t.Errorf("constant light should not be detected anymore")
+	}
+}
+
+func TestNewLink(t *testing.T) {
+	a, b := NewPort(), NewPort()
+	a.Transport, b.Transport = NewLink()
+	a.Write(0xC1)
+	b.Write(0xC0)
+
+	a.Tick(4)
+	b.Tick(4)
+	if a.Read()&receiveMask == 0 {
+		t.Errorf("port should not receive its own light")
+	}
+	if b.Read()&receiveMask != 0 {
+		t.Errorf("port should receive light from the other end")
+	}
+}
@@ -0,0 +1,28 @@
+package infrared
+
+const (
+	RPAddr = 0xFF56
+
+	ledMask        = 0x01
+	receiveMask    = 0x02
+	readEnableMask = 0xC0
+	rpUnusedMask   = 0x3C
+)
+
+func (port *Port) Read() uint8 {
+	out := port.RP | rpUnusedMask | receiveMask
+
+	// Bit 1 is 0 when a signal is being received, only if reading is enabled
+	if port.readEnabled() && port.signalDetected() {
+		out &^= receiveMask
+	}
+	return out
+}
+
+func (port *Port) Write(v uint8) {
+	port.RP = v & (readEnableMask | ledMask)
+
+	if port.Transport != nil {
+		port.Transport.SetLED(port.LEDOn())
+	}
+}
@@ -0,0 +1,87 @@
+package infrared
+
+import (
+	"io"
+	"log"
+	"net"
+	"sync/atomic"
+)
+
+// Loopback is a transport that receives the light emitted by its own LED
+// (like pointing the port at a mirror)
+type Loopback struct {
+	led atomic.Bool
+}
+
+func (l *Loopback) SetLED(on bool) { l.led.Store(on) }
+func (l *Loopback) Light() bool    { return l.led.Load() }
+
+// linkEnd is one side of a transport linking two ports in the same process
+type linkEnd struct {
+	out *atomic.Bool
+	in  *atomic.Bool
+}
+
+func (e linkEnd) SetLED(on bool) { e.out.Store(on) }
+func (e linkEnd) Light() bool    { return e.in.Load() }
+
+// NewLink returns the two ends of a transport connecting two local emulator instances
+func NewLink() (Transport, Transport) {
+	a, b := new(atomic.Bool), new(atomic.Bool)
+	return linkEnd{out: a, in: b}, linkEnd{out: b, in: a}
+}
+
+// ConnTransport exchanges LED state changes with another emulator over a socket
+type ConnTransport struct {
+	Conn net.Conn
+
+	led   atomic.Bool
+	light atomic.Bool
+}
+
+// NewConnTransport creates a transport over conn and starts listening for incoming light changes
+func NewConnTransport(conn net.Conn) *ConnTransport {
+	t := &ConnTransport{Conn: conn}
+	go t.listen()
+	return t
+}
+
+func (t *ConnTransport) SetLED(on bool) {
+	// Only send state changes
+	if t.led.Swap(on) == on {
+		return
+	}
+
+	var b uint8
+	if on {
+		b = 1
+	}
+	if _, err := t.Conn.Write([]uint8{b}); err != nil {
+		log.Println("[ERROR] Infrared connection:", err)
+	}
+}
+
+func (t *ConnTransport) Light() bool {
+	return t.light.Load()
+}
+
+func (t *ConnTransport) listen() {
+	buf := make([]uint8, 1)
+
+	for {
+		_, err := t.Conn.Read(buf)
+
+		switch {
+		case err == nil:
+			t.light.Store(buf[0] != 0)
+		case err == io.EOF:
+			// Other side disconnected, no more light
+			t.light.Store(false)
+			return
+		default:
+			log.Println("[ERROR] Infrared connection:", err)
+			t.light.Store(false)
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package testrom builds the ROMs run by the tests: 32 KiB without MBC, the entry
+// point jumps to the program at $0150 and the interrupt handlers return (RETI)
+package testrom
+
+// ProgramStart is the address of the program
+const ProgramStart = 0x0150
+
+// WaitVBlank halts waiting for VBlank, then writes SCX (the number of frames)
+var WaitVBlank = []uint8{
+	0x3E, 0x01, // $0150: LD A, $01
+	0xE0, 0xFF, // $0152: LDH ($FF), A (enable VBlank interrupt)
+	0xFB,       // $0154: EI
+	0x76,       // $0155: loop: HALT
+	0xE0, 0x43, // $0156: LDH ($43), A
+	0x3C,       // $0158: INC A
+	0x18, 0xFA, // $0159: JR loop
+}
+
+// New returns a DMG ROM running the program
+func New(program ...uint8) []uint8 {
+	rom := make([]uint8, 0x8000)
+	copy(rom[0x100:], []uint8{0x00, 0xC3, 0x50, 0x01}) // NOP; JP $0150
+	copy(rom[ProgramStart:], program)
+	for vector := 0x40; vector <= 0x60; vector += 8 {
+		rom[vector] = 0xD9 // RETI
+	}
+	return rom
+}
+
+// NewCGB returns a ROM running the program, compatible with the CGB
+func NewCGB(program ...uint8) []uint8 {
+	rom := New(program...)
+	rom[0x143] = 0x80
+	return rom
+}
@@ -32,7 +32,12 @@ type Joypad struct {
 	aRight    uint8
 
 	RequestInterrupt func()
-	inputProvider    InputProvider
+	inputProvider    InputProvider `snapshot:"-"`
+
+	// Super Game Boy: receives the values written to P1 and
+	// returns the currently selected joypad (0-3) in multiplayer mode
+	WriteCallback func(v uint8)
+	JoypadID      func() uint8
 }
 
 func New() *Joypad {
@@ -50,9 +55,18 @@ func (jp *Joypad) Write(v uint8) {
 		jp.bLeft = 1
 		jp.aRight = 1
 	}
+
+	if jp.WriteCallback != nil {
+		jp.WriteCallback(v)
+	}
 }
 
 func (jp *Joypad) Read() uint8 {
+	// SGB multiplayer: with nothing selected, the low nibble returns the ID of the current joypad ($F = joypad 1)
+	if jp.JoypadID != nil && jp.selectButtons == 1 && jp.selectDPad == 1 {
+		return 0xF0 | (0xF - jp.JoypadID())
+	}
+
 	return 0xC0 | (jp.selectButtons << 5) | (jp.selectDPad << 4) |
 		(jp.startDown << 3) | (jp.selectUp << 2) | (jp.bLeft << 1) | jp.aRight
 }
@@ -68,6 +82,11 @@ func (jp *Joypad) DetectKeysPressed() {
 		return
 	}
 
+	// Only joypad 1 is connected to the input provider
+	if jp.JoypadID != nil && jp.JoypadID() != 0 {
+		return
+	}
+
 	if jp.selectButtons == 0 {
 		if jp.inputProvider.IsKeyPressed(KeyStart) {
 			if jp.startDown == 1 { // Detect high -> low transition
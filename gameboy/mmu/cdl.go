@@ -0,0 +1,37 @@
+package mmu
+
+import "github.com/danielecanzoneri/lucky-boy/gameboy/cdl"
+
+// SetCDL sets the code/data logger recording memory accesses (nil to disable it)
+func (mmu *MMU) SetCDL(logger *cdl.Logger) {
+	mmu.cdl = logger
+}
+
+// logAccess marks the ROM or RAM byte mapped at addr in the code/data log
+func (mmu *MMU) logAccess(addr uint16, flags cdl.Flags) {
+	if mmu.cdl == nil {
+		return
+	}
+
+	// Echo RAM
+	if 0xE000 <= addr && addr < 0xFE00 {
+		addr -= 0x2000
+	}
+
+	switch {
+	case addr < 0x8000:
+		// Boot ROM is not part of the cartridge
+		if !mmu.BootRomDisabled && (addr < 0x100 || mmu.cgb && 0x200 <= addr && addr < 0x900) {
+			return
+		}
+		mmu.cdl.Mark(cdl.ROM, mmu.Cartridge.ROMBank(addr)<<14|uint(addr&0x3FFF), flags)
+	case 0xA000 <= addr && addr < 0xC000:
+		mmu.cdl.Mark(cdl.SRAM, mmu.Cartridge.RAMBank()<<13|uint(addr&0x1FFF), flags)
+	case 0xC000 <= addr && addr < 0xD000:
+		mmu.cdl.Mark(cdl.WRAM, uint(addr-0xC000), flags)
+	case 0xD000 <= addr && addr < 0xE000:
+		mmu.cdl.Mark(cdl.WRAM, uint(mmu.wRAMBank())<<12|uint(addr-0xD000), flags)
+	case 0xFF80 <= addr && addr < 0xFFFF:
+		mmu.cdl.Mark(cdl.HRAM, uint(addr-0xFF80), flags)
+	}
+}
@@ -1,13 +1,117 @@
 package mmu
 
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
+// DebugRead reads addr as the CPU would, without side effects (vRAM and OAM are readable while the PPU uses them)
 func (mmu *MMU) DebugRead(addr uint16) uint8 {
+	switch {
 	// Read Wave RAM
-	if NR10Addr <= addr && addr < waveRAMStartAddr+waveRAMLength {
+	case NR10Addr <= addr && addr < waveRAMStartAddr+waveRAMLength:
 		return mmu.apu.DebugRead(addr)
+	case 0x8000 <= addr && addr < 0xA000:
+		return mmu.ppu.DebugReadVRAM(mmu.ppu.DebugVRAMBank(), addr)
+	case 0xFE00 <= addr && addr < 0xFF00:
+		return mmu.ppu.DebugReadOAM(addr)
 	}
 	return mmu.read(addr)
 }
 
+// DebugWrite writes addr bypassing the side effects of CPU writes: ROM and external RAM
+// are changed in the banks mapped (instead of writing MBC registers), vRAM and OAM even while
+// the PPU uses them. I/O registers are written as the CPU would
+func (mmu *MMU) DebugWrite(addr uint16, value uint8) {
+	switch {
+	case addr < 0x8000:
+		mmu.DebugWriteRegion(RegionROM, mmu.Cartridge.ROMBank(addr)<<14|uint(addr&0x3FFF), value)
+	case addr < 0xA000:
+		mmu.ppu.DebugWriteVRAM(mmu.ppu.DebugVRAMBank(), addr, value)
+	case addr < 0xC000:
+		mmu.DebugWriteRegion(RegionSRAM, mmu.Cartridge.RAMBank()<<13|uint(addr&0x1FFF), value)
+	case addr < 0xE000:
+		mmu.write(addr, value)
+	case addr < 0xFE00: // Echo RAM
+		mmu.write(addr-0x2000, value)
+	case addr < 0xFF00:
+		mmu.ppu.DebugWriteOAM(addr, value)
+	default:
+		mmu.write(addr, value)
+	}
+}
+
+// DebugWriteBank writes addr as if the specified bank was mapped (see DebugReadBank)
+func (mmu *MMU) DebugWriteBank(bank uint, addr uint16, value uint8) {
+	switch {
+	case bank == mmu.DebugBank(addr):
+		mmu.DebugWrite(addr, value)
+	case addr < 0x8000:
+		mmu.DebugWriteRegion(RegionROM, bank<<14|uint(addr&0x3FFF), value)
+	case 0xD000 <= addr && addr < 0xE000:
+		mmu.DebugWriteRegion(RegionWRAM, bank<<12|uint(addr-0xD000), value)
+	default:
+		mmu.DebugWrite(addr, value)
+	}
+}
+
+// AccessHook is called on every memory access made by the CPU.
+// On reads, old and new are both the value read
+type AccessHook func(addr uint16, old, new uint8, write bool)
+
+type ownedAccessHook struct {
+	owner string
+	hook  AccessHook
+}
+
+// SetAccessHook sets the access hook of an owner (e.g. the debugger or the GDB stub),
+// nil to remove it. The hooks of all the owners are called, in the order they were set
+func (mmu *MMU) SetAccessHook(owner string, hook AccessHook) {
+	for i, h := range mmu.accessHooks {
+		if h.owner == owner {
+			if hook == nil {
+				mmu.accessHooks = append(mmu.accessHooks[:i:i], mmu.accessHooks[i+1:]...)
+			} else {
+				mmu.accessHooks[i].hook = hook
+			}
+			return
+		}
+	}
+	if hook != nil {
+		mmu.accessHooks = append(mmu.accessHooks, ownedAccessHook{owner, hook})
+	}
+}
+
+// SetScriptHook sets a function called on every memory access made by the CPU for scripts,
+// independent from the access hook of the debugger (nil to remove it)
+func (mmu *MMU) SetScriptHook(hook func(addr uint16, value uint8, write bool)) {
+	mmu.scriptHook = hook
+}
+
+// timelineRegisters are the registers whose writes are recorded in the timeline
+// (scrolling, LCD control, palettes and timer)
+var timelineRegisters = map[uint16]bool{
+	LCDCAddr: true, STATAddr: true, SCYAddr: true, SCXAddr: true, LYCAddr: true,
+	BGPAddr: true, OBP0Addr: true, OBP1Addr: true, WYAddr: true, WXAddr: true,
+	BGPIAddr: true, BGPDAddr: true, OBPIAddr: true, OBPDAddr: true,
+	DIVAddr: true, TIMAAddr: true, TMAAddr: true, TACAddr: true,
+}
+
+// SetEventHook sets a function called when OAM DMA transfers start and end, on each
+// HDMA block transferred and on CPU writes to the timeline registers (nil to remove it)
+func (mmu *MMU) SetEventHook(hook timeline.Hook) {
+	mmu.eventHook = hook
+}
+
+// event calls the event hook, if set
+func (mmu *MMU) event(kind timeline.Kind, addr uint16, value uint8) {
+	if mmu.eventHook != nil {
+		mmu.eventHook(kind, addr, value)
+	}
+}
+
+// SetROMPatch sets a function changing the values read from ROM (nil to remove it)
+func (mmu *MMU) SetROMPatch(patch func(addr uint16, value uint8) uint8) {
+	mmu.romPatch = patch
+}
+
 func (mmu *MMU) DebugGetVDMASrcAddress() uint16 {
 	return mmu.vDMASrcAddress
 }
@@ -19,3 +123,104 @@ func (mmu *MMU) DebugGetVDMADestAddress() uint16 {
 func (mmu *MMU) DebugGetVDMALength() uint8 {
 	return mmu.read(HDMA5Addr)
 }
+
+// DebugBank returns the bank currently mapped at addr for switchable regions
+// (ROM, external RAM and CGB wRAM), 0 elsewhere
+func (mmu *MMU) DebugBank(addr uint16) uint {
+	switch {
+	case addr < 0x8000:
+		return mmu.Cartridge.ROMBank(addr)
+	case 0xA000 <= addr && addr < 0xC000:
+		return mmu.Cartridge.RAMBank()
+	case 0xD000 <= addr && addr < 0xE000:
+		return uint(mmu.wRAMBank())
+	case 0xF000 <= addr && addr < 0xFE00: // Echo RAM
+		return uint(mmu.wRAMBank())
+	default:
+		return 0
+	}
+}
+
+// DebugReadBank reads addr as if the specified bank was mapped (only ROM and wRAM
+// banks can be selected, other regions are read as currently mapped)
+func (mmu *MMU) DebugReadBank(bank uint, addr uint16) uint8 {
+	if bank == mmu.DebugBank(addr) {
+		return mmu.DebugRead(addr)
+	}
+
+	switch {
+	case addr < 0x8000:
+		return mmu.Cartridge.ReadROMBank(bank, addr)
+	case 0xD000 <= addr && addr < 0xE000 && bank < uint(len(mmu.wRAM)/0x1000):
+		return mmu.wRAM[0x1000*bank+uint(addr-0xD000)]
+	default:
+		return mmu.DebugRead(addr)
+	}
+}
+
+// Region is a memory area the debugger can access by offset, independently of the banks mapped
+type Region int
+
+const (
+	RegionROM  Region = iota // Whole ROM
+	RegionVRAM               // vRAM banks (bank << 13 | addr - 8000)
+	RegionWRAM               // wRAM banks (bank << 12 | addr & 0FFF)
+	RegionSRAM               // External RAM banks (bank << 13 | addr - A000)
+)
+
+// DebugRegionSize returns the size of the region (0 if it is not present)
+func (mmu *MMU) DebugRegionSize(r Region) int {
+	switch r {
+	case RegionROM:
+		return len(mmu.Cartridge.ROMData())
+	case RegionVRAM:
+		if mmu.cgb {
+			return 0x4000
+		}
+		return 0x2000
+	case RegionWRAM:
+		if mmu.cgb {
+			return len(mmu.wRAM)
+		}
+		return 0x2000
+	case RegionSRAM:
+		return len(mmu.Cartridge.RAMData())
+	}
+	return 0
+}
+
+// DebugReadRegion reads the byte at offset in the region (FF outside it)
+func (mmu *MMU) DebugReadRegion(r Region, offset uint) uint8 {
+	if offset >= uint(mmu.DebugRegionSize(r)) {
+		return 0xFF
+	}
+
+	switch r {
+	case RegionROM:
+		return mmu.Cartridge.ROMData()[offset]
+	case RegionVRAM:
+		return mmu.ppu.DebugReadVRAM(uint8(offset>>13), 0x8000+uint16(offset&0x1FFF))
+	case RegionWRAM:
+		return mmu.wRAM[offset]
+	default:
+		return mmu.Cartridge.RAMData()[offset]
+	}
+}
+
+// DebugWriteRegion writes the byte at offset in the region (ignored outside it)
+func (mmu *MMU) DebugWriteRegion(r Region, offset uint, value uint8) {
+	if offset >= uint(mmu.DebugRegionSize(r)) {
+		return
+	}
+
+	switch r {
+	case RegionROM:
+		mmu.Cartridge.ROMData()[offset] = value
+	case RegionVRAM:
+		mmu.ppu.DebugWriteVRAM(uint8(offset>>13), 0x8000+uint16(offset&0x1FFF), value)
+	case RegionWRAM:
+		mmu.wRAM[offset] = value
+	default:
+		mmu.Cartridge.RAMData()[offset] = value
+	}
+}
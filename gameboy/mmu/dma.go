@@ -1,6 +1,8 @@
 package mmu
 
 import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cdl"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/util"
 )
 
@@ -48,8 +50,10 @@ func (mmu *MMU) vDMATransfer() {
 	// Transfer 0x10 bytes
 	for i := uint16(0); i < 0x10; i++ {
 		src := mmu.read(mmu.vDMASrcAddress + i)
+		mmu.logAccess(mmu.vDMASrcAddress+i, cdl.DMA)
 		mmu.ppu.VDMAWrite(mmu.vDMADestAddress+i, src)
 	}
+	mmu.event(timeline.HDMA, 0x8000+mmu.vDMADestAddress, mmu.vDMALength)
 	mmu.vDMASrcAddress += 0x10
 	mmu.vDMADestAddress += 0x10
 
@@ -1,6 +1,7 @@
 package mmu
 
 import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/infrared"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/serial"
 	"github.com/danielecanzoneri/lucky-boy/util"
 )
@@ -124,6 +125,12 @@ func (mmu *MMU) writeIO(addr uint16, v uint8) {
 	case HDMA5Addr:
 		mmu.VDMA(v)
 
+	// Infrared port (CGB only)
+	case infrared.RPAddr:
+		if mmu.cgb {
+			mmu.ir.Write(v)
+		}
+
 	// wRAM bank register
 	case WBKAddr:
 		mmu.vbk = v & 0b111
@@ -192,6 +199,13 @@ func (mmu *MMU) readIO(addr uint16) uint8 {
 			return 0x80 | mmu.vDMALength
 		}
 
+	// Infrared port (CGB only)
+	case infrared.RPAddr:
+		if mmu.cgb {
+			return mmu.ir.Read()
+		}
+		return 0xFF
+
 	// wRAM bank register
 	case WBKAddr:
 		return 0xF8 | mmu.vbk
@@ -3,9 +3,12 @@ package mmu
 import (
 	"github.com/danielecanzoneri/lucky-boy/gameboy/audio"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cdl"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/infrared"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/serial"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/timer"
 )
 
@@ -21,6 +24,7 @@ type MMU struct {
 	timer  *timer.Timer
 	joypad *joypad.Joypad
 	serial *serial.Port
+	ir     *infrared.Port
 
 	// wRAM bank register
 	vbk uint8
@@ -55,19 +59,35 @@ type MMU struct {
 
 	// Boot ROM
 	BootRomDisabled bool
-	BootRom         []uint8
+	BootRom         []uint8 `snapshot:"-"`
 
 	// CGB flag
 	cgb bool
+
+	// Debugger hooks called on each CPU memory access
+	accessHooks []ownedAccessHook `snapshot:"-"`
+
+	// Debugger hook called on DMA transfers and writes to the timeline registers
+	eventHook timeline.Hook `snapshot:"-"`
+
+	// Script hook called on each CPU memory access (independent from the debugger one)
+	scriptHook func(addr uint16, value uint8, write bool) `snapshot:"-"`
+
+	// Code/data logger (nil if disabled)
+	cdl *cdl.Logger `snapshot:"-"`
+
+	// Patches the values read from ROM (Game Genie codes)
+	romPatch func(addr uint16, value uint8) uint8 `snapshot:"-"`
 }
 
-func New(ppu *ppu.PPU, apu *audio.APU, timer *timer.Timer, jp *joypad.Joypad, serialPort *serial.Port, cgb bool) *MMU {
+func New(ppu *ppu.PPU, apu *audio.APU, timer *timer.Timer, jp *joypad.Joypad, serialPort *serial.Port, irPort *infrared.Port, cgb bool) *MMU {
 	return &MMU{
 		ppu:         ppu,
 		apu:         apu,
 		timer:       timer,
 		joypad:      jp,
 		serial:      serialPort,
+		ir:          irPort,
 		cgb:         cgb,
 		speedFactor: 0,
 	}
@@ -82,12 +102,14 @@ func (mmu *MMU) Tick(ticks int) {
 
 			addr := uint16(mmu.read(dmaAddress)) << 8
 			mmu.dmaValue = mmu.read(addr + mmu.dmaOffset)
+			mmu.logAccess(addr+mmu.dmaOffset, cdl.DMA)
 
 			mmu.ppu.DMAWrite(mmu.dmaOffset, mmu.dmaValue)
 			mmu.dmaOffset++
 
 			if mmu.dmaOffset == dmaDuration {
 				mmu.dmaTransfer = false
+				mmu.event(timeline.DMAEnd, dmaAddress, 0)
 			}
 		}
 	}
@@ -98,6 +120,7 @@ func (mmu *MMU) Tick(ticks int) {
 			mmu.dmaTransfer = true
 			mmu.dmaTicks = 0
 			mmu.dmaOffset = 0
+			mmu.event(timeline.DMAStart, dmaAddress, mmu.dmaReg)
 		}
 	}
 
@@ -124,16 +147,37 @@ func (mmu *MMU) SwitchSpeed(doubleSpeed bool) {
 }
 
 func (mmu *MMU) Read(addr uint16) uint8 {
+	return mmu.cpuRead(addr, cdl.Data)
+}
+
+// Fetch reads a byte of the instruction being executed (opcode or operand)
+func (mmu *MMU) Fetch(addr uint16, opcode bool) uint8 {
+	if opcode {
+		return mmu.cpuRead(addr, cdl.Code|cdl.Opcode)
+	}
+	return mmu.cpuRead(addr, cdl.Code|cdl.Operand)
+}
+
+// cpuRead reads addr on behalf of the CPU, logging the access with the specified flags
+func (mmu *MMU) cpuRead(addr uint16, flags cdl.Flags) uint8 {
 	// During DMA, HRAM can still be accessed otherwise return what DMA is reading
 	//if mmu.dmaTransfer && !(0xFF00 <= addr && addr < 0xFFFF) {
 	//	return mmu.dmaValue
 	//}
 	// OAM is inaccessible during DMA
-	if mmu.dmaTransfer && 0xFE00 <= addr && addr < 0xFEA0 {
-		return 0xFF
+	var value uint8 = 0xFF
+	if !(mmu.dmaTransfer && 0xFE00 <= addr && addr < 0xFEA0) {
+		value = mmu.read(addr)
 	}
+	mmu.logAccess(addr, flags)
 
-	return mmu.read(addr)
+	for _, h := range mmu.accessHooks {
+		h.hook(addr, value, value, false)
+	}
+	if mmu.scriptHook != nil {
+		mmu.scriptHook(addr, value, false)
+	}
+	return value
 }
 
 func (mmu *MMU) read(addr uint16) uint8 {
@@ -152,6 +196,9 @@ func (mmu *MMU) read(addr uint16) uint8 {
 	switch {
 	// MBC addresses
 	case addr < 0x8000:
+		if mmu.romPatch != nil {
+			return mmu.romPatch(addr, mmu.Cartridge.Read(addr))
+		}
 		return mmu.Cartridge.Read(addr)
 	case addr < 0xA000: // vRAM
 		return mmu.ppu.Read(addr)
@@ -161,11 +208,7 @@ func (mmu *MMU) read(addr uint16) uint8 {
 		return mmu.wRAM[addr-0xC000]
 	case addr < 0xE000: // wRAM (bank 1-7)
 		baseAddr := addr - 0xD000
-
-		var bank uint16 = 1
-		if mmu.cgb && mmu.vbk > 0 {
-			bank = uint16(mmu.vbk)
-		}
+		bank := mmu.wRAMBank()
 		return mmu.wRAM[0x1000*bank+baseAddr]
 	case addr < 0xFE00: // Echo RAM
 		return mmu.read(addr - 0x2000)
@@ -183,6 +226,19 @@ func (mmu *MMU) Write(addr uint16, value uint8) {
 	// if mmu.dmaTransfer && !(0xFF80 <= addr && addr < 0xFFFF) {
 	// 	return
 	// }
+	if len(mmu.accessHooks) > 0 {
+		old := mmu.DebugRead(addr)
+		for _, h := range mmu.accessHooks {
+			h.hook(addr, old, value, true)
+		}
+	}
+	if mmu.scriptHook != nil {
+		mmu.scriptHook(addr, value, true)
+	}
+	if mmu.eventHook != nil && timelineRegisters[addr] {
+		mmu.eventHook(timeline.Write, addr, value)
+	}
+
 	// OAM is inaccessible during DMA
 	if mmu.dmaTransfer && 0xFE00 <= addr && addr < 0xFEA0 {
 		return
@@ -204,11 +260,7 @@ func (mmu *MMU) write(addr uint16, value uint8) {
 		mmu.wRAM[addr-0xC000] = value
 	case addr < 0xE000: // wRAM (bank 1-7)
 		baseAddr := addr - 0xD000
-
-		var bank uint16 = 1
-		if mmu.cgb && mmu.vbk > 0 {
-			bank = uint16(mmu.vbk)
-		}
+		bank := mmu.wRAMBank()
 		mmu.wRAM[0x1000*bank+baseAddr] = value
 	case addr < 0xFE00: // Echo RAM
 		mmu.Write(addr-0x2000, value)
@@ -229,3 +281,11 @@ func (mmu *MMU) WriteWord(addr uint16, value uint16) {
 	mmu.Write(addr, uint8(value))
 	mmu.Write(addr+1, uint8(value>>8))
 }
+
+// wRAMBank returns the wRAM bank mapped at D000-DFFF (always 1 for DMG, bank 0 selects 1 in CGB)
+func (mmu *MMU) wRAMBank() uint16 {
+	if mmu.cgb && mmu.vbk > 0 {
+		return uint16(mmu.vbk)
+	}
+	return 1
+}
@@ -1,8 +1,10 @@
 package ppu
 
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
 // Debug methods for debugger access to internal PPU state
-// These methods provide read-only access to internal implementation details
-// that are needed for debugging but should not be part of the public API.
+// These methods provide access to internal implementation details (bypassing the
+// restrictions of CPU accesses) that are needed for debugging but should not be part of the public API.
 
 // DebugGetBGTileMapAddr returns the background tile map address
 func (ppu *PPU) DebugGetBGTileMapAddr() uint16 {
@@ -57,3 +59,49 @@ func (ppu *PPU) DebugGetInternalStateLength() int {
 func (ppu *PPU) DebugGetMode() uint8 {
 	return ppu.STAT & 3
 }
+
+// DebugGetFrameCount returns the number of frames (VBlanks) since power on
+func (ppu *PPU) DebugGetFrameCount() uint64 {
+	return ppu.frameCount
+}
+
+// DebugVRAMBank returns the vRAM bank currently mapped at 8000-9FFF
+func (ppu *PPU) DebugVRAMBank() uint8 {
+	return ppu.vRAM.bankNumber
+}
+
+// DebugReadVRAM reads addr (8000-9FFF) in the specified vRAM bank, even while the PPU is drawing
+func (ppu *PPU) DebugReadVRAM(bank uint8, addr uint16) uint8 {
+	return ppu.vRAM.readBank(bank&1, addr)
+}
+
+// DebugWriteVRAM writes addr (8000-9FFF) in the specified vRAM bank, even while the PPU is drawing
+func (ppu *PPU) DebugWriteVRAM(bank uint8, addr uint16, v uint8) {
+	ppu.vRAM.writeBank(bank&1, addr, v)
+}
+
+// DebugReadOAM reads addr (FE00-FEFF) without triggering the OAM bug, even while the PPU is using OAM
+func (ppu *PPU) DebugReadOAM(addr uint16) uint8 {
+	if addr >= 0xFEA0 {
+		return 0xFF
+	}
+	return ppu.oam.read(uint8(addr - OAMStartAddr))
+}
+
+// DebugWriteOAM writes addr (FE00-FE9F) without triggering the OAM bug, even while the PPU is using OAM
+func (ppu *PPU) DebugWriteOAM(addr uint16, v uint8) {
+	if addr < 0xFEA0 {
+		ppu.oam.write(uint8(addr-OAMStartAddr), v)
+	}
+}
+
+// DebugGetPosition returns the current line and dot. While the PPU switches state,
+// the dot is the one of the switch (the last tick may have gone past it)
+func (ppu *PPU) DebugGetPosition() (uint8, int) {
+	return ppu.LY, ppu.dots + min(ppu.internalStateLength, 0)
+}
+
+// SetEventHook sets a function called on PPU mode switches (nil to remove it)
+func (ppu *PPU) SetEventHook(hook timeline.Hook) {
+	ppu.eventHook = hook
+}
@@ -1,6 +1,7 @@
 package ppu
 
 import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/util"
 )
 
@@ -23,6 +24,9 @@ type PPU struct {
 	// First frame after enabling is always blank
 	firstFrame bool
 
+	// Number of VBlanks since power on
+	frameCount uint64
+
 	// Double buffering to avoid screen tearing
 	frontBuffer *[FrameHeight][FrameWidth]uint16
 	backBuffer  *[FrameHeight][FrameWidth]uint16
@@ -73,9 +77,15 @@ type PPU struct {
 	RequestSTATInterrupt   func()
 
 	// Callback to be called on VBlank and HBlank
-	VBlankCallback func()
+	VBlankCallback func() `snapshot:"-"` // Set by the debugger
 	HBlankCallback func()
 
+	// Called when a frame is complete and moved to the front buffer
+	FrameCallback func()
+
+	// Debugger hook called on PPU mode switches
+	eventHook timeline.Hook `snapshot:"-"`
+
 	modeTicksElapsed uint
 }
 
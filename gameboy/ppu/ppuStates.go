@@ -1,5 +1,7 @@
 package ppu
 
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
 const (
 	lineLength = 456
 
@@ -14,7 +16,13 @@ type ppuInternalState interface {
 }
 
 func (ppu *PPU) setState(state ppuInternalState) {
+	mode := ppu.STAT & 3
 	ppu.internalState = state
 	state.Init(ppu) // Here it's where the actual state switching happens
+
+	// Before adding the duration, so that the position is the dot of the switch
+	if ppu.eventHook != nil && ppu.STAT&3 != mode {
+		ppu.eventHook(timeline.Mode, STATAddr, ppu.STAT&3)
+	}
 	ppu.internalStateLength += state.Duration()
 }
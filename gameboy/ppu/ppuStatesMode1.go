@@ -30,12 +30,16 @@ func (st *vBlank) Init(ppu *PPU) {
 
 		ppu.wyCounter = 0
 		ppu.RequestVBlankInterrupt()
+		ppu.frameCount++
 
 		// Frame complete, switch buffers (don't switch on first frame - keep screen blank)
 		if ppu.firstFrame {
 			ppu.firstFrame = false
 		} else {
 			ppu.swapBuffers()
+			if ppu.FrameCallback != nil {
+				ppu.FrameCallback()
+			}
 		}
 		ppu.windowRendered = false
 
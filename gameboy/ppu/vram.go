@@ -32,16 +32,20 @@ func (v *vRAM) Read(addr uint16) uint8 {
 }
 
 func (v *vRAM) read(addr uint16) uint8 {
+	return v.readBank(v.bankNumber, addr)
+}
+
+func (v *vRAM) readBank(bank uint8, addr uint16) uint8 {
 	addr -= vRAMStartAddr
 
 	if addr < tileNums*tileSize { // Tile data
 		tileId := addr / tileSize
 		tileOffset := addr % tileSize
-		return v.tileData[v.bankNumber][tileId].read(tileOffset)
+		return v.tileData[bank][tileId].read(tileOffset)
 	}
 
 	// Tile maps
-	return v.tileMaps[v.bankNumber][addr-tileNums*tileSize]
+	return v.tileMaps[bank][addr-tileNums*tileSize]
 }
 
 func (v *vRAM) Write(addr uint16, value uint8) {
@@ -52,17 +56,21 @@ func (v *vRAM) Write(addr uint16, value uint8) {
 }
 
 func (v *vRAM) write(addr uint16, value uint8) {
+	v.writeBank(v.bankNumber, addr, value)
+}
+
+func (v *vRAM) writeBank(bank uint8, addr uint16, value uint8) {
 	addr -= vRAMStartAddr
 
 	if addr < tileNums*tileSize { // Tile data
 		tileId := addr / tileSize
 		tileOffset := addr % tileSize
-		v.tileData[v.bankNumber][tileId].write(tileOffset, value)
+		v.tileData[bank][tileId].write(tileOffset, value)
 		return
 	}
 
 	// Tile maps
-	v.tileMaps[v.bankNumber][addr-tileNums*tileSize] = value
+	v.tileMaps[bank][addr-tileNums*tileSize] = value
 }
 
 func (ppu *PPU) VDMAWrite(index uint16, value uint8) {
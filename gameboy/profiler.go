@@ -0,0 +1,23 @@
+package gameboy
+
+import "github.com/danielecanzoneri/lucky-boy/gameboy/profiler"
+
+// SetProfiler counts the cycles spent by each instruction in the profiler (nil to stop profiling)
+func (gb *GameBoy) SetProfiler(p *profiler.Profiler) {
+	gb.Profiler = p
+}
+
+// profileInstruction executes an instruction counting its cycles in the profiler.
+// Cycles spent halted are counted at the HALT instruction
+func (gb *GameBoy) profileInstruction(p *profiler.Profiler) {
+	halted := gb.CPU.Halted() || gb.Memory.VDMAActive()
+	pc := gb.CPU.PC
+	if halted {
+		pc = gb.CPU.InstructionPC()
+	}
+	loc := profiler.Location{Bank: gb.Memory.DebugBank(pc), PC: pc}
+
+	start := gb.CPU.Cycles()
+	gb.CPU.ExecuteInstruction()
+	p.Add(loc, gb.CPU.Cycles()-start, halted, gb.PPU.DebugGetFrameCount())
+}
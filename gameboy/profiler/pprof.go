@@ -0,0 +1,152 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WritePprof writes the profile in the pprof format (gzipped protocol buffer, see
+// https://github.com/google/pprof/blob/main/proto/profile.proto) to be read with go tool pprof.
+// Locations are named after their function by name (BB:AAAA if it returns ""), their
+// address is the bank in bits 16 and above and the PC in the low 16 bits
+func (p *Profiler) WritePprof(w io.Writer, program string, name func(Location) string) error {
+	hotspots := p.Hotspots()
+	SortBy(hotspots, ByLocation)
+
+	var b pprofBuilder
+	b.strings = map[string]int{"": 0}
+	b.stringTable = []string{""}
+
+	// Sample types: instructions, cycles and halted cycles (cycles by default)
+	for _, st := range [][2]string{{"instructions", "count"}, {"cycles", "cycles"}, {"halted", "cycles"}} {
+		var vt protoBuffer
+		vt.int(1, b.str(st[0]))
+		vt.int(2, b.str(st[1]))
+		b.profile.bytes(1, vt)
+	}
+
+	functions := make(map[string]int)
+	for i, h := range hotspots {
+		locationID := i + 1
+		var sample protoBuffer
+		sample.packed(1, uint64(locationID))
+		sample.packed(2, h.Count, h.Cycles, h.Halted)
+		b.profile.bytes(2, sample)
+
+		fn := name(h.Location)
+		if fn == "" {
+			fn = fmt.Sprintf("%02X:%04X", h.Location.Bank, h.Location.PC)
+		}
+		functionID, ok := functions[fn]
+		if !ok {
+			functionID = len(functions) + 1
+			functions[fn] = functionID
+
+			var function protoBuffer
+			function.int(1, functionID)
+			function.int(2, b.str(fn))
+			function.int(3, b.str(fn))
+			b.functions.bytes(5, function)
+		}
+
+		var line protoBuffer
+		line.int(1, functionID)
+		var location protoBuffer
+		location.int(1, locationID)
+		location.int(2, 1) // Mapping
+		location.uint(3, uint64(h.Location.Bank)<<16|uint64(h.Location.PC))
+		location.bytes(4, line)
+		b.profile.bytes(4, location)
+	}
+
+	// The whole address space is mapped to the ROM, symbolized here
+	var mapping protoBuffer
+	mapping.int(1, 1)
+	mapping.uint(3, 1<<32)
+	mapping.int(5, b.str(program))
+	mapping.bool(7, true)
+	b.profile.bytes(3, mapping)
+
+	b.profile = append(b.profile, b.functions...)
+	for _, s := range b.stringTable {
+		b.profile.string(6, s)
+	}
+	b.profile.int(9, int(time.Now().UnixNano()))
+	b.profile.int(14, b.str("cycles"))
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.profile); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type pprofBuilder struct {
+	profile   protoBuffer
+	functions protoBuffer
+
+	strings     map[string]int
+	stringTable []string
+}
+
+// str returns the index of s in the string table
+func (b *pprofBuilder) str(s string) int {
+	i, ok := b.strings[s]
+	if !ok {
+		i = len(b.stringTable)
+		b.strings[s] = i
+		b.stringTable = append(b.stringTable, s)
+	}
+	return i
+}
+
+// protoBuffer is an encoded protocol buffer message
+type protoBuffer []byte
+
+func (m *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*m = append(*m, byte(v)|0x80)
+		v >>= 7
+	}
+	*m = append(*m, byte(v))
+}
+
+func (m *protoBuffer) key(field, wireType int) {
+	m.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (m *protoBuffer) uint(field int, v uint64) {
+	m.key(field, 0)
+	m.varint(v)
+}
+
+func (m *protoBuffer) int(field int, v int) {
+	m.uint(field, uint64(v))
+}
+
+func (m *protoBuffer) bool(field int, v bool) {
+	if v {
+		m.uint(field, 1)
+	}
+}
+
+func (m *protoBuffer) bytes(field int, v []byte) {
+	m.key(field, 2)
+	m.varint(uint64(len(v)))
+	*m = append(*m, v...)
+}
+
+func (m *protoBuffer) string(field int, s string) {
+	m.bytes(field, []byte(s))
+}
+
+// packed writes repeated varints
+func (m *protoBuffer) packed(field int, values ...uint64) {
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(v)
+	}
+	m.bytes(field, packed)
+}
@@ -0,0 +1,196 @@
+// Package profiler counts the CPU cycles spent by each instruction and in each frame
+package profiler
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Location of an instruction: the address and the bank mapped there
+type Location struct {
+	Bank uint
+	PC   uint16
+}
+
+// Stats are the cycles counted for a location, a function or the whole profile
+type Stats struct {
+	Count  uint64 // Instructions executed
+	Cycles uint64 // T-cycles spent executing them (including the interrupt dispatches that followed)
+	Halted uint64 // T-cycles spent halted (or stalled by HDMA) after them
+}
+
+func (s *Stats) add(o Stats) {
+	s.Count += o.Count
+	s.Cycles += o.Cycles
+	s.Halted += o.Halted
+}
+
+// Hotspot is the time spent at a location or in a function
+type Hotspot struct {
+	Location Location
+	Name     string // Function name (set by Functions)
+	Stats
+}
+
+// Frame is the time spent between two VBlanks
+type Frame struct {
+	Number uint64 // Frames since power on
+	Stats
+}
+
+// Busy returns the fraction of the frame spent executing instructions
+func (f Frame) Busy() float64 {
+	if total := f.Cycles + f.Halted; total > 0 {
+		return float64(f.Cycles) / float64(total)
+	}
+	return 0
+}
+
+// Profiler accumulates the cycles spent by the CPU. It is fed while the emulation
+// runs and can be read from other goroutines
+type Profiler struct {
+	mu sync.Mutex
+
+	locations map[Location]*Stats
+	total     Stats
+
+	frames  []Frame // Last completed frames, oldest first
+	current Frame
+	started bool
+	size    int
+}
+
+// New returns a profiler keeping the statistics of the specified number of frames
+func New(frames int) *Profiler {
+	return &Profiler{locations: make(map[Location]*Stats), size: frames}
+}
+
+// Add counts the cycles spent by the instruction at loc (executed or halted) in frame
+func (p *Profiler) Add(loc Location, cycles uint64, halted bool, frame uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var s Stats
+	if halted {
+		s.Halted = cycles
+	} else {
+		s.Count, s.Cycles = 1, cycles
+	}
+
+	stats, ok := p.locations[loc]
+	if !ok {
+		stats = new(Stats)
+		p.locations[loc] = stats
+	}
+	stats.add(s)
+	p.total.add(s)
+
+	if !p.started || frame != p.current.Number {
+		if p.started {
+			p.endFrame()
+		}
+		p.current = Frame{Number: frame}
+		p.started = true
+	}
+	p.current.add(s)
+}
+
+func (p *Profiler) endFrame() {
+	if len(p.frames) == p.size {
+		p.frames = append(p.frames[:0], p.frames[1:]...)
+	}
+	p.frames = append(p.frames, p.current)
+}
+
+// Total returns the cycles counted since the profiler was created or reset
+func (p *Profiler) Total() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.total
+}
+
+// Frames returns the last completed frames, oldest first
+func (p *Profiler) Frames() []Frame {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.frames)
+}
+
+// Hotspots returns the locations executed, the most expensive first
+func (p *Profiler) Hotspots() []Hotspot {
+	p.mu.Lock()
+	hotspots := make([]Hotspot, 0, len(p.locations))
+	for loc, s := range p.locations {
+		hotspots = append(hotspots, Hotspot{Location: loc, Stats: *s})
+	}
+	p.mu.Unlock()
+
+	SortBy(hotspots, ByCycles)
+	return hotspots
+}
+
+// Functions sums the hotspots of each function (named by name, the first location
+// is the most expensive one), the most expensive first
+func Functions(hotspots []Hotspot, name func(Location) string) []Hotspot {
+	index := make(map[string]int)
+	var functions []Hotspot
+	for _, h := range hotspots {
+		n := name(h.Location)
+		i, ok := index[n]
+		if !ok {
+			i = len(functions)
+			index[n] = i
+			functions = append(functions, Hotspot{Location: h.Location, Name: n})
+		}
+		functions[i].add(h.Stats)
+	}
+
+	SortBy(functions, ByCycles)
+	return functions
+}
+
+// Order of the hotspots
+type Order int
+
+const (
+	ByCycles Order = iota
+	ByHalted
+	ByCount
+	ByLocation
+)
+
+// SortBy sorts the hotspots: by location in ascending order, by the other values in
+// descending order (then by location)
+func SortBy(hotspots []Hotspot, order Order) {
+	slices.SortFunc(hotspots, func(a, b Hotspot) int {
+		var c int
+		switch order {
+		case ByCycles:
+			c = cmp.Compare(b.Cycles, a.Cycles)
+		case ByHalted:
+			c = cmp.Compare(b.Halted, a.Halted)
+		case ByCount:
+			c = cmp.Compare(b.Count, a.Count)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.Location.Bank, b.Location.Bank)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.Location.PC, b.Location.PC)
+		}
+		return c
+	})
+}
+
+// Reset discards the cycles counted
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.locations = make(map[Location]*Stats)
+	p.total = Stats{}
+	p.frames = nil
+	p.current = Frame{}
+	p.started = false
+}
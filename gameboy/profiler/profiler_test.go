@@ -0,0 +1,107 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestProfiler(t *testing.T) {
+	p := New(2)
+
+	main := Location{Bank: 0, PC: 0x0150}
+	update := Location{Bank: 1, PC: 0x4000}
+	updateLoop := Location{Bank: 1, PC: 0x4010}
+	halt := Location{Bank: 0, PC: 0x0160}
+	for frame := range uint64(3) {
+		p.Add(main, 12, false, frame)
+		p.Add(update, 20, false, frame)
+		p.Add(updateLoop, 12, false, frame)
+		p.Add(updateLoop, 12, false, frame)
+		p.Add(halt, 100, true, frame)
+	}
+	p.Add(main, 12, false, 3)
+
+	if total := p.Total(); total != (Stats{Count: 13, Cycles: 180, Halted: 300}) {
+		t.Errorf("total: got %+v", total)
+	}
+
+	// Frame 0 was discarded, frame 3 is not complete
+	frames := p.Frames()
+	expectedFrame := Stats{Count: 4, Cycles: 56, Halted: 100}
+	if len(frames) != 2 || frames[0].Number != 1 || frames[1].Number != 2 || frames[1].Stats != expectedFrame {
+		t.Errorf("frames: got %+v", frames)
+	}
+	if busy := frames[0].Busy(); busy != 56.0/156 {
+		t.Errorf("busy: got %v", busy)
+	}
+
+	hotspots := p.Hotspots()
+	locations := func(hotspots []Hotspot) []Location {
+		var locs []Location
+		for _, h := range hotspots {
+			locs = append(locs, h.Location)
+		}
+		return locs
+	}
+	if got := locations(hotspots); !slices.Equal(got, []Location{updateLoop, update, main, halt}) {
+		t.Errorf("hotspots by cycles: got %v", got)
+	}
+	SortBy(hotspots, ByCount)
+	if got := locations(hotspots); !slices.Equal(got, []Location{updateLoop, main, update, halt}) {
+		t.Errorf("hotspots by count: got %v", got)
+	}
+	SortBy(hotspots, ByLocation)
+	if got := locations(hotspots); !slices.Equal(got, []Location{main, halt, update, updateLoop}) {
+		t.Errorf("hotspots by location: got %v", got)
+	}
+
+	functions := Functions(hotspots, func(loc Location) string {
+		if loc.Bank == 1 {
+			return "Update"
+		}
+		return "Main"
+	})
+	if len(functions) != 2 || functions[0].Name != "Update" || functions[0].Stats != (Stats{Count: 9, Cycles: 132}) {
+		t.Errorf("functions: got %+v", functions)
+	}
+
+	p.Reset()
+	if total := p.Total(); total != (Stats{}) || len(p.Hotspots()) != 0 || len(p.Frames()) != 0 {
+		t.Errorf("profiler not reset")
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	p := New(1)
+	p.Add(Location{Bank: 2, PC: 0x4567}, 24, false, 0)
+
+	var buf bytes.Buffer
+	if err := p.WritePprof(&buf, "game.gb", func(Location) string { return "" }); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Samples: location 1 with 1 instruction, 24 cycles and 0 halted cycles
+	if !bytes.Contains(data, []byte{0x12, 0x08, 0x0A, 0x01, 0x01, 0x12, 0x03, 0x01, 0x18, 0x00}) {
+		t.Errorf("sample not found")
+	}
+	// Location address: bank and PC
+	if !bytes.Contains(data, []byte{0x18, 0xE7, 0x8A, 0x09}) {
+		t.Errorf("location address not found")
+	}
+	for _, s := range []string{"cycles", "halted", "game.gb", "02:4567"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("string %q not found", s)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package gameboy
+
+import (
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/profiler"
+)
+
+func TestProfiler(t *testing.T) {
+	gb := newTestGameBoy(testrom.WaitVBlank...)
+	p := profiler.New(4)
+	gb.SetProfiler(p)
+	start := gb.CPU.Cycles()
+	for range 3 * 70224 / 4 {
+		gb.Step()
+	}
+
+	total := p.Total()
+	if total.Cycles+total.Halted != gb.CPU.Cycles()-start {
+		t.Errorf("cycles counted: got %d, expected %d", total.Cycles+total.Halted, gb.CPU.Cycles()-start)
+	}
+
+	// Mostly halted, at the HALT instruction
+	hotspots := p.Hotspots()
+	profiler.SortBy(hotspots, profiler.ByHalted)
+	if h := hotspots[0]; h.Location.PC != 0x0155 || h.Halted < total.Halted || total.Halted < 2*70224 {
+		t.Errorf("halted: got %+v, total %d", h, total.Halted)
+	}
+
+	frames := p.Frames()
+	if len(frames) < 2 || frames[1].Cycles+frames[1].Halted != 70224 {
+		t.Errorf("frames: got %+v", frames)
+	}
+}
@@ -0,0 +1,403 @@
+// Package remote streams the emulator to web browsers: an HTTP server serves a page showing
+// the screen and playing the audio, sent over a WebSocket. Several viewers can watch at once,
+// one of them controls the joypad.
+//
+// Messages sent to the page are binary frames and audio, and JSON status updates:
+//
+//	0x01, width, height, x, y (uint16 LE), PNG    a frame: the PNG is drawn at (x, y) on the
+//	                                              previous one (the first is the whole frame)
+//	0x02, samples (int16 LE)                      audio, stereo interleaved (left first)
+//	{"type": "status", "id": 2, "controller": 1, "viewers": 3, "sample_rate": 44100}
+//
+// The page sends JSON messages:
+//
+//	{"type": "buttons", "buttons": 9}   buttons pressed (emulator.Buttons), only from the controller
+//	{"type": "control"}                 takes the control if nobody has it
+//	{"type": "release"}                 gives up the control
+package remote
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/png"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	frameMessage = 0x01
+	audioMessage = 0x02
+
+	// Messages queued for each viewer, frames are dropped if it is slower
+	sendQueueSize = 16
+)
+
+//go:embed web/index.html
+var page []byte
+
+// Server streams the frames and audio pushed to the viewers connected and implements
+// joypad.InputProvider with the buttons pressed by the controller. Frames and audio
+// can be pushed from any goroutine without blocking
+type Server struct {
+	sampleRate int
+
+	mu         sync.Mutex
+	viewers    []*viewer // In order of arrival
+	controller *viewer
+	nextID     int
+	closed     bool
+
+	buttons atomic.Uint32
+
+	frames chan *image.RGBA // Latest frame not broadcast yet
+	done   chan struct{}
+
+	http     *http.Server
+	upgrader websocket.Upgrader
+}
+
+type viewer struct {
+	id   int
+	conn *websocket.Conn
+	send chan message
+
+	// The next frame sent is the whole one (on arrival or after dropping frames)
+	keyframe bool
+}
+
+// status is sent to the viewers when they arrive or leave and when the control changes
+type status struct {
+	Type       string `json:"type"`
+	ID         int    `json:"id"`
+	Controller int    `json:"controller"` // 0 if nobody controls
+	Viewers    int    `json:"viewers"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+// message is queued to be sent to a viewer
+type message struct {
+	text bool
+	data []uint8
+}
+
+type command struct {
+	Type    string           `json:"type"`
+	Buttons emulator.Buttons `json:"buttons"`
+}
+
+// New returns a server streaming audio at the sample rate (stereo)
+func New(sampleRate int) *Server {
+	s := &Server{
+		sampleRate: sampleRate,
+		frames:     make(chan *image.RGBA, 1),
+		done:       make(chan struct{}),
+	}
+	go s.broadcastFrames()
+	return s
+}
+
+// Listen serves the page and the WebSocket on the address (e.g. ":8080") until Close
+func (s *Server) Listen(addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s.http = &http.Server{Handler: s}
+	go s.http.Serve(listener)
+	return listener.Addr(), nil
+}
+
+// ServeHTTP serves the page on / and the stream on /ws
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	case "/ws":
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.serveViewer(conn)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Close disconnects the viewers and stops the server
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	for _, v := range s.viewers {
+		v.conn.Close()
+	}
+	s.mu.Unlock()
+
+	if s.http != nil {
+		return s.http.Close()
+	}
+	return nil
+}
+
+// Viewers returns the number of viewers connected
+func (s *Server) Viewers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.viewers)
+}
+
+// IsKeyPressed implements joypad.InputProvider with the buttons of the controller
+func (s *Server) IsKeyPressed(key joypad.Key) bool {
+	return s.buttons.Load()&(1<<key) != 0
+}
+
+// PushFrame sends a frame to the viewers (only what changed since the previous one).
+// The frame is copied, so it can be reused
+func (s *Server) PushFrame(frame *image.RGBA) {
+	if s.Viewers() == 0 {
+		return
+	}
+
+	copied := image.NewRGBA(image.Rect(0, 0, frame.Rect.Dx(), frame.Rect.Dy()))
+	for y := range frame.Rect.Dy() {
+		i := frame.PixOffset(frame.Rect.Min.X, frame.Rect.Min.Y+y)
+		copy(copied.Pix[y*copied.Stride:], frame.Pix[i:i+4*frame.Rect.Dx()])
+	}
+
+	// Replace the frame not broadcast yet, if any
+	select {
+	case <-s.frames:
+	default:
+	}
+	select {
+	case s.frames <- copied:
+	default:
+	}
+}
+
+// PushAudio sends the samples (stereo interleaved, from -1 to 1) to the viewers
+func (s *Server) PushAudio(samples []float32) {
+	if len(samples) == 0 || s.Viewers() == 0 {
+		return
+	}
+
+	data := make([]uint8, 1+2*len(samples))
+	data[0] = audioMessage
+	for i, sample := range samples {
+		v := int16(math.Round(float64(max(-1, min(sample, 1))) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(data[1+2*i:], uint16(v))
+	}
+	msg := message{data: data}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.viewers {
+		select {
+		case v.send <- msg:
+		default:
+		}
+	}
+}
+
+// broadcastFrames encodes the frames pushed and sends them to the viewers
+func (s *Server) broadcastFrames() {
+	var previous *image.RGBA
+	for {
+		var frame *image.RGBA
+		select {
+		case frame = <-s.frames:
+		case <-s.done:
+			return
+		}
+
+		// Encoded on demand, at most once
+		var delta, whole []uint8
+		changed := changedRect(previous, frame)
+
+		s.mu.Lock()
+		for _, v := range s.viewers {
+			var msg message
+			if v.keyframe || previous == nil || frame.Rect != previous.Rect {
+				if whole == nil {
+					whole = encodeFrame(frame, frame.Rect)
+				}
+				msg.data = whole
+			} else if !changed.Empty() {
+				if delta == nil {
+					delta = encodeFrame(frame, changed)
+				}
+				msg.data = delta
+			} else {
+				continue
+			}
+
+			select {
+			case v.send <- msg:
+				v.keyframe = false
+			default:
+				// Slow viewer: the next deltas would be drawn on a frame it did not receive
+				v.keyframe = true
+			}
+		}
+		s.mu.Unlock()
+
+		previous = frame
+	}
+}
+
+// changedRect returns the bounds of the pixels that differ between two frames of the same size
+func changedRect(previous, frame *image.RGBA) image.Rectangle {
+	if previous == nil || previous.Rect != frame.Rect {
+		return frame.Rect
+	}
+
+	var r image.Rectangle
+	width := 4 * frame.Rect.Dx()
+	for y := range frame.Rect.Dy() {
+		row, prevRow := frame.Pix[y*frame.Stride:][:width], previous.Pix[y*previous.Stride:][:width]
+		if bytes.Equal(row, prevRow) {
+			continue
+		}
+
+		x0 := 0
+		for row[x0] == prevRow[x0] {
+			x0++
+		}
+		x1 := width
+		for row[x1-1] == prevRow[x1-1] {
+			x1--
+		}
+		r = r.Union(image.Rect(x0/4, y, (x1+3)/4, y+1))
+	}
+	return r
+}
+
+// encodeFrame returns the frame message of a rectangle of the frame
+func encodeFrame(frame *image.RGBA, r image.Rectangle) []uint8 {
+	var buf bytes.Buffer
+	header := []uint16{uint16(frame.Rect.Dx()), uint16(frame.Rect.Dy()), uint16(r.Min.X), uint16(r.Min.Y)}
+	buf.WriteByte(frameMessage)
+	binary.Write(&buf, binary.LittleEndian, header)
+
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	encoder.Encode(&buf, frame.SubImage(r))
+	return buf.Bytes()
+}
+
+// serveViewer sends the stream to a viewer and reads its commands until it leaves
+func (s *Server) serveViewer(conn *websocket.Conn) {
+	v := &viewer{conn: conn, send: make(chan message, sendQueueSize), keyframe: true}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.nextID++
+	v.id = s.nextID
+	s.viewers = append(s.viewers, v)
+	if s.controller == nil {
+		s.setController(v)
+	}
+	s.sendStatus()
+	s.mu.Unlock()
+
+	// The queue is closed when the viewer leaves
+	go func() {
+		for msg := range v.send {
+			messageType := websocket.BinaryMessage
+			if msg.text {
+				messageType = websocket.TextMessage
+			}
+			if err := conn.WriteMessage(messageType, msg.data); err != nil {
+				conn.Close()
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var cmd command
+		if json.Unmarshal(data, &cmd) == nil {
+			s.handle(v, &cmd)
+		}
+	}
+
+	s.mu.Lock()
+	for i, other := range s.viewers {
+		if other == v {
+			s.viewers = append(s.viewers[:i], s.viewers[i+1:]...)
+			break
+		}
+	}
+	if s.controller == v {
+		s.setController(nil)
+	}
+	close(v.send)
+	s.sendStatus()
+	s.mu.Unlock()
+	conn.Close()
+}
+
+func (s *Server) handle(v *viewer, cmd *command) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch cmd.Type {
+	case "buttons":
+		if s.controller == v {
+			s.buttons.Store(uint32(cmd.Buttons))
+		}
+	case "control":
+		if s.controller == nil {
+			s.setController(v)
+			s.sendStatus()
+		}
+	case "release":
+		if s.controller == v {
+			s.setController(nil)
+			s.sendStatus()
+		}
+	}
+}
+
+// setController gives the control to a viewer (or nobody), releasing the buttons
+func (s *Server) setController(v *viewer) {
+	s.controller = v
+	s.buttons.Store(0)
+}
+
+// sendStatus sends the status to each viewer (the lock must be held)
+func (s *Server) sendStatus() {
+	st := status{Type: "status", Viewers: len(s.viewers), SampleRate: s.sampleRate}
+	if s.controller != nil {
+		st.Controller = s.controller.id
+	}
+	for _, v := range s.viewers {
+		st.ID = v.id
+		data, _ := json.Marshal(st)
+		select {
+		case v.send <- message{text: true, data: data}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/gorilla/websocket"
+)
+
+type client struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dial(t *testing.T, addr string) *client {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn}
+}
+
+// next returns the next message of the type (status, frame or audio), skipping the others
+func (c *client) next(kind string) []uint8 {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.t.Fatalf("waiting for %s: %v", kind, err)
+		}
+		switch {
+		case messageType == websocket.TextMessage && kind == "status",
+			messageType == websocket.BinaryMessage && data[0] == frameMessage && kind == "frame",
+			messageType == websocket.BinaryMessage && data[0] == audioMessage && kind == "audio":
+			return data
+		}
+	}
+}
+
+func (c *client) status() status {
+	c.t.Helper()
+	var st status
+	if err := json.Unmarshal(c.next("status"), &st); err != nil {
+		c.t.Fatal(err)
+	}
+	return st
+}
+
+// frame returns the position and the image of the next frame message
+func (c *client) frame() (image.Point, image.Image) {
+	c.t.Helper()
+	data := c.next("frame")
+	var header [4]uint16
+	binary.Read(bytes.NewReader(data[1:9]), binary.LittleEndian, &header)
+	img, err := png.Decode(bytes.NewReader(data[9:]))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return image.Pt(int(header[2]), int(header[3])), img
+}
+
+func (c *client) send(cmd command) {
+	if err := c.conn.WriteJSON(cmd); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// waitFor polls a condition updated by the server
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := New(44100)
+	defer s.Close()
+	addr, err := s.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get("http://" + addr.String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "<canvas") {
+		t.Error("page without canvas")
+	}
+
+	// The first viewer controls
+	first := dial(t, addr.String())
+	if st := first.status(); st.ID != 1 || st.Controller != 1 || st.Viewers != 1 || st.SampleRate != 44100 {
+		t.Errorf("first viewer: got %+v", st)
+	}
+	second := dial(t, addr.String())
+	if st := second.status(); st.ID != 2 || st.Controller != 1 || st.Viewers != 2 {
+		t.Errorf("second viewer: got %+v", st)
+	}
+	first.status()
+
+	// Whole frame, then only the pixel changed
+	frame := image.NewRGBA(image.Rect(0, 0, 160, 144))
+	s.PushFrame(frame)
+	for _, c := range []*client{first, second} {
+		if pos, img := c.frame(); pos != (image.Point{}) || img.Bounds().Size() != frame.Rect.Size() {
+			t.Errorf("first frame: got %v at %v", img.Bounds(), pos)
+		}
+	}
+	frame.SetRGBA(10, 20, color.RGBA{R: 0xFF, A: 0xFF})
+	s.PushFrame(frame)
+	pos, img := first.frame()
+	if pos != image.Pt(10, 20) || img.Bounds().Size() != image.Pt(1, 1) {
+		t.Errorf("delta: got %v at %v", img.Bounds(), pos)
+	} else if r, _, _, _ := img.At(img.Bounds().Min.X, img.Bounds().Min.Y).RGBA(); r != 0xFFFF {
+		t.Errorf("delta: got red %04X", r)
+	}
+
+	s.PushAudio([]float32{1, -1})
+	if data := second.next("audio"); !bytes.Equal(data, []uint8{audioMessage, 0xFF, 0x7F, 0x01, 0x80}) {
+		t.Errorf("audio: got % X", data)
+	}
+
+	// Only the controller presses the buttons
+	second.send(command{Type: "buttons", Buttons: emulator.ButtonB})
+	first.send(command{Type: "buttons", Buttons: emulator.ButtonA | emulator.ButtonUp})
+	waitFor(t, "buttons", func() bool { return s.IsKeyPressed(joypad.KeyA) })
+	if !s.IsKeyPressed(joypad.KeyUp) || s.IsKeyPressed(joypad.KeyB) {
+		t.Error("buttons of the other viewer pressed")
+	}
+
+	// Taken by another viewer when the controller leaves
+	second.send(command{Type: "control"})
+	first.conn.Close()
+	if st := second.status(); st.Controller != 0 || st.Viewers != 1 {
+		t.Errorf("controller left: got %+v", st)
+	}
+	if s.IsKeyPressed(joypad.KeyA) {
+		t.Error("buttons of the controller gone still pressed")
+	}
+	second.send(command{Type: "control"})
+	if st := second.status(); st.Controller != 2 {
+		t.Errorf("control taken: got %+v", st)
+	}
+	second.send(command{Type: "release"})
+	if st := second.status(); st.Controller != 0 {
+		t.Errorf("control released: got %+v", st)
+	}
+}
+
+func TestChangedRect(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	b := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	if r := changedRect(a, b); !r.Empty() {
+		t.Errorf("equal frames: got %v", r)
+	}
+
+	b.Pix[b.PixOffset(2, 3)+1] = 1
+	b.Pix[b.PixOffset(5, 6)] = 1
+	if r := changedRect(a, b); r != image.Rect(2, 3, 6, 7) {
+		t.Errorf("got %v", r)
+	}
+	if r := changedRect(nil, b); r != b.Rect {
+		t.Errorf("no previous frame: got %v", r)
+	}
+}
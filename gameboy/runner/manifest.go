@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+)
+
+// Manifest lists the tests of a suite, read from a JSON file:
+//
+//	{
+//		"name": "homebrew",
+//		"tests": [
+//			{
+//				"name": "title screen",
+//				"rom": "roms/game.gb",
+//				"model": "cgb",
+//				"frames": 600,
+//				"input": [{"frame": 120, "buttons": "start"}, {"frame": 125, "buttons": ""}],
+//				"screenshot": "9f86d081884c7d65...",
+//				"ram": {"C0A0": 3, "FF80": 0},
+//				"serial": "Passed"
+//			}
+//		]
+//	}
+//
+// Paths are relative to the manifest
+type Manifest struct {
+	Name  string `json:"name"`
+	Tests []Test `json:"tests"`
+}
+
+// Test runs a ROM for some frames and checks the state reached
+type Test struct {
+	Name    string `json:"name"`
+	ROM     string `json:"rom"`
+	BootROM string `json:"boot_rom"` // Skipped if empty
+	Model   string `json:"model"`    // auto (default), dmg, cgb or sgb
+	Frames  int    `json:"frames"`
+
+	// Buttons pressed from a frame on (until the next input)
+	Input []Input `json:"input"`
+
+	// Expected results (only the ones set are checked)
+	Screenshot string           `json:"screenshot"` // SHA-256 of the RGBA pixels of the last frame, in hex
+	RAM        map[string]uint8 `json:"ram"`        // Values by address (in hex, as mapped at the end)
+	Serial     string           `json:"serial"`     // Contained in the bytes sent through the serial port
+}
+
+// Input presses the buttons (e.g. "up+a", "" to release them) from the frame on
+type Input struct {
+	Frame   int    `json:"frame"`
+	Buttons string `json:"buttons"`
+}
+
+// LoadManifest reads a manifest, resolving the paths of the tests and checking them.
+// The suite is named after the file if the manifest has no name
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	dir := filepath.Dir(path)
+	for i := range m.Tests {
+		t := &m.Tests[i]
+		if t.Name == "" {
+			t.Name = t.ROM
+		}
+		if err := t.check(); err != nil {
+			return nil, fmt.Errorf("%s: test %q: %w", path, t.Name, err)
+		}
+
+		t.ROM = resolve(dir, t.ROM)
+		if t.BootROM != "" {
+			t.BootROM = resolve(dir, t.BootROM)
+		}
+	}
+	return &m, nil
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// check validates the test fields
+func (t *Test) check() error {
+	if t.ROM == "" {
+		return fmt.Errorf("missing rom")
+	}
+	if t.Frames <= 0 {
+		return fmt.Errorf("frames must be positive")
+	}
+	if _, err := t.model(); err != nil {
+		return err
+	}
+	if _, err := t.ramAddresses(); err != nil {
+		return err
+	}
+	for _, in := range t.Input {
+		if _, err := emulator.ParseButtons(in.Buttons); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Test) model() (gameboy.SystemModel, error) {
+	if t.Model == "" {
+		return gameboy.Auto, nil
+	}
+	return gameboy.ParseModel(t.Model)
+}
+
+// ramAddresses parses the addresses of the RAM values ("C000", "$C000" or "0xC000")
+func (t *Test) ramAddresses() (map[uint16]uint8, error) {
+	values := make(map[uint16]uint8, len(t.RAM))
+	for key, v := range t.RAM {
+		s := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(key), "$"), "0x")
+		addr, err := strconv.ParseUint(s, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q", key)
+		}
+		values[uint16(addr)] = v
+	}
+	return values, nil
+}
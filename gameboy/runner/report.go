@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// JUnit XML report, as read by CI servers
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Suite is the results of the tests of a manifest
+type Suite struct {
+	Manifest *Manifest
+	Results  []Result
+}
+
+// WriteJUnit writes the results of the suites as JUnit XML
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	var report junitSuites
+	var total time.Duration
+	for _, s := range suites {
+		js := junitSuite{Name: s.Manifest.Name, Tests: len(s.Results)}
+		var duration time.Duration
+		for _, r := range s.Results {
+			c := junitCase{
+				Name:      r.Test.Name,
+				ClassName: s.Manifest.Name,
+				Time:      seconds(r.Duration),
+				SystemOut: r.Serial,
+			}
+			if r.Err != nil {
+				js.Errors++
+				c.Error = &junitProblem{Message: r.Err.Error()}
+			} else if len(r.Failures) > 0 {
+				js.Failures++
+				c.Failure = &junitProblem{Message: r.Failures[0], Text: strings.Join(r.Failures, "\n")}
+			}
+			duration += r.Duration
+			js.Cases = append(js.Cases, c)
+		}
+		js.Time = seconds(duration)
+
+		report.Tests += js.Tests
+		report.Failures += js.Failures
+		report.Errors += js.Errors
+		total += duration
+		report.Suites = append(report.Suites, js)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteSummary writes a line for each test and the totals, failures are detailed.
+// It returns the number of tests that did not pass
+func WriteSummary(w io.Writer, suites []Suite, elapsed time.Duration) int {
+	var passed, failed, errors int
+	for _, s := range suites {
+		for _, r := range s.Results {
+			name := s.Manifest.Name + "/" + r.Test.Name
+			switch {
+			case r.Err != nil:
+				errors++
+				fmt.Fprintf(w, "ERROR %s: %v\n", name, r.Err)
+			case len(r.Failures) > 0:
+				failed++
+				fmt.Fprintf(w, "FAIL  %s (%s)\n", name, r.Duration.Round(time.Millisecond))
+				for _, f := range r.Failures {
+					fmt.Fprintf(w, "      %s\n", f)
+				}
+			default:
+				passed++
+				fmt.Fprintf(w, "PASS  %s (%s)\n", name, r.Duration.Round(time.Millisecond))
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d errors in %s\n", passed, failed, errors, elapsed.Round(time.Millisecond))
+	return failed + errors
+}
@@ -0,0 +1,170 @@
+// Package runner runs suites of test ROMs for regressions: each test runs a ROM for some
+// frames pressing the buttons of its input script, then checks the screen, RAM values and
+// serial output against the expected ones. Tests run in parallel, each on its own Game Boy.
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/emulator"
+)
+
+// Serial registers (a transfer starts writing SC with the internal clock)
+const (
+	addrSB = 0xFF01
+	addrSC = 0xFF02
+)
+
+// Result of a test
+type Result struct {
+	Test     *Test
+	Duration time.Duration
+
+	Err      error    // The test could not run (e.g. missing ROM)
+	Failures []string // Expected results not matched
+
+	Screenshot string      // Hash of the last frame
+	Serial     string      // Bytes sent through the serial port
+	Frame      *image.RGBA // Last frame
+}
+
+// Passed reports whether the test ran and matched all the expected results
+func (r *Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Run runs the tests of the manifest on the specified number of goroutines (the number
+// of CPUs if not positive) and returns the results in the order of the tests
+func Run(m *Manifest, workers int) []Result {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(m.Tests))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(m.Tests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = RunTest(&m.Tests[i])
+			}
+		}()
+	}
+
+	for i := range m.Tests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// RunTest runs a test on a new Game Boy. A panic of the emulator is reported as the
+// error of the test, so that the other tests still run
+func RunTest(t *Test) (r Result) {
+	start := time.Now()
+	r.Test = t
+	defer func() {
+		if v := recover(); v != nil {
+			r.Err = fmt.Errorf("emulator panic: %v", v)
+		}
+		r.Duration = time.Since(start)
+	}()
+
+	r.Err = r.run(t)
+	return r
+}
+
+func (r *Result) run(t *Test) error {
+	rom, err := os.ReadFile(t.ROM)
+	if err != nil {
+		return err
+	}
+	var bootROM []uint8
+	if t.BootROM != "" {
+		if bootROM, err = os.ReadFile(t.BootROM); err != nil {
+			return err
+		}
+	}
+	model, err := t.model()
+	if err != nil {
+		return err
+	}
+	ram, err := t.ramAddresses()
+	if err != nil {
+		return err
+	}
+
+	emu, err := emulator.New(rom,
+		emulator.WithModel(model),
+		emulator.WithBootROM(bootROM),
+		emulator.WithSampleRate(0),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Capture the bytes sent through the serial port (as the test ROMs print their results)
+	gb := emu.GameBoy()
+	var serial strings.Builder
+	gb.SetHooks(gameboy.Hooks{Access: func(addr uint16, value uint8, write bool) {
+		if write && addr == addrSC && value&0x81 == 0x81 {
+			serial.WriteByte(gb.Memory.DebugRead(addrSB))
+		}
+	}})
+
+	input := slices.Clone(t.Input)
+	slices.SortStableFunc(input, func(a, b Input) int { return a.Frame - b.Frame })
+	for frame := range t.Frames {
+		for len(input) > 0 && input[0].Frame <= frame {
+			buttons, _ := emulator.ParseButtons(input[0].Buttons)
+			emu.SetButtons(buttons)
+			input = input[1:]
+		}
+		emu.RunFrame()
+	}
+
+	r.Frame = emu.Frame()
+	r.Screenshot = Hash(r.Frame)
+	r.Serial = serial.String()
+
+	if t.Screenshot != "" && !strings.EqualFold(t.Screenshot, r.Screenshot) {
+		r.Failures = append(r.Failures, fmt.Sprintf("screenshot: got %s, expected %s", r.Screenshot, t.Screenshot))
+	}
+	addresses := make([]uint16, 0, len(ram))
+	for addr := range ram {
+		addresses = append(addresses, addr)
+	}
+	slices.Sort(addresses)
+	for _, addr := range addresses {
+		if v := gb.Memory.DebugRead(addr); v != ram[addr] {
+			r.Failures = append(r.Failures, fmt.Sprintf("$%04X: got $%02X, expected $%02X", addr, v, ram[addr]))
+		}
+	}
+	if t.Serial != "" && !strings.Contains(r.Serial, t.Serial) {
+		r.Failures = append(r.Failures, fmt.Sprintf("serial: got %q, expected %q", r.Serial, t.Serial))
+	}
+	return nil
+}
+
+// Hash returns the SHA-256 of the pixels of a frame, in hex
+func Hash(frame *image.RGBA) string {
+	h := sha256.New()
+	for y := frame.Rect.Min.Y; y < frame.Rect.Max.Y; y++ {
+		i := frame.PixOffset(frame.Rect.Min.X, y)
+		h.Write(frame.Pix[i : i+4*frame.Rect.Dx()])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
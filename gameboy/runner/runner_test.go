@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+)
+
+// Prints "OK" through the serial port, sets $C000 and copies the buttons to $C001
+func testROM() []uint8 {
+	return testrom.New(
+		0x3E, 'O', // LD A, 'O'
+		0xE0, 0x01, // LDH (SB), A
+		0x3E, 0x81, // LD A, $81
+		0xE0, 0x02, // LDH (SC), A
+		0x3E, 'K', // LD A, 'K'
+		0xE0, 0x01, // LDH (SB), A
+		0x3E, 0x81, // LD A, $81
+		0xE0, 0x02, // LDH (SC), A
+		0x3E, 0x42, // LD A, $42
+		0xEA, 0x00, 0xC0, // LD ($C000), A
+		0x3E, 0x10, // loop: LD A, $10 (buttons)
+		0xE0, 0x00, // LDH (P1), A
+		0xF0, 0x00, // LDH A, (P1)
+		0xEA, 0x01, 0xC0, // LD ($C001), A
+		0x18, 0xF5, // JR loop
+	)
+}
+
+func writeManifest(t *testing.T, manifest string) string {
+	dir := t.TempDir()
+	badHeader := testROM()
+	badHeader[0x147] = 0xFC // Pocket Camera
+	roms := map[string][]uint8{
+		"test.gb":      testROM(),
+		"bad.gb":       badHeader,
+		"truncated.gb": testROM()[:0x150], // Jumps out of the ROM
+	}
+	for name, rom := range roms {
+		if err := os.WriteFile(filepath.Join(dir, name), rom, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "suite.json")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	path := writeManifest(t, `{"tests": [
+		{"name": "pass", "rom": "test.gb", "frames": 10, "serial": "OK",
+			"input": [{"frame": 5, "buttons": ""}, {"frame": 0, "buttons": "a"}],
+			"ram": {"C000": 66, "$C001": 223}},
+		{"name": "fail", "rom": "test.gb", "frames": 10, "serial": "KO",
+			"input": [{"frame": 0, "buttons": "a"}],
+			"ram": {"0xC001": 223}, "screenshot": "00"},
+		{"name": "missing", "rom": "missing.gb", "frames": 1},
+		{"name": "bad header", "rom": "bad.gb", "frames": 1},
+		{"name": "truncated", "rom": "truncated.gb", "frames": 1}
+	]}`)
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "suite" || len(m.Tests) != 5 {
+		t.Fatalf("got manifest %+v", m)
+	}
+
+	results := Run(m, 2)
+	if r := results[0]; !r.Passed() || r.Serial != "OK" {
+		t.Errorf("pass: got error %v, failures %q, serial %q", r.Err, r.Failures, r.Serial)
+	}
+	if r := results[1]; r.Err != nil || len(r.Failures) != 3 {
+		t.Errorf("fail: got error %v, failures %q", r.Err, r.Failures)
+	}
+	for _, r := range results[2:] {
+		if r.Err == nil {
+			t.Errorf("%s: no error", r.Test.Name)
+		}
+	}
+	if err := results[4].Err; err == nil || !strings.Contains(err.Error(), "panic") {
+		t.Errorf("truncated: got error %v", err)
+	}
+
+	// Independent instances give the same frames
+	if results[0].Screenshot != results[1].Screenshot || results[0].Screenshot != Hash(results[1].Frame) {
+		t.Errorf("different screenshots %s and %s", results[0].Screenshot, results[1].Screenshot)
+	}
+
+	suites := []Suite{{Manifest: m, Results: results}}
+	var junit, summary strings.Builder
+	if err := WriteJUnit(&junit, suites); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`tests="5" failures="1" errors="3"`, `<testcase name="fail" classname="suite"`, `<failure message="screenshot: got `} {
+		if !strings.Contains(junit.String(), s) {
+			t.Errorf("JUnit report without %q:\n%s", s, junit.String())
+		}
+	}
+	if failed := WriteSummary(&summary, suites, 0); failed != 4 || !strings.Contains(summary.String(), "1 passed, 1 failed, 3 errors") {
+		t.Errorf("got %d failed, summary:\n%s", failed, summary.String())
+	}
+}
+
+func TestInvalidManifest(t *testing.T) {
+	for _, manifest := range []string{
+		`{"tests": [{"rom": "test.gb"}]}`,
+		`{"tests": [{"rom": "test.gb", "frames": 1, "ram": {"G000": 0}}]}`,
+		`{"tests": [{"rom": "test.gb", "frames": 1, "input": [{"buttons": "x"}]}]}`,
+		`{"tests": [{"rom": "test.gb", "frames": 1, "model": "gba"}]}`,
+	} {
+		if _, err := LoadManifest(writeManifest(t, manifest)); err == nil {
+			t.Errorf("%s: loaded", manifest)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package script
+
+import (
+	"image/color"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cpu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// The Lua libraries available to the scripts:
+//
+//	event.onframe(fn)                fn() after each frame
+//	event.onexec(addr, fn)           fn(pc) before executing the instruction at addr
+//	event.onread(addr, [last,] fn)   fn(addr, value) when the CPU reads addr (up to last)
+//	event.onwrite(addr, [last,] fn)  fn(addr, value) when the CPU writes addr (up to last)
+//	event.remove(id)                 removes a callback (event.on* return its id)
+//
+//	memory.read(addr), memory.read16(addr), memory.write(addr, v), memory.write16(addr, v)
+//	memory.bank(addr)                bank mapped at addr
+//	cpu.get(reg), cpu.set(reg, v)    registers a, f, b, c, d, e, h, l, af, bc, de, hl, sp, pc
+//
+//	joypad.set(keys)                 presses (true) or releases (false) the keys of the table
+//	                                 (a, b, select, start, right, left, up, down) until cleared
+//	joypad.clear()                   gives the keys back to the player
+//	joypad.get()                     table of the keys pressed
+//
+//	gui.text(x, y, s, [color]), gui.rect(x, y, w, h, [color]), gui.fill(x, y, w, h, [color]),
+//	gui.line(x1, y1, x2, y2, [color]), gui.pixel(x, y, [color])
+//	                                 draw on the screen until the end of the next frame, colors are
+//	                                 names, "#RRGGBB", "#RRGGBBAA" or 0xRRGGBB (white by default)
+//
+//	state.save(), state.load(s)      save and load states in memory (since the last reset)
+//	emu.frame(), emu.cycles()        frames and CPU cycles since power on
+
+const stateType = "state"
+
+func (e *Engine) openLibs() {
+	L := e.vm
+	libs := map[string]map[string]lua.LGFunction{
+		"event": {
+			"onframe": e.onFrame,
+			"onexec":  e.onExec,
+			"onread":  func(L *lua.LState) int { return e.onAccess(L, false) },
+			"onwrite": func(L *lua.LState) int { return e.onAccess(L, true) },
+			"remove":  e.removeCallback,
+		},
+		"memory": {
+			"read":    e.readMemory,
+			"read16":  e.readMemory16,
+			"write":   e.writeMemory,
+			"write16": e.writeMemory16,
+			"bank":    e.memoryBank,
+		},
+		"cpu": {
+			"get": e.getRegister,
+			"set": e.setRegister,
+		},
+		"joypad": {
+			"set":   e.setKeys,
+			"clear": e.clearKeys,
+			"get":   e.getKeys,
+		},
+		"gui": {
+			"text":  e.drawText,
+			"rect":  func(L *lua.LState) int { return e.drawRect(L, Rect) },
+			"fill":  func(L *lua.LState) int { return e.drawRect(L, FilledRect) },
+			"line":  e.drawLine,
+			"pixel": e.drawPixel,
+		},
+		"state": {
+			"save": e.saveState,
+			"load": e.loadState,
+		},
+		"emu": {
+			"frame":  e.frameCount,
+			"cycles": e.cycles,
+		},
+	}
+	for name, funcs := range libs {
+		L.SetGlobal(name, L.SetFuncs(L.NewTable(), funcs))
+	}
+	L.NewTypeMetatable(stateType)
+}
+
+// checkAddress returns the n-th argument, an address
+func checkAddress(L *lua.LState, n int) uint16 {
+	addr := L.CheckInt(n)
+	if addr < 0 || addr > 0xFFFF {
+		L.ArgError(n, "address out of range")
+	}
+	return uint16(addr)
+}
+
+// Events
+
+func (e *Engine) onFrame(L *lua.LState) int {
+	id := e.addCallback(&callback{fn: L.CheckFunction(1)}, &e.frame)
+	L.Push(lua.LNumber(id))
+	return 1
+}
+
+func (e *Engine) onExec(L *lua.LState) int {
+	pc := checkAddress(L, 1)
+	list := e.exec[pc]
+	id := e.addCallback(&callback{fn: L.CheckFunction(2)}, &list)
+	e.exec[pc] = list
+	e.updateHooks() // Now that the list is in the map
+
+	L.Push(lua.LNumber(id))
+	return 1
+}
+
+func (e *Engine) onAccess(L *lua.LState, write bool) int {
+	cb := &callback{first: checkAddress(L, 1), write: write}
+	cb.last = cb.first
+	if L.GetTop() >= 3 {
+		cb.last = checkAddress(L, 2)
+		cb.fn = L.CheckFunction(3)
+	} else {
+		cb.fn = L.CheckFunction(2)
+	}
+
+	L.Push(lua.LNumber(e.addCallback(cb, &e.access)))
+	return 1
+}
+
+func (e *Engine) removeCallback(L *lua.LState) int {
+	L.Push(lua.LBool(e.remove(L.CheckInt(1))))
+	return 1
+}
+
+// Memory
+
+func (e *Engine) readMemory(L *lua.LState) int {
+	L.Push(lua.LNumber(e.gb.Memory.DebugRead(checkAddress(L, 1))))
+	return 1
+}
+
+func (e *Engine) readMemory16(L *lua.LState) int {
+	addr := checkAddress(L, 1)
+	lo, hi := e.gb.Memory.DebugRead(addr), e.gb.Memory.DebugRead(addr+1)
+	L.Push(lua.LNumber(uint16(hi)<<8 | uint16(lo)))
+	return 1
+}
+
+func (e *Engine) writeMemory(L *lua.LState) int {
+	e.gb.Memory.DebugWrite(checkAddress(L, 1), uint8(L.CheckInt(2)))
+	return 0
+}
+
+func (e *Engine) writeMemory16(L *lua.LState) int {
+	addr, value := checkAddress(L, 1), uint16(L.CheckInt(2))
+	e.gb.Memory.DebugWrite(addr, uint8(value))
+	e.gb.Memory.DebugWrite(addr+1, uint8(value>>8))
+	return 0
+}
+
+func (e *Engine) memoryBank(L *lua.LState) int {
+	L.Push(lua.LNumber(e.gb.Memory.DebugBank(checkAddress(L, 1))))
+	return 1
+}
+
+// Registers
+
+func registers(c *cpu.CPU) map[string]*uint8 {
+	return map[string]*uint8{"a": &c.A, "f": &c.F, "b": &c.B, "c": &c.C, "d": &c.D, "e": &c.E, "h": &c.H, "l": &c.L}
+}
+
+func registerPairs(c *cpu.CPU) map[string][2]*uint8 {
+	return map[string][2]*uint8{"af": {&c.A, &c.F}, "bc": {&c.B, &c.C}, "de": {&c.D, &c.E}, "hl": {&c.H, &c.L}}
+}
+
+func (e *Engine) getRegister(L *lua.LState) int {
+	c := e.gb.CPU
+	name := L.CheckString(1)
+
+	var value uint16
+	if r, ok := registers(c)[name]; ok {
+		value = uint16(*r)
+	} else if p, ok := registerPairs(c)[name]; ok {
+		value = uint16(*p[0])<<8 | uint16(*p[1])
+	} else if name == "sp" {
+		value = c.SP
+	} else if name == "pc" {
+		value = c.PC
+	} else {
+		L.ArgError(1, "unknown register "+name)
+	}
+
+	L.Push(lua.LNumber(value))
+	return 1
+}
+
+func (e *Engine) setRegister(L *lua.LState) int {
+	c := e.gb.CPU
+	name, value := L.CheckString(1), uint16(L.CheckInt(2))
+
+	// The low nibble of F is always 0
+	if name == "f" || name == "af" {
+		value &= 0xFFF0
+	}
+
+	if r, ok := registers(c)[name]; ok {
+		*r = uint8(value)
+	} else if p, ok := registerPairs(c)[name]; ok {
+		*p[0], *p[1] = uint8(value>>8), uint8(value)
+	} else if name == "sp" {
+		c.SP = value
+	} else if name == "pc" {
+		c.PC = value
+	} else {
+		L.ArgError(1, "unknown register "+name)
+	}
+	return 0
+}
+
+// Joypad
+
+var keyNames = map[string]joypad.Key{
+	"start": joypad.KeyStart, "select": joypad.KeySelect, "b": joypad.KeyB, "a": joypad.KeyA,
+	"down": joypad.KeyDown, "up": joypad.KeyUp, "left": joypad.KeyLeft, "right": joypad.KeyRight,
+}
+
+func (e *Engine) setKeys(L *lua.LState) int {
+	keys := L.CheckTable(1)
+	keys.ForEach(func(k, v lua.LValue) {
+		key, ok := keyNames[k.String()]
+		if !ok {
+			L.ArgError(1, "unknown key "+k.String())
+		}
+		e.mask |= 1 << key
+		if lua.LVAsBool(v) {
+			e.pressed |= 1 << key
+		} else {
+			e.pressed &^= 1 << key
+		}
+	})
+	return 0
+}
+
+func (e *Engine) clearKeys(*lua.LState) int {
+	e.mask, e.pressed = 0, 0
+	return 0
+}
+
+func (e *Engine) getKeys(L *lua.LState) int {
+	keys := L.NewTable()
+	for name, key := range keyNames {
+		if e.IsKeyPressed(key) {
+			keys.RawSetString(name, lua.LTrue)
+		}
+	}
+	L.Push(keys)
+	return 1
+}
+
+// Overlay
+
+// optColor returns the n-th argument, a color (white if missing)
+func optColor(L *lua.LState, n int) (c color.RGBA) {
+	switch v := L.Get(n).(type) {
+	case *lua.LNilType:
+		return colorNames["white"]
+	case lua.LNumber:
+		return colorFromNumber(uint32(v))
+	case lua.LString:
+		c, err := parseColor(string(v))
+		if err != nil {
+			L.ArgError(n, err.Error())
+		}
+		return c
+	default:
+		L.ArgError(n, "color expected")
+		return
+	}
+}
+
+func (e *Engine) drawText(L *lua.LState) int {
+	e.draw(Shape{Kind: Text, X1: L.CheckInt(1), Y1: L.CheckInt(2), Text: L.CheckString(3), Color: optColor(L, 4)})
+	return 0
+}
+
+func (e *Engine) drawRect(L *lua.LState, kind ShapeKind) int {
+	x, y := L.CheckInt(1), L.CheckInt(2)
+	e.draw(Shape{Kind: kind, X1: x, Y1: y, X2: x + L.CheckInt(3), Y2: y + L.CheckInt(4), Color: optColor(L, 5)})
+	return 0
+}
+
+func (e *Engine) drawLine(L *lua.LState) int {
+	e.draw(Shape{
+		Kind: Line,
+		X1:   L.CheckInt(1), Y1: L.CheckInt(2),
+		X2: L.CheckInt(3), Y2: L.CheckInt(4),
+		Color: optColor(L, 5),
+	})
+	return 0
+}
+
+func (e *Engine) drawPixel(L *lua.LState) int {
+	e.draw(Shape{Kind: Pixel, X1: L.CheckInt(1), Y1: L.CheckInt(2), Color: optColor(L, 3)})
+	return 0
+}
+
+// States
+
+func (e *Engine) saveState(L *lua.LState) int {
+	ud := L.NewUserData()
+	ud.Value = e.gb.SaveState()
+	L.SetMetatable(ud, L.GetTypeMetatable(stateType))
+	L.Push(ud)
+	return 1
+}
+
+func (e *Engine) loadState(L *lua.LState) int {
+	s, ok := L.CheckUserData(1).Value.(*gameboy.State)
+	if !ok {
+		L.ArgError(1, "state expected")
+	}
+	if err := e.gb.LoadState(s); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+// Emulation
+
+func (e *Engine) frameCount(L *lua.LState) int {
+	L.Push(lua.LNumber(e.gb.PPU.DebugGetFrameCount()))
+	return 1
+}
+
+func (e *Engine) cycles(L *lua.LState) int {
+	L.Push(lua.LNumber(e.gb.CPU.Cycles()))
+	return 1
+}
@@ -0,0 +1,71 @@
+package script
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// ShapeKind is the kind of a shape drawn on the overlay
+type ShapeKind uint8
+
+const (
+	Text       ShapeKind = iota // Text at (X1, Y1)
+	Rect                        // Outline of the rectangle from (X1, Y1) to (X2, Y2) excluded
+	FilledRect                  // Rectangle from (X1, Y1) to (X2, Y2) excluded
+	Line                        // Line from (X1, Y1) to (X2, Y2)
+	Pixel                       // Pixel at (X1, Y1)
+)
+
+// Shape is drawn on the overlay, coordinates are pixels of the Game Boy screen
+type Shape struct {
+	Kind           ShapeKind
+	X1, Y1, X2, Y2 int
+	Color          color.RGBA
+	Text           string
+}
+
+// draw adds a shape to the overlay of the current frame
+func (e *Engine) draw(s Shape) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.drawing = append(e.drawing, s)
+}
+
+var colorNames = map[string]color.RGBA{
+	"white":   {R: 255, G: 255, B: 255, A: 255},
+	"black":   {A: 255},
+	"red":     {R: 255, A: 255},
+	"green":   {G: 255, A: 255},
+	"blue":    {B: 255, A: 255},
+	"yellow":  {R: 255, G: 255, A: 255},
+	"cyan":    {G: 255, B: 255, A: 255},
+	"magenta": {R: 255, B: 255, A: 255},
+}
+
+// parseColor parses a color name, "#RRGGBB" or "#RRGGBBAA"
+func parseColor(s string) (color.RGBA, error) {
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+
+	c := color.RGBA{A: 255}
+	var err error
+	switch len(s) {
+	case 7:
+		_, err = fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 9:
+		_, err = fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	default:
+		err = fmt.Errorf("invalid color %q", s)
+	}
+	if err != nil {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	return c, nil
+}
+
+// colorFromNumber converts 0xRRGGBB to an opaque color
+func colorFromNumber(n uint32) color.RGBA {
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 255}
+}
@@ -0,0 +1,196 @@
+// Package script runs Lua scripts driving the emulation. Scripts register callbacks
+// (on frame end, on executed addresses and on memory accesses), read and write memory
+// and registers, press joypad keys, draw an overlay on the screen and save and load states.
+//
+// Scripts keep running across resets. Callbacks run on the goroutine running the emulation
+// (the overlay can be read from others), they are not called while the debugger replays the
+// history and an error in one stops the script. The Lua API is listed in api.go
+package script
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// callback is a Lua function registered by a script
+type callback struct {
+	id int
+	fn *lua.LFunction
+
+	// Memory callbacks: accesses in [first, last], writes or reads
+	first, last uint16
+	write       bool
+}
+
+// Engine runs a script on a Game Boy
+type Engine struct {
+	gb *gameboy.GameBoy
+	vm *lua.LState
+
+	frame  []*callback
+	exec   map[uint16][]*callback
+	access []*callback
+	nextID int
+
+	// Keys controlled by the script (mask) and their state, the others are read from input
+	input   joypad.InputProvider
+	mask    uint8
+	pressed uint8
+
+	// Shapes drawn during the current frame and during the last completed one
+	mu      sync.Mutex
+	drawing []Shape
+	overlay []Shape
+
+	// First error of a callback, which stopped the script
+	err error
+}
+
+// New returns an engine for the Game Boy, keys not set by the script are read from input
+func New(gb *gameboy.GameBoy, input joypad.InputProvider) *Engine {
+	e := &Engine{
+		gb:    gb,
+		vm:    lua.NewState(),
+		exec:  make(map[uint16][]*callback),
+		input: input,
+	}
+	e.openLibs()
+	return e
+}
+
+// Run executes a script file, which usually registers the callbacks
+func (e *Engine) Run(path string) error {
+	return e.vm.DoFile(path)
+}
+
+// RunString executes a script
+func (e *Engine) RunString(source string) error {
+	return e.vm.DoString(source)
+}
+
+// Err returns the error that stopped the script (nil if it is running)
+func (e *Engine) Err() error {
+	return e.err
+}
+
+// Close removes the Game Boy hooks and releases the Lua state
+func (e *Engine) Close() {
+	e.gb.SetHooks(gameboy.Hooks{})
+	e.vm.Close()
+}
+
+// Overlay returns the shapes drawn during the last frame
+func (e *Engine) Overlay() []Shape {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.overlay
+}
+
+// IsKeyPressed implements joypad.InputProvider with the keys set by the script
+func (e *Engine) IsKeyPressed(key joypad.Key) bool {
+	if e.mask&(1<<key) != 0 {
+		return e.pressed&(1<<key) != 0
+	}
+	return e.input != nil && e.input.IsKeyPressed(key)
+}
+
+// addCallback adds a callback to the list and returns its id
+func (e *Engine) addCallback(cb *callback, list *[]*callback) int {
+	e.nextID++
+	cb.id = e.nextID
+	*list = append(*list, cb)
+	e.updateHooks()
+	return cb.id
+}
+
+// remove removes the callback with the specified id, it returns false if there is none
+func (e *Engine) remove(id int) bool {
+	match := func(cb *callback) bool { return cb.id == id }
+
+	// The lists are copied since they may be iterated by the callback removing itself
+	removed := false
+	for _, list := range []*[]*callback{&e.frame, &e.access} {
+		if i := slices.IndexFunc(*list, match); i >= 0 {
+			*list = slices.Delete(slices.Clone(*list), i, i+1)
+			removed = true
+		}
+	}
+	for pc, list := range e.exec {
+		if i := slices.IndexFunc(list, match); i >= 0 {
+			e.exec[pc] = slices.Delete(slices.Clone(list), i, i+1)
+			if len(e.exec[pc]) == 0 {
+				delete(e.exec, pc)
+			}
+			removed = true
+		}
+	}
+
+	e.updateHooks()
+	return removed
+}
+
+// updateHooks installs the Game Boy hooks needed by the callbacks registered
+// (the frame hook is always needed to show the overlay)
+func (e *Engine) updateHooks() {
+	if e.err != nil {
+		e.gb.SetHooks(gameboy.Hooks{})
+		return
+	}
+
+	hooks := gameboy.Hooks{Frame: e.frameCompleted}
+	if len(e.exec) > 0 {
+		hooks.Instruction = e.instruction
+	}
+	if len(e.access) > 0 {
+		hooks.Access = e.memoryAccessed
+	}
+	e.gb.SetHooks(hooks)
+}
+
+func (e *Engine) frameCompleted() {
+	for _, cb := range e.frame {
+		e.call(cb)
+	}
+
+	e.mu.Lock()
+	e.overlay, e.drawing = e.drawing, nil
+	e.mu.Unlock()
+}
+
+func (e *Engine) instruction(pc uint16) {
+	for _, cb := range e.exec[pc] {
+		e.call(cb, lua.LNumber(pc))
+	}
+}
+
+func (e *Engine) memoryAccessed(addr uint16, value uint8, write bool) {
+	for _, cb := range e.access {
+		if cb.write == write && cb.first <= addr && addr <= cb.last {
+			e.call(cb, lua.LNumber(addr), lua.LNumber(value))
+		}
+	}
+}
+
+// call calls a callback, an error stops the script
+func (e *Engine) call(cb *callback, args ...lua.LValue) {
+	if e.err != nil {
+		return
+	}
+
+	err := e.vm.CallByParam(lua.P{Fn: cb.fn, Protect: true}, args...)
+	if err != nil {
+		e.err = fmt.Errorf("script stopped: %w", err)
+		log.Println(e.err)
+		e.updateHooks()
+
+		e.mu.Lock()
+		e.overlay, e.drawing = nil, nil
+		e.mu.Unlock()
+	}
+}
@@ -0,0 +1,225 @@
+package script
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const frameSteps = 70224 / 4
+
+// newGameBoy returns a Game Boy waiting for VBlank (see testrom.WaitVBlank)
+func newGameBoy() *gameboy.GameBoy {
+	gb := gameboy.New(nil, 44100)
+	gb.Load(cartridge.NewCartridge(testrom.New(testrom.WaitVBlank...), nil))
+	gb.LoadBootROM(nil)
+	gb.APU.Muted = true
+	return gb
+}
+
+func run(gb *gameboy.GameBoy, steps int) {
+	for range steps {
+		gb.Step()
+	}
+}
+
+// global returns a global number of the script
+func global(e *Engine, name string) int {
+	return int(lua.LVAsNumber(e.vm.GetGlobal(name)))
+}
+
+func TestCallbacks(t *testing.T) {
+	gb := newGameBoy()
+	e := New(gb, nil)
+	defer e.Close()
+
+	err := e.RunString(`
+		frames, execs = 0, 0
+		writes = {}
+		event.onframe(function()
+			frames = frames + 1
+			gui.text(1, 2, "frame " .. frames, "#FF000080")
+			gui.fill(0, 0, 10, 5, 0x00FF00)
+		end)
+		event.onexec(0x0158, function(pc) execs = execs + 1 end)
+		event.onwrite(0xFF40, 0xFF4F, function(addr, value)
+			if addr == 0xFF43 then table.insert(writes, value) end
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(gb, 3*frameSteps)
+
+	frames := global(e, "frames")
+	if frames < 2 || frames > 3 {
+		t.Errorf("frames: got %d", frames)
+	}
+
+	// SCX is written once per VBlank
+	var writes []int
+	e.vm.GetGlobal("writes").(*lua.LTable).ForEach(func(_, v lua.LValue) {
+		writes = append(writes, int(lua.LVAsNumber(v)))
+	})
+	if len(writes) < frames || len(writes) > frames+1 || !slices.Equal(writes, []int{1, 2, 3, 4}[:len(writes)]) {
+		t.Errorf("SCX writes: got %v in %d frames", writes, frames)
+	}
+	if execs := global(e, "execs"); execs != len(writes) {
+		t.Errorf("INC A executed %d times, SCX written %d times", execs, len(writes))
+	}
+
+	overlay := e.Overlay()
+	expected := []Shape{
+		{Kind: Text, X1: 1, Y1: 2, Color: color.RGBA{R: 255, A: 128}, Text: "frame " + string(rune('0'+frames))},
+		{Kind: FilledRect, X1: 0, Y1: 0, X2: 10, Y2: 5, Color: color.RGBA{G: 255, A: 255}},
+	}
+	if !slices.Equal(overlay, expected) {
+		t.Errorf("overlay: got %v, expected %v", overlay, expected)
+	}
+}
+
+func TestMemoryAndRegisters(t *testing.T) {
+	gb := newGameBoy()
+	e := New(gb, nil)
+	defer e.Close()
+
+	err := e.RunString(`
+		memory.write16(0xC000, 0x1234)
+		cpu.set("bc", 0xBEEF)
+		cpu.set("af", 0x12FF)
+		lo, word = memory.read(0xC000), memory.read16(0xC000)
+		b, f = cpu.get("b"), cpu.get("f")
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gb.Memory.DebugRead(0xC000) != 0x34 || gb.Memory.DebugRead(0xC001) != 0x12 {
+		t.Error("memory not written")
+	}
+	if gb.CPU.B != 0xBE || gb.CPU.C != 0xEF || gb.CPU.A != 0x12 || gb.CPU.F != 0xF0 {
+		t.Errorf("registers: got BC=%02X%02X AF=%02X%02X", gb.CPU.B, gb.CPU.C, gb.CPU.A, gb.CPU.F)
+	}
+	if global(e, "lo") != 0x34 || global(e, "word") != 0x1234 || global(e, "b") != 0xBE || global(e, "f") != 0xF0 {
+		t.Error("values read by the script differ")
+	}
+
+	if err := e.RunString(`cpu.get("x")`); err == nil {
+		t.Error("unknown register accepted")
+	}
+}
+
+type testInput struct{ keys uint8 }
+
+func (in *testInput) IsKeyPressed(key joypad.Key) bool {
+	return in.keys&(1<<key) != 0
+}
+
+func TestJoypad(t *testing.T) {
+	gb := newGameBoy()
+	input := &testInput{keys: 1 << joypad.KeyA}
+	e := New(gb, input)
+	defer e.Close()
+
+	if err := e.RunString(`joypad.set{start = true, a = false}`); err != nil {
+		t.Fatal(err)
+	}
+	if !e.IsKeyPressed(joypad.KeyStart) || e.IsKeyPressed(joypad.KeyA) {
+		t.Error("keys set by the script not reported")
+	}
+
+	input.keys = 1 << joypad.KeyLeft
+	if err := e.RunString(`keys = joypad.get()`); err != nil {
+		t.Fatal(err)
+	}
+	var pressed []string
+	e.vm.GetGlobal("keys").(*lua.LTable).ForEach(func(k, _ lua.LValue) {
+		pressed = append(pressed, k.String())
+	})
+	slices.Sort(pressed)
+	if !slices.Equal(pressed, []string{"left", "start"}) {
+		t.Errorf("keys pressed: got %v", pressed)
+	}
+
+	if err := e.RunString(`joypad.clear()`); err != nil {
+		t.Fatal(err)
+	}
+	if e.IsKeyPressed(joypad.KeyStart) || !e.IsKeyPressed(joypad.KeyLeft) {
+		t.Error("keys not given back to the player")
+	}
+}
+
+func TestStates(t *testing.T) {
+	gb := newGameBoy()
+	e := New(gb, nil)
+	defer e.Close()
+
+	// Saved from a callback: the state is taken once INC A is executed
+	err := e.RunString(`
+		event.onexec(0x0158, function()
+			if saved == nil then saved = state.save() end
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(gb, 2*frameSteps)
+
+	a := gb.CPU.A
+	err = e.RunString(`
+		before = memory.read(0xC000)
+		memory.write(0xC000, (before + 1) % 256)
+		state.load(saved)
+		after = memory.read(0xC000)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if global(e, "before") != global(e, "after") {
+		t.Error("memory not restored")
+	}
+	if gb.CPU.PC != 0x0159 || gb.CPU.A != 2 || a == 2 {
+		t.Errorf("got PC=%04X A=%d, expected PC=0159 A=2", gb.CPU.PC, gb.CPU.A)
+	}
+
+	// States are lost by resets
+	gb.Reset()
+	if err := e.RunString(`state.load(saved)`); err == nil {
+		t.Error("state loaded after a reset")
+	}
+}
+
+func TestError(t *testing.T) {
+	gb := newGameBoy()
+	e := New(gb, nil)
+	defer e.Close()
+
+	err := e.RunString(`
+		calls = 0
+		event.onframe(function()
+			calls = calls + 1
+			gui.pixel(0, 0)
+			error("boom")
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(gb, 3*frameSteps)
+
+	if e.Err() == nil {
+		t.Fatal("error not reported")
+	}
+	if calls := global(e, "calls"); calls != 1 {
+		t.Errorf("callback called %d times after the error", calls)
+	}
+	if len(e.Overlay()) != 0 {
+		t.Error("overlay of a stopped script")
+	}
+}
@@ -14,9 +14,9 @@ const (
 
 type Port struct {
 	// TCP socket
-	Conn net.Conn
+	Conn net.Conn `snapshot:"-"`
 	// Connection state
-	State LinkState
+	State LinkState `snapshot:"-"`
 
 	SB uint8
 	// Serial control (bit 7: transfer enable, bit 0: clock select)
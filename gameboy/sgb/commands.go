@@ -0,0 +1,265 @@
+package sgb
+
+const (
+	PAL01    = 0x00
+	PAL23    = 0x01
+	PAL03    = 0x02
+	PAL12    = 0x03
+	ATTR_BLK = 0x04
+	ATTR_LIN = 0x05
+	ATTR_DIV = 0x06
+	ATTR_CHR = 0x07
+	PAL_SET  = 0x0A
+	PAL_TRN  = 0x0B
+	MLT_REQ  = 0x11
+	CHR_TRN  = 0x13
+	PCT_TRN  = 0x14
+	ATTR_TRN = 0x15
+	ATTR_SET = 0x16
+	MASK_EN  = 0x17
+)
+
+func (sgb *SGB) execute(cmd []uint8) {
+	switch cmd[0] >> 3 {
+	case PAL01:
+		sgb.setPalettes(0, 1, cmd)
+	case PAL23:
+		sgb.setPalettes(2, 3, cmd)
+	case PAL03:
+		sgb.setPalettes(0, 3, cmd)
+	case PAL12:
+		sgb.setPalettes(1, 2, cmd)
+
+	case ATTR_BLK:
+		sgb.attrBlock(cmd)
+	case ATTR_LIN:
+		sgb.attrLine(cmd)
+	case ATTR_DIV:
+		sgb.attrDivide(cmd)
+	case ATTR_CHR:
+		sgb.attrChar(cmd)
+
+	case PAL_SET:
+		sgb.paletteSet(cmd)
+	case PAL_TRN:
+		sgb.requestTransfer(func(data []uint8) {
+			for i := range sgb.systemPalettes {
+				for c := range 4 {
+					sgb.systemPalettes[i][c] = readColor(data, i*8+c*2)
+				}
+			}
+		})
+
+	case MLT_REQ:
+		switch cmd[1] & 0b11 {
+		case 1:
+			sgb.players = 2
+		case 3:
+			sgb.players = 4
+		default:
+			sgb.players = 1
+		}
+		sgb.currentPlayer = 0
+
+	case CHR_TRN:
+		offset := int(cmd[1]&1) * 0x80
+		sgb.requestTransfer(func(data []uint8) {
+			for i := range 0x80 {
+				copy(sgb.borderTiles[offset+i][:], data[i*32:])
+			}
+		})
+	case PCT_TRN:
+		sgb.requestTransfer(func(data []uint8) {
+			for i := range sgb.borderMap {
+				sgb.borderMap[i] = uint16(data[i*2]) | uint16(data[i*2+1])<<8
+			}
+			for p := range sgb.borderPalettes {
+				for c := range 16 {
+					sgb.borderPalettes[p][c] = readColor(data, 0x800+p*32+c*2)
+				}
+			}
+		})
+
+	case ATTR_TRN:
+		sgb.requestTransfer(func(data []uint8) {
+			for f := range sgb.attributeFiles {
+				for i := range attrWidth * attrHeight {
+					v := data[f*90+i/4]
+					sgb.attributeFiles[f][i/attrWidth][i%attrWidth] = (v >> (6 - 2*(i%4))) & 0b11
+				}
+			}
+		})
+	case ATTR_SET:
+		sgb.attributeSet(cmd[1])
+
+	case MASK_EN:
+		sgb.mask = cmd[1] & 0b11
+
+	default:
+		// Sound, SNES programs and other commands are not supported
+	}
+}
+
+// readColor reads a little-endian RGB555 color
+func readColor(data []uint8, i int) uint16 {
+	return (uint16(data[i]) | uint16(data[i+1])<<8) & 0x7FFF
+}
+
+// setPalettes sets colors of palettes p1 and p2 (PAL01, PAL23, PAL03 and PAL12)
+func (sgb *SGB) setPalettes(p1, p2 int, cmd []uint8) {
+	color0 := readColor(cmd, 1)
+	for p := range sgb.palettes {
+		sgb.palettes[p][0] = color0
+	}
+
+	for c := 1; c < 4; c++ {
+		sgb.palettes[p1][c] = readColor(cmd, 1+c*2)
+		sgb.palettes[p2][c] = readColor(cmd, 7+c*2)
+	}
+}
+
+// attrBlock sets the palette inside, on the border and outside rectangular areas
+func (sgb *SGB) attrBlock(cmd []uint8) {
+	n := min(int(cmd[1]), 18, (len(cmd)-2)/6)
+
+	for i := range n {
+		set := cmd[2+i*6 : 2+(i+1)*6]
+		control := set[0]
+		inside, border, outside := set[1]&0b11, (set[1]>>2)&0b11, (set[1]>>4)&0b11
+		x1, y1, x2, y2 := int(set[2]), int(set[3]), int(set[4]), int(set[5])
+
+		// If only inside or outside is changed, the border also uses that palette
+		switch control & 0b111 {
+		case 0b001:
+			control |= 0b010
+			border = inside
+		case 0b100:
+			control |= 0b010
+			border = outside
+		}
+
+		for y := range attrHeight {
+			for x := range attrWidth {
+				switch {
+				case x > x1 && x < x2 && y > y1 && y < y2:
+					if control&0b001 != 0 {
+						sgb.attributes[y][x] = inside
+					}
+				case x >= x1 && x <= x2 && y >= y1 && y <= y2:
+					if control&0b010 != 0 {
+						sgb.attributes[y][x] = border
+					}
+				default:
+					if control&0b100 != 0 {
+						sgb.attributes[y][x] = outside
+					}
+				}
+			}
+		}
+	}
+}
+
+// attrLine sets the palette of entire rows or columns
+func (sgb *SGB) attrLine(cmd []uint8) {
+	n := min(int(cmd[1]), 110, len(cmd)-2)
+
+	for i := range n {
+		v := cmd[2+i]
+		line := int(v & 0x1F)
+		palette := (v >> 5) & 0b11
+
+		if v&0x80 != 0 { // Horizontal line
+			if line < attrHeight {
+				for x := range attrWidth {
+					sgb.attributes[line][x] = palette
+				}
+			}
+		} else { // Vertical line
+			if line < attrWidth {
+				for y := range attrHeight {
+					sgb.attributes[y][line] = palette
+				}
+			}
+		}
+	}
+}
+
+// attrDivide splits the screen in two halves with a line between them
+func (sgb *SGB) attrDivide(cmd []uint8) {
+	after, before, on := cmd[1]&0b11, (cmd[1]>>2)&0b11, (cmd[1]>>4)&0b11
+	horizontal := cmd[1]&0x40 != 0
+	coord := int(cmd[2])
+
+	for y := range attrHeight {
+		for x := range attrWidth {
+			pos := x
+			if horizontal {
+				pos = y
+			}
+
+			switch {
+			case pos < coord:
+				sgb.attributes[y][x] = before
+			case pos == coord:
+				sgb.attributes[y][x] = on
+			default:
+				sgb.attributes[y][x] = after
+			}
+		}
+	}
+}
+
+// attrChar sets the palette of single blocks, starting from a given position
+func (sgb *SGB) attrChar(cmd []uint8) {
+	x, y := int(cmd[1]), int(cmd[2])
+	n := min(int(cmd[3])|int(cmd[4])<<8, attrWidth*attrHeight)
+	topToBottom := cmd[5]&1 != 0
+
+	for i := range n {
+		if 6+i/4 >= len(cmd) || x >= attrWidth || y >= attrHeight {
+			return
+		}
+		sgb.attributes[y][x] = (cmd[6+i/4] >> (6 - 2*(i%4))) & 0b11
+
+		if topToBottom {
+			y++
+			if y == attrHeight {
+				y = 0
+				x++
+			}
+		} else {
+			x++
+			if x == attrWidth {
+				x = 0
+				y++
+			}
+		}
+	}
+}
+
+// paletteSet copies system palettes (received with PAL_TRN) to palettes 0-3
+func (sgb *SGB) paletteSet(cmd []uint8) {
+	for p := range sgb.palettes {
+		i := (int(cmd[1+p*2]) | int(cmd[2+p*2])<<8) & 0x1FF
+		sgb.palettes[p] = sgb.systemPalettes[i]
+	}
+
+	// Color 0 of palette 0 is shared
+	for p := range sgb.palettes {
+		sgb.palettes[p][0] = sgb.palettes[0][0]
+	}
+
+	if cmd[9]&0x80 != 0 {
+		sgb.attributeSet(cmd[9])
+	}
+}
+
+// attributeSet applies one of the attribute files received with ATTR_TRN
+func (sgb *SGB) attributeSet(v uint8) {
+	if file := int(v & 0x3F); file < len(sgb.attributeFiles) {
+		sgb.attributes = sgb.attributeFiles[file]
+	}
+	if v&0x40 != 0 {
+		sgb.mask = 0
+	}
+}
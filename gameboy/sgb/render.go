@@ -0,0 +1,116 @@
+package sgb
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+)
+
+// FrameCompleted colorizes the frame rendered by the PPU (DMG color ids)
+// and draws it inside the border
+func (sgb *SGB) FrameCompleted(frame *[ppu.FrameHeight][ppu.FrameWidth]uint16) {
+	if sgb.transfer != nil {
+		sgb.transferFrames--
+		if sgb.transferFrames == 0 {
+			sgb.transfer(readTransferData(frame))
+			sgb.transfer = nil
+		}
+	}
+
+	// Keep the last frame on screen
+	if sgb.mask == 1 {
+		return
+	}
+
+	backdrop := sgb.palettes[0][0]
+	for y := range ScreenHeight {
+		for x := range ScreenWidth {
+			sgb.backBuffer[y][x] = backdrop
+		}
+	}
+
+	for y := range ppu.FrameHeight {
+		for x := range ppu.FrameWidth {
+			var c uint16
+			switch sgb.mask {
+			case 2: // Black
+				c = 0
+			case 3: // Color 0
+				c = backdrop
+			default:
+				palette := sgb.attributes[y/8][x/8]
+				c = sgb.palettes[palette][frame[y][x]&0b11]
+			}
+			sgb.backBuffer[GameY+y][GameX+x] = c
+		}
+	}
+
+	sgb.drawBorder()
+	sgb.frontBuffer, sgb.backBuffer = sgb.backBuffer, sgb.frontBuffer
+}
+
+// drawBorder draws the non-transparent pixels of the border over the screen
+func (sgb *SGB) drawBorder() {
+	for ty := range ScreenHeight / 8 {
+		for tx := range ScreenWidth / 8 {
+			entry := sgb.borderMap[ty*32+tx]
+			tile := &sgb.borderTiles[entry&0xFF]
+			palette := int((entry>>10)&0b111) - 4
+			xFlip, yFlip := entry&0x4000 != 0, entry&0x8000 != 0
+
+			// Only palettes 4-7 are available for the border
+			if palette < 0 {
+				continue
+			}
+
+			for row := range 8 {
+				tileRow := row
+				if yFlip {
+					tileRow = 7 - row
+				}
+
+				for col := range 8 {
+					bit := 7 - col
+					if xFlip {
+						bit = col
+					}
+
+					// SNES 4bpp: planes 0-1 in the first 16 bytes, planes 2-3 in the last 16
+					c := (tile[tileRow*2]>>bit)&1 |
+						((tile[tileRow*2+1]>>bit)&1)<<1 |
+						((tile[16+tileRow*2]>>bit)&1)<<2 |
+						((tile[16+tileRow*2+1]>>bit)&1)<<3
+
+					// Color 0 is transparent
+					if c != 0 {
+						sgb.backBuffer[ty*8+row][tx*8+col] = sgb.borderPalettes[palette][c]
+					}
+				}
+			}
+		}
+	}
+}
+
+// requestTransfer schedules a VRAM transfer: data will be read from the screen some frames later
+func (sgb *SGB) requestTransfer(f func(data []uint8)) {
+	sgb.transfer = f
+	sgb.transferFrames = transferDelay
+}
+
+// readTransferData converts the 4 KiB displayed on screen (tiles 0-255, 20 per row) back to tile data
+func readTransferData(frame *[ppu.FrameHeight][ppu.FrameWidth]uint16) []uint8 {
+	data := make([]uint8, 0x1000)
+
+	for tile := range 256 {
+		tx, ty := tile%attrWidth, tile/attrWidth
+		for row := range 8 {
+			var lo, hi uint8
+			for col := range 8 {
+				c := frame[ty*8+row][tx*8+col]
+				lo |= uint8(c&1) << (7 - col)
+				hi |= uint8((c>>1)&1) << (7 - col)
+			}
+			data[tile*16+row*2] = lo
+			data[tile*16+row*2+1] = hi
+		}
+	}
+	return data
+}
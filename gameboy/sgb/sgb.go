@@ -0,0 +1,153 @@
+package sgb
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+)
+
+const (
+	ScreenWidth  = 256
+	ScreenHeight = 224
+
+	// Position of the Game Boy screen inside the border
+	GameX = (ScreenWidth - ppu.FrameWidth) / 2
+	GameY = (ScreenHeight - ppu.FrameHeight) / 2
+
+	packetLength = 16
+	packetBits   = packetLength * 8
+
+	// Attribute blocks are 8x8 pixels
+	attrWidth  = ppu.FrameWidth / 8
+	attrHeight = ppu.FrameHeight / 8
+
+	// Frames to wait before copying the screen contents in a VRAM transfer
+	transferDelay = 2
+)
+
+// Default palette used before the game sends its own
+var defaultPalette = [4]uint16{0x67BF, 0x265B, 0x10B5, 0x2866}
+
+type SGB struct {
+	// If false, the cartridge does not support SGB functions and commands are ignored
+	enabled bool
+
+	// Packet being received
+	packet      [packetLength]uint8
+	bitsRead    int
+	receiving   bool // Reset pulse received
+	readyForBit bool // P14 and P15 went high after the previous pulse
+
+	// Multi-packet command being received
+	command     []uint8
+	packetsLeft int
+
+	// Multiplayer (MLT_REQ)
+	players       uint8
+	currentPlayer uint8
+	p15Low        bool
+
+	// Palettes 0-3 used to color the game screen (color 0 is shared)
+	palettes       [4][4]uint16
+	systemPalettes [512][4]uint16
+
+	// Palette used by each 8x8 block of the game screen
+	attributes     [attrHeight][attrWidth]uint8
+	attributeFiles [45][attrHeight][attrWidth]uint8
+
+	// Border (SNES 4bpp tiles, 32x28 tilemap and palettes 4-7)
+	borderTiles    [256][32]uint8
+	borderMap      [32 * 32]uint16
+	borderPalettes [4][16]uint16
+
+	// MASK_EN (0: cancel, 1: freeze, 2: black, 3: color 0)
+	mask uint8
+
+	// VRAM transfer waiting for the screen contents
+	transfer       func(data []uint8)
+	transferFrames int
+
+	frontBuffer *[ScreenHeight][ScreenWidth]uint16
+	backBuffer  *[ScreenHeight][ScreenWidth]uint16
+}
+
+func New(enabled bool) *SGB {
+	sgb := &SGB{
+		enabled:     enabled,
+		players:     1,
+		frontBuffer: new([ScreenHeight][ScreenWidth]uint16),
+		backBuffer:  new([ScreenHeight][ScreenWidth]uint16),
+	}
+	for i := range sgb.palettes {
+		sgb.palettes[i] = defaultPalette
+	}
+	return sgb
+}
+
+// Write decodes the packets sent through the P14 and P15 lines of the joypad register
+func (sgb *SGB) Write(v uint8) {
+	switch v & 0x30 {
+	case 0x00: // Reset pulse
+		sgb.receiving = true
+		sgb.readyForBit = false
+		sgb.bitsRead = 0
+		sgb.packet = [packetLength]uint8{}
+
+	case 0x10, 0x20: // P15 low: bit 1, P14 low: bit 0
+		var bit uint8 = 0
+		if v&0x30 == 0x10 {
+			bit = 1
+			sgb.p15Low = true
+		}
+
+		if !sgb.receiving || !sgb.readyForBit {
+			return
+		}
+		sgb.readyForBit = false
+
+		if sgb.bitsRead == packetBits {
+			// Stop bit must be 0
+			sgb.receiving = false
+			if bit == 0 {
+				sgb.receivePacket()
+			}
+			return
+		}
+
+		// Bits are sent LSB first
+		sgb.packet[sgb.bitsRead/8] |= bit << (sgb.bitsRead % 8)
+		sgb.bitsRead++
+
+	case 0x30:
+		sgb.readyForBit = true
+
+		// In multiplayer mode, the next joypad is selected when P15 goes high
+		if sgb.p15Low && sgb.players > 1 {
+			sgb.currentPlayer = (sgb.currentPlayer + 1) % sgb.players
+		}
+		sgb.p15Low = false
+	}
+}
+
+// JoypadID returns the joypad currently selected (0-3)
+func (sgb *SGB) JoypadID() uint8 {
+	return sgb.currentPlayer
+}
+
+func (sgb *SGB) receivePacket() {
+	// First packet of a command: number of packets is in the lower 3 bits of the header
+	if sgb.packetsLeft == 0 {
+		sgb.packetsLeft = max(int(sgb.packet[0]&0b111), 1)
+		sgb.command = sgb.command[:0]
+	}
+
+	sgb.command = append(sgb.command, sgb.packet[:]...)
+	sgb.packetsLeft--
+
+	if sgb.packetsLeft == 0 && sgb.enabled {
+		sgb.execute(sgb.command)
+	}
+}
+
+// GetFrame returns the last colorized frame with the border
+func (sgb *SGB) GetFrame() *[ScreenHeight][ScreenWidth]uint16 {
+	return sgb.frontBuffer
+}
@@ -0,0 +1,134 @@
+package sgb
+
+import (
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+)
+
+// sendPacket writes a packet to P1 like a game would do
+func sendPacket(sgb *SGB, packet [packetLength]uint8) {
+	sgb.Write(0x00)
+	sgb.Write(0x30)
+	for _, b := range packet {
+		for i := range 8 {
+			if (b>>i)&1 == 1 {
+				sgb.Write(0x10)
+			} else {
+				sgb.Write(0x20)
+			}
+			sgb.Write(0x30)
+		}
+	}
+	// Stop bit
+	sgb.Write(0x20)
+	sgb.Write(0x30)
+}
+
+func TestSGB_PAL01(t *testing.T) {
+	sgb := New(true)
+	sendPacket(sgb, [packetLength]uint8{
+		PAL01<<3 | 1,
+		0x11, 0x00, // Color 0
+		0x01, 0x00, 0x02, 0x00, 0x03, 0x00, // Palette 0
+		0x04, 0x00, 0x05, 0x00, 0x06, 0x00, // Palette 1
+	})
+
+	expected := [4][4]uint16{
+		{0x11, 1, 2, 3},
+		{0x11, 4, 5, 6},
+		{0x11, defaultPalette[1], defaultPalette[2], defaultPalette[3]},
+		{0x11, defaultPalette[1], defaultPalette[2], defaultPalette[3]},
+	}
+	if sgb.palettes != expected {
+		t.Errorf("got %X, expected %X", sgb.palettes, expected)
+	}
+}
+
+func TestSGB_Disabled(t *testing.T) {
+	sgb := New(false)
+	sendPacket(sgb, [packetLength]uint8{PAL01<<3 | 1, 0x11})
+
+	if sgb.palettes[0][0] != defaultPalette[0] {
+		t.Errorf("commands should be ignored when SGB functions are not supported")
+	}
+}
+
+func TestSGB_AttrDiv(t *testing.T) {
+	sgb := New(true)
+	// Vertical division at X=5: left palette 1, line palette 2, right palette 3
+	sendPacket(sgb, [packetLength]uint8{ATTR_DIV<<3 | 1, 0b10_01_11, 5})
+
+	for x, expected := range map[int]uint8{0: 1, 4: 1, 5: 2, 6: 3, 19: 3} {
+		if got := sgb.attributes[10][x]; got != expected {
+			t.Errorf("x=%d: got %d, expected %d", x, got, expected)
+		}
+	}
+}
+
+func TestSGB_AttrBlkShortPacket(t *testing.T) {
+	sgb := New(true)
+	// 18 data sets announced, only the 2 that fit in one packet are sent
+	sendPacket(sgb, [packetLength]uint8{
+		ATTR_BLK<<3 | 1, 18,
+		0b001, 0b01, 0, 0, 19, 17, // Inside of the whole screen: palette 1
+		0b001, 0b10, 2, 2, 4, 4, // Inside of (3, 3): palette 2
+	})
+
+	if got := sgb.attributes[10][10]; got != 1 {
+		t.Errorf("first set: got palette %d, expected 1", got)
+	}
+	if got := sgb.attributes[3][3]; got != 2 {
+		t.Errorf("second set: got palette %d, expected 2", got)
+	}
+}
+
+func TestSGB_AttrLinShortPacket(t *testing.T) {
+	sgb := New(true)
+	// 110 lines announced, only 14 are sent: the last one is row 5 with palette 3
+	packet := [packetLength]uint8{ATTR_LIN<<3 | 1, 110}
+	for i := 2; i < packetLength; i++ {
+		packet[i] = 0x80 | 3<<5 | 5
+	}
+	sendPacket(sgb, packet)
+
+	if got := sgb.attributes[5][0]; got != 3 {
+		t.Errorf("got palette %d, expected 3", got)
+	}
+}
+
+func TestSGB_Multiplayer(t *testing.T) {
+	sgb := New(true)
+	sendPacket(sgb, [packetLength]uint8{MLT_REQ<<3 | 1, 1})
+
+	if sgb.JoypadID() != 0 {
+		t.Fatalf("joypad 1 should be selected after MLT_REQ")
+	}
+
+	sgb.Write(0x10)
+	sgb.Write(0x30)
+	if sgb.JoypadID() != 1 {
+		t.Errorf("got joypad %d, expected 1", sgb.JoypadID())
+	}
+
+	sgb.Write(0x10)
+	sgb.Write(0x30)
+	if sgb.JoypadID() != 0 {
+		t.Errorf("got joypad %d, expected 0", sgb.JoypadID())
+	}
+}
+
+func TestSGB_Render(t *testing.T) {
+	sgb := New(true)
+	frame := new([ppu.FrameHeight][ppu.FrameWidth]uint16)
+	frame[0][0] = 3
+
+	sgb.FrameCompleted(frame)
+	out := sgb.GetFrame()
+	if out[GameY][GameX] != defaultPalette[3] {
+		t.Errorf("got %04X, expected %04X", out[GameY][GameX], defaultPalette[3])
+	}
+	if out[0][0] != defaultPalette[0] {
+		t.Errorf("border: got %04X, expected %04X", out[0][0], defaultPalette[0])
+	}
+}
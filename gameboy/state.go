@@ -0,0 +1,68 @@
+package gameboy
+
+import (
+	"errors"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cpu"
+	"github.com/danielecanzoneri/lucky-boy/util/snapshot"
+)
+
+// ErrStaleState is returned when loading a state saved before a reset or another game was loaded
+var ErrStaleState = errors.New("state saved before the last reset")
+
+// State is an in-memory copy of the emulation state (see SaveState)
+type State struct {
+	cpu   *cpu.CPU // Components the state was taken from
+	state *snapshot.Snapshot
+}
+
+// SaveState copies the emulation state. When called by a hook while an instruction
+// is executing, the state is copied as soon as the instruction completes
+func (gb *GameBoy) SaveState() *State {
+	s := &State{cpu: gb.CPU}
+	if gb.stepping {
+		gb.pendingSaves = append(gb.pendingSaves, s)
+	} else {
+		s.state = snapshot.Take(&machine{CPU: gb.CPU, SGB: gb.SGB})
+	}
+	return s
+}
+
+// LoadState restores a state saved since the last reset and clears the history.
+// When called by a hook while an instruction is executing, the state is restored
+// as soon as the instruction completes
+func (gb *GameBoy) LoadState(s *State) error {
+	if s.cpu != gb.CPU {
+		return ErrStaleState
+	}
+
+	if gb.stepping {
+		gb.pendingLoad = s
+	} else {
+		gb.restoreState(s)
+	}
+	return nil
+}
+
+func (gb *GameBoy) restoreState(s *State) {
+	s.state.Restore()
+
+	// The instructions recorded do not lead here anymore
+	if gb.History != nil {
+		gb.History = newHistory(gb)
+		gb.Joypad.SetInputProvider(gb.History)
+	}
+}
+
+// completeStep saves and loads the states requested while executing the last instruction
+func (gb *GameBoy) completeStep() {
+	for _, s := range gb.pendingSaves {
+		s.state = snapshot.Take(&machine{CPU: gb.CPU, SGB: gb.SGB})
+	}
+	gb.pendingSaves = gb.pendingSaves[:0]
+
+	if s := gb.pendingLoad; s != nil {
+		gb.pendingLoad = nil
+		gb.restoreState(s)
+	}
+}
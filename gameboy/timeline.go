@@ -0,0 +1,32 @@
+package gameboy
+
+import "github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+
+// SetTimeline records the hardware events in the recorder (nil to stop recording)
+func (gb *GameBoy) SetTimeline(r *timeline.Recorder) {
+	gb.Timeline = r
+	if gb.CPU != nil {
+		gb.installTimelineHooks()
+	}
+}
+
+func (gb *GameBoy) installTimelineHooks() {
+	var hook timeline.Hook
+	if gb.Timeline != nil {
+		hook = gb.recordEvent
+	}
+	gb.CPU.SetEventHook(hook)
+	gb.Memory.SetEventHook(hook)
+	gb.PPU.SetEventHook(hook)
+}
+
+// recordEvent records an event at the current position of the PPU
+func (gb *GameBoy) recordEvent(kind timeline.Kind, addr uint16, value uint8) {
+	recorder := gb.Timeline
+	if recorder == nil {
+		return
+	}
+
+	ly, dot := gb.PPU.DebugGetPosition()
+	recorder.Record(timeline.Event{Kind: kind, LY: ly, Dot: dot, Addr: addr, Value: value})
+}
@@ -0,0 +1,218 @@
+// Package timeline records the hardware events of the last frames
+// (PPU modes, interrupts, DMA transfers, register writes and HALT periods)
+// with the scanline and the dot they happened at.
+package timeline
+
+import "sync"
+
+const (
+	// LineLength is the number of dots of a scanline
+	LineLength = 456
+
+	// maxFrameEvents limits the events of a frame (with the LCD off LY never goes back)
+	maxFrameEvents = 1 << 16
+)
+
+// Kind of event
+type Kind uint8
+
+const (
+	Mode     Kind = iota // PPU mode switched (Value: new mode)
+	Request              // Interrupt requested (Value: interrupt mask)
+	Service              // Interrupt serviced (Value: interrupt mask)
+	Halt                 // CPU halted
+	Resume               // CPU resumed after HALT
+	DMAStart             // OAM DMA started (Value: source high byte)
+	DMAEnd               // OAM DMA completed
+	HDMA                 // 16 bytes transferred by HDMA (Addr: destination, Value: blocks left)
+	Write                // Register written (Addr and Value)
+)
+
+func (k Kind) String() string {
+	return [...]string{
+		Mode: "Mode", Request: "Request", Service: "Service", Halt: "HALT", Resume: "Resume",
+		DMAStart: "OAM DMA", DMAEnd: "OAM DMA end", HDMA: "HDMA", Write: "Write",
+	}[k]
+}
+
+// Hook is the function called by the components on each event
+type Hook func(kind Kind, addr uint16, value uint8)
+
+// Event happened at a dot of a scanline
+type Event struct {
+	Kind  Kind
+	LY    uint8
+	Dot   int
+	Addr  uint16
+	Value uint8
+}
+
+// Position returns the dots elapsed from the start of the frame
+func (e Event) Position() int {
+	return int(e.LY)*LineLength + min(max(e.Dot, 0), LineLength-1)
+}
+
+// Frame is the list of events from line 0 to the next line 0
+type Frame struct {
+	Number   uint64 // Frames recorded before this one
+	Complete bool   // False for the frame being recorded
+
+	// State when the frame started
+	Mode   uint8
+	Halted bool
+	DMA    bool
+
+	Events []Event
+}
+
+// Span is a period of a frame from Start to End (positions as returned by Event.Position)
+type Span struct {
+	Start, End int
+	Value      uint8
+}
+
+// Modes returns the periods spent by the PPU in each mode (Span.Value)
+func (f *Frame) Modes() []Span {
+	return f.spans(f.Mode, func(e Event, _ uint8) (uint8, bool) {
+		return e.Value, e.Kind == Mode
+	})
+}
+
+// Halts returns the periods in which the CPU was halted
+func (f *Frame) Halts() []Span {
+	return f.activeSpans(f.Halted, Halt, Resume)
+}
+
+// DMAs returns the periods of the OAM DMA transfers
+func (f *Frame) DMAs() []Span {
+	return f.activeSpans(f.DMA, DMAStart, DMAEnd)
+}
+
+// activeSpans returns the periods between a start event and an end event
+func (f *Frame) activeSpans(active bool, start, end Kind) []Span {
+	state := uint8(0)
+	if active {
+		state = 1
+	}
+	spans := f.spans(state, func(e Event, state uint8) (uint8, bool) {
+		switch e.Kind {
+		case start:
+			return 1, true
+		case end:
+			return 0, true
+		}
+		return state, false
+	})
+
+	periods := spans[:0]
+	for _, s := range spans {
+		if s.Value == 1 {
+			periods = append(periods, s)
+		}
+	}
+	return periods
+}
+
+// spans splits the frame in the periods with the same state, changed by the events
+func (f *Frame) spans(state uint8, update func(e Event, state uint8) (uint8, bool)) []Span {
+	var spans []Span
+	start := 0
+	for _, e := range f.Events {
+		next, ok := update(e, state)
+		if !ok || next == state {
+			continue
+		}
+		if pos := e.Position(); pos > start {
+			spans = append(spans, Span{Start: start, End: pos, Value: state})
+			start = pos
+		}
+		state = next
+	}
+
+	end := 154 * LineLength
+	if n := len(f.Events); !f.Complete && n > 0 {
+		// Recorded until the last event
+		end = f.Events[n-1].Position()
+	}
+	if end > start {
+		spans = append(spans, Span{Start: start, End: end, Value: state})
+	}
+	return spans
+}
+
+// Recorder keeps the events of the last frames. Events are recorded while
+// the emulation runs and can be read from other goroutines
+type Recorder struct {
+	mu sync.Mutex
+
+	frames  []Frame // Completed, oldest first
+	current Frame
+	size    int
+}
+
+// NewRecorder returns a recorder keeping the specified number of completed frames
+func NewRecorder(frames int) *Recorder {
+	return &Recorder{size: frames}
+}
+
+// Record adds an event to the current frame, a new frame starts when LY goes back
+// (line 0 after VBlank, the LCD switched off or the emulation restored to a previous state)
+func (r *Recorder) Record(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.current.Events); n > 0 && e.LY < r.current.Events[n-1].LY {
+		r.endFrame()
+	}
+	if len(r.current.Events) < maxFrameEvents {
+		r.current.Events = append(r.current.Events, e)
+	}
+}
+
+func (r *Recorder) endFrame() {
+	next := Frame{
+		Number: r.current.Number + 1,
+		Mode:   r.current.Mode,
+		Halted: r.current.Halted,
+		DMA:    r.current.DMA,
+	}
+	for _, e := range r.current.Events {
+		switch e.Kind {
+		case Mode:
+			next.Mode = e.Value
+		case Halt, Resume:
+			next.Halted = e.Kind == Halt
+		case DMAStart, DMAEnd:
+			next.DMA = e.Kind == DMAStart
+		}
+	}
+
+	if len(r.frames) == r.size {
+		r.frames = append(r.frames[:0], r.frames[1:]...)
+	}
+	r.current.Complete = true
+	r.frames = append(r.frames, r.current)
+	r.current = next
+}
+
+// Frames returns the completed frames and the current one, oldest first
+func (r *Recorder) Frames() []Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	frames := make([]Frame, 0, len(r.frames)+1)
+	frames = append(frames, r.frames...)
+
+	current := r.current
+	current.Events = append([]Event(nil), r.current.Events...)
+	return append(frames, current)
+}
+
+// Clear discards the frames recorded
+func (r *Recorder) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.frames = nil
+	r.current = Frame{}
+}
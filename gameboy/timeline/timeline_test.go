@@ -0,0 +1,88 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(2)
+
+	// Three frames: the first one is discarded
+	for frame := range 3 {
+		r.Record(Event{Kind: Mode, LY: 0, Dot: 0, Value: 2})
+		r.Record(Event{Kind: Write, LY: 10, Dot: 100, Addr: 0xFF43, Value: uint8(frame)})
+		r.Record(Event{Kind: Mode, LY: 144, Dot: 4, Value: 1})
+	}
+	r.Record(Event{Kind: Halt, LY: 0, Dot: 200})
+	r.Record(Event{Kind: Mode, LY: 1, Dot: 0, Value: 2})
+
+	frames := r.Frames()
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, expected 3", len(frames))
+	}
+	for i, f := range frames {
+		if f.Number != uint64(i+1) {
+			t.Errorf("frame %d: number %d", i, f.Number)
+		}
+		if f.Complete != (i < 2) {
+			t.Errorf("frame %d: complete %v", i, f.Complete)
+		}
+	}
+	if v := frames[0].Events[1].Value; v != 1 {
+		t.Errorf("oldest frame kept: SCX %d, expected 1", v)
+	}
+
+	// State carried to the next frame
+	current := frames[2]
+	if current.Mode != 1 || current.Halted {
+		t.Errorf("current frame state: mode %d, halted %v", current.Mode, current.Halted)
+	}
+	if got := current.Halts(); !reflect.DeepEqual(got, []Span{{Start: 200, End: LineLength, Value: 1}}) {
+		t.Errorf("halts: got %v", got)
+	}
+
+	r.Clear()
+	if frames := r.Frames(); len(frames) != 1 || len(frames[0].Events) != 0 {
+		t.Errorf("frames after clear: %v", frames)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	f := Frame{
+		Complete: true,
+		Mode:     1,
+		DMA:      true,
+		Events: []Event{
+			{Kind: DMAEnd, LY: 0, Dot: 40},
+			{Kind: Mode, LY: 0, Dot: 80, Value: 2},
+			{Kind: Mode, LY: 0, Dot: 84, Value: 3},
+			{Kind: Request, LY: 0, Dot: 100, Value: 2},
+			{Kind: Mode, LY: 0, Dot: 256, Value: 0},
+			{Kind: DMAStart, LY: 1, Dot: 0},
+			{Kind: DMAEnd, LY: 1, Dot: 640}, // Clamped to the end of the line
+		},
+	}
+
+	modes := []Span{
+		{Start: 0, End: 80, Value: 1},
+		{Start: 80, End: 84, Value: 2},
+		{Start: 84, End: 256, Value: 3},
+		{Start: 256, End: 154 * LineLength, Value: 0},
+	}
+	if got := f.Modes(); !reflect.DeepEqual(got, modes) {
+		t.Errorf("modes: got %v, expected %v", got, modes)
+	}
+
+	dmas := []Span{
+		{Start: 0, End: 40, Value: 1},
+		{Start: LineLength, End: 2*LineLength - 1, Value: 1},
+	}
+	if got := f.DMAs(); !reflect.DeepEqual(got, dmas) {
+		t.Errorf("DMAs: got %v, expected %v", got, dmas)
+	}
+
+	if got := f.Halts(); len(got) != 0 {
+		t.Errorf("halts: got %v, expected none", got)
+	}
+}
@@ -0,0 +1,57 @@
+package gameboy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/internal/testrom"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+)
+
+func TestTimeline(t *testing.T) {
+	gb := newTestGameBoy(testrom.WaitVBlank...)
+	recorder := timeline.NewRecorder(4)
+	gb.SetTimeline(recorder)
+	for range 3 * 70224 / 4 {
+		gb.Step()
+	}
+
+	frames := recorder.Frames()
+	if len(frames) < 3 {
+		t.Fatalf("got %d frames, expected at least 3", len(frames))
+	}
+	f := frames[len(frames)-2]
+	if !f.Complete {
+		t.Fatal("frame not complete")
+	}
+
+	// Line 0: OAM scan then drawing, VBlank from line 144
+	modes := f.Modes()
+	if modes[1] != (timeline.Span{Start: 4, End: 84, Value: 2}) || modes[2].Value != 3 {
+		t.Errorf("line 0 modes: got %v", modes[:3])
+	}
+	vblank := modes[len(modes)-1]
+	if vblank != (timeline.Span{Start: 144*timeline.LineLength + 4, End: 154 * timeline.LineLength, Value: 1}) {
+		t.Errorf("VBlank: got %v", vblank)
+	}
+
+	// Halted until the VBlank interrupt, then SCX written and halted again
+	var kinds []timeline.Kind
+	for _, e := range f.Events {
+		if e.Kind != timeline.Mode {
+			if e.LY != 144 {
+				t.Errorf("event %v not at line 144", e)
+			}
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	expected := []timeline.Kind{timeline.Request, timeline.Resume, timeline.Service, timeline.Write, timeline.Halt}
+	if !slices.Equal(kinds, expected) {
+		t.Errorf("events: got %v, expected %v", kinds, expected)
+	}
+
+	halts := f.Halts()
+	if len(halts) != 2 || halts[0].Start != 0 || halts[0].End != vblank.Start || halts[1].End != vblank.End {
+		t.Errorf("halts: got %v", halts)
+	}
+}
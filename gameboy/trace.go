@@ -0,0 +1,54 @@
+package gameboy
+
+import "github.com/danielecanzoneri/lucky-boy/gameboy/trace"
+
+// SetTracer writes every executed instruction to the tracer (nil to stop tracing)
+func (gb *GameBoy) SetTracer(t *trace.Tracer) {
+	gb.Tracer = t
+	if gb.CPU != nil {
+		gb.installTraceHook()
+	}
+}
+
+func (gb *GameBoy) installTraceHook() {
+	if gb.Tracer == nil && gb.hooks.Instruction == nil {
+		gb.CPU.SetTraceHook(nil)
+		return
+	}
+	gb.CPU.SetTraceHook(gb.beforeInstruction)
+}
+
+// beforeInstruction traces the instruction and calls the script hook
+func (gb *GameBoy) beforeInstruction() {
+	gb.traceInstruction()
+	if hook := gb.hooks.Instruction; hook != nil {
+		hook(gb.CPU.PC)
+	}
+}
+
+func (gb *GameBoy) traceInstruction() {
+	tracer := gb.Tracer
+	if tracer == nil {
+		return
+	}
+
+	pc := gb.CPU.PC
+	bank := gb.Memory.DebugBank(pc)
+	if !tracer.Wants(pc, bank) {
+		return
+	}
+
+	c := gb.CPU
+	s := trace.State{
+		A: c.A, F: c.F, B: c.B, C: c.C, D: c.D, E: c.E, H: c.H, L: c.L,
+		SP:     c.SP,
+		PC:     pc,
+		Cycles: c.Cycles(),
+		LY:     gb.PPU.LY,
+		Bank:   bank,
+	}
+	for i := range s.PCMem {
+		s.PCMem[i] = gb.Memory.DebugRead(pc + uint16(i))
+	}
+	tracer.Trace(&s)
+}
@@ -0,0 +1,238 @@
+// Package trace writes executed instructions in the Gameboy Doctor log format
+// (https://github.com/robert/gameboy-doctor), optionally with extra information.
+package trace
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Options select the extra information appended to each line
+type Options struct {
+	Cycles bool // CY: T-cycles elapsed since reset (decimal)
+	LY     bool // LY: current scanline
+	Bank   bool // BANK: ROM bank mapped at PC
+}
+
+// ParseOptions parses a comma separated list of extra information ("cycles,ly,bank")
+func ParseOptions(spec string) (Options, error) {
+	var opts Options
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "cycles":
+			opts.Cycles = true
+		case "ly":
+			opts.LY = true
+		case "bank":
+			opts.Bank = true
+		case "":
+		default:
+			return opts, fmt.Errorf("invalid trace option %q", name)
+		}
+	}
+	return opts, nil
+}
+
+// State of the CPU before executing an instruction
+type State struct {
+	A, F, B, C, D, E, H, L uint8
+	SP, PC                 uint16
+
+	// Bytes at PC, PC+1, PC+2 and PC+3
+	PCMem [4]uint8
+
+	Cycles uint64
+	LY     uint8
+	Bank   uint
+}
+
+// Filter matches the instructions in the address range [Start, End],
+// only in the specified ROM bank if Bank is not negative
+type Filter struct {
+	Bank       int
+	Start, End uint16
+}
+
+// ParseFilter parses a filter in the form "[BB:]AAAA[-AAAA]" or "BB:" (the whole bank),
+// numbers are hexadecimal
+func ParseFilter(spec string) (Filter, error) {
+	f := Filter{Bank: -1, Start: 0x0000, End: 0xFFFF}
+
+	spec = strings.TrimSpace(spec)
+	if bankStr, rest, ok := strings.Cut(spec, ":"); ok {
+		bank, err := strconv.ParseUint(bankStr, 16, 16)
+		if err != nil {
+			return f, fmt.Errorf("invalid bank %q", bankStr)
+		}
+		f.Bank = int(bank)
+		spec = rest
+		if spec == "" {
+			return f, nil
+		}
+	}
+
+	startStr, endStr, isRange := strings.Cut(spec, "-")
+	start, err := strconv.ParseUint(startStr, 16, 16)
+	if err != nil {
+		return f, fmt.Errorf("invalid address %q", startStr)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.ParseUint(endStr, 16, 16); err != nil {
+			return f, fmt.Errorf("invalid address %q", endStr)
+		}
+	}
+	if end < start {
+		return f, errors.New("invalid address range")
+	}
+	f.Start, f.End = uint16(start), uint16(end)
+	return f, nil
+}
+
+// ParseFilters parses a comma separated list of filters (see ParseFilter)
+func ParseFilters(spec string) ([]Filter, error) {
+	var filters []Filter
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		f, err := ParseFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func (f Filter) matches(pc uint16, bank uint) bool {
+	return f.Start <= pc && pc <= f.End && (f.Bank < 0 || uint(f.Bank) == bank)
+}
+
+type Tracer struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	buf []byte
+
+	opts Options
+
+	// Only instructions matching at least one filter are traced (all if empty)
+	filters []Filter
+
+	// Tracing can be started and stopped (e.g. by breakpoints)
+	active bool
+}
+
+func New(w io.Writer, opts Options, filters []Filter) *Tracer {
+	return &Tracer{
+		w:       bufio.NewWriterSize(w, 1<<16),
+		opts:    opts,
+		filters: filters,
+		active:  true,
+	}
+}
+
+// SetActive starts or stops tracing
+func (t *Tracer) SetActive(active bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active = active
+}
+
+func (t *Tracer) Active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.active
+}
+
+// Wants returns true if the instruction at pc in the bank should be traced
+func (t *Tracer) Wants(pc uint16, bank uint) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active {
+		return false
+	}
+	if len(t.filters) == 0 {
+		return true
+	}
+	for _, f := range t.filters {
+		if f.matches(pc, bank) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trace writes a line with the state (filters are checked by Wants)
+func (t *Tracer) Trace(s *State) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = AppendLine(t.buf[:0], s, t.opts)
+	t.w.Write(t.buf)
+}
+
+// Flush writes buffered lines to the underlying writer
+func (t *Tracer) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.w.Flush()
+}
+
+// AppendLine appends the trace line of the state to b, e.g.
+// "A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 PCMEM:00,C3,13,02"
+func AppendLine(b []byte, s *State, opts Options) []byte {
+	registers := [...]struct {
+		name  string
+		value uint8
+	}{{"A:", s.A}, {" F:", s.F}, {" B:", s.B}, {" C:", s.C}, {" D:", s.D}, {" E:", s.E}, {" H:", s.H}, {" L:", s.L}}
+	for _, r := range registers {
+		b = append(b, r.name...)
+		b = appendHex(b, uint64(r.value), 2)
+	}
+
+	b = append(b, " SP:"...)
+	b = appendHex(b, uint64(s.SP), 4)
+	b = append(b, " PC:"...)
+	b = appendHex(b, uint64(s.PC), 4)
+	b = append(b, " PCMEM:"...)
+	for i, v := range s.PCMem {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendHex(b, uint64(v), 2)
+	}
+
+	if opts.Cycles {
+		b = append(b, " CY:"...)
+		b = strconv.AppendUint(b, s.Cycles, 10)
+	}
+	if opts.LY {
+		b = append(b, " LY:"...)
+		b = appendHex(b, uint64(s.LY), 2)
+	}
+	if opts.Bank {
+		b = append(b, " BANK:"...)
+		b = appendHex(b, uint64(s.Bank), 2)
+	}
+	return append(b, '\n')
+}
+
+// appendHex appends v in uppercase hexadecimal with at least the specified digits
+func appendHex(b []byte, v uint64, digits int) []byte {
+	const hexDigits = "0123456789ABCDEF"
+
+	for digits < 16 && v>>(4*digits) != 0 {
+		digits++
+	}
+	for shift := 4 * (digits - 1); shift >= 0; shift -= 4 {
+		b = append(b, hexDigits[(v>>shift)&0xF])
+	}
+	return b
+}
@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"bytes"
+	"testing"
+)
+
+// First line of the Gameboy Doctor logs (state after the DMG boot ROM)
+var bootState = State{
+	A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D,
+	SP: 0xFFFE, PC: 0x0100,
+	PCMem:  [4]uint8{0x00, 0xC3, 0x13, 0x02},
+	Cycles: 1234,
+	LY:     0x90,
+	Bank:   0x1A3,
+}
+
+func TestAppendLine(t *testing.T) {
+	const want = "A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 PCMEM:00,C3,13,02"
+
+	if got := string(AppendLine(nil, &bootState, Options{})); got != want+"\n" {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	opts := Options{Cycles: true, LY: true, Bank: true}
+	if got := string(AppendLine(nil, &bootState, opts)); got != want+" CY:1234 LY:90 BANK:1A3\n" {
+		t.Errorf("with options: got %q", got)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	filters, err := ParseFilters("C000-CFFF, 02:, 01:4000-40FF")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	tracer := New(&out, Options{}, filters)
+
+	tests := []struct {
+		pc   uint16
+		bank uint
+		want bool
+	}{
+		{0xC123, 0, true},
+		{0xD000, 1, false},
+		{0x5000, 2, true},
+		{0x4080, 1, true},
+		{0x4080, 3, false},
+	}
+	for _, tt := range tests {
+		if got := tracer.Wants(tt.pc, tt.bank); got != tt.want {
+			t.Errorf("%02X:%04X: got %v, want %v", tt.bank, tt.pc, got, tt.want)
+		}
+	}
+
+	tracer.SetActive(false)
+	if tracer.Wants(0xC123, 0) {
+		t.Error("inactive tracer wants instructions")
+	}
+
+	if _, err := ParseFilter("4000-3000"); err == nil {
+		t.Error("invalid range accepted")
+	}
+}
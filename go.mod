@@ -5,8 +5,10 @@ go 1.24.2
 require (
 	github.com/ebitengine/oto/v3 v3.4.0
 	github.com/ebitenui/ebitenui v0.7.2
+	github.com/gorilla/websocket v1.5.3
 	github.com/hajimehoshi/ebiten/v2 v2.9.7
 	github.com/sqweek/dialog v0.0.0-20240226140203-065105509627
+	github.com/yuin/gopher-lua v1.1.1
 	golang.org/x/image v0.34.0
 )
 
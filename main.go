@@ -7,16 +7,28 @@ import (
 )
 
 const (
-	socketPort = "4321"
+	socketPort         = "4321"
+	infraredSocketPort = "4322"
 )
 
 var (
 	startWithDebugger = flag.Bool("debug", false, "Start emulator with debugger enabled")
 	bootRom           = flag.String("boot-rom", "", "Boot ROM filename")
 	romPath           = flag.String("rom", "", "ROM filename")
+	patchPath         = flag.String("patch", "", "IPS, UPS or BPS patch applied to the ROM")
 	serial            = flag.String("serial", "", "Serial role (master or slave)")
+	infrared          = flag.String("infrared", "", "Infrared port (loopback, listen or connect)")
 	shader            = flag.Bool("shader", true, "Use GBC color correction shader")
-	systemModel       = flag.String("model", "auto", "GameBoy model (auto, dmg, cgb)")
+	systemModel       = flag.String("model", "auto", "GameBoy model (auto, dmg, cgb, sgb)")
+	codeDataLog       = flag.Bool("cdl", false, "Log code/data accesses to a .cdl file next to the ROM")
+	tracePath         = flag.String("trace", "", "Write executed instructions to a file (Gameboy Doctor format)")
+	traceInfo         = flag.String("trace-info", "", "Extra trace information (comma separated: cycles, ly, bank)")
+	traceFilter       = flag.String("trace-filter", "", "Only trace these PC ranges ([BB:]AAAA[-AAAA] or BB:, comma separated)")
+	traceWait         = flag.Bool("trace-wait", false, "Start tracing when a \"trace start\" breakpoint is hit")
+	gdbPort           = flag.String("gdb", "", "Start a GDB remote debugging server on this local port (e.g. 2345)")
+	dapPort           = flag.String("dap", "", "Start a Debug Adapter Protocol server on this local port for editors (e.g. 4711)")
+	scriptPath        = flag.String("script", "", "Lua script driving the emulation")
+	webAddr           = flag.String("web", "", "Stream the game to web browsers on this address, one viewer controls it (e.g. :8080)")
 )
 
 func main() {
@@ -39,6 +51,12 @@ func main() {
 		log.Fatal(err)
 	}
 
+	gui.SetPatch(*patchPath)
+
+	if *codeDataLog {
+		gui.EnableCDL()
+	}
+
 	err = gui.LoadROM(*romPath)
 	if err != nil {
 		log.Fatal(err)
@@ -60,6 +78,49 @@ func main() {
 		log.Printf("Invalid serial role %q", *serial)
 	}
 
+	// Infrared port (CGB only)
+	switch *infrared {
+	case "loopback":
+		gui.InfraredLoopback()
+	case "listen":
+		gui.ListenInfrared(infraredSocketPort)
+	case "connect":
+		gui.ConnectInfrared(infraredSocketPort)
+	case "":
+	default:
+		log.Printf("Invalid infrared mode %q", *infrared)
+	}
+
+	if *tracePath != "" {
+		if err = gui.StartTrace(*tracePath, *traceInfo, *traceFilter, *traceWait); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *gdbPort != "" {
+		if err = gui.ListenGDB(*gdbPort); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *dapPort != "" {
+		if err = gui.ListenDAP(*dapPort); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *webAddr != "" {
+		if err = gui.ListenWeb(*webAddr); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *scriptPath != "" {
+		if err = gui.RunScript(*scriptPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if *startWithDebugger {
 		gui.ToggleDebugger()
 	}
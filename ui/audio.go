@@ -61,31 +61,51 @@ func (ui *UI) Read(buf []byte) (n int, err error) {
 			bufferPosition += 4
 
 		default:
-			// If debugger is active and paused, return silence
-			if ui.Paused || (ui.debugger.Active && !ui.debugger.Running) { // If paused, return silence
+			// A GDB client controls the execution while attached
+			gdbAttached := ui.gdbServer != nil && ui.gdbServer.Attached()
+
+			// If debugger is active and paused (or a GDB client halted the game boy), return silence
+			if ui.Paused || (!gdbAttached && ui.debugger.Active && !ui.debugger.Running) || !ui.step() {
 				binary.LittleEndian.PutUint32(buf[bufferPosition:], math.Float32bits(0))
 				bufferPosition += 4
 				continue
 			}
-
-			ui.GameBoy.Joypad.DetectKeysPressed()
-			ui.GameBoy.CPU.ExecuteInstruction()
+			if gdbAttached {
+				continue
+			}
 
 			if ui.debugger.Active {
 				pc := ui.GameBoy.CPU.ReadPC()
+				ui.debugger.LearnCode(pc)
 				switch {
-				// Stop if next instruction
-				case ui.debugger.NextInstruction && ui.debugger.CallDepth <= 0:
-					ui.debugger.NextInstruction = false
+				// Stop if a watchpoint was hit
+				case ui.debugger.CheckWatchpoint():
+					ui.debugger.Stop()
+
+				// Stop if Next or Step Out are done
+				case ui.debugger.StepDone():
 					ui.debugger.Stop()
 
 				// Stop if breakpoint
 				case ui.debugger.CheckBreakpoint(pc):
 					ui.debugger.Stop()
 				}
+			} else if ui.GameBoy.Tracer != nil {
+				// Breakpoints starting or stopping the trace also work with the debugger closed
+				ui.debugger.CheckTraceTrigger(ui.GameBoy.CPU.ReadPC())
 			}
 		}
 	}
 
+	ui.pushWebAudio(buf[:bufferPosition])
 	return bufferPosition, nil
 }
+
+// step executes an instruction, returning false if a GDB client halted the game boy
+func (ui *UI) step() bool {
+	if ui.gdbServer != nil {
+		return ui.gdbServer.Run(ui.GameBoy.Step)
+	}
+	ui.GameBoy.Step()
+	return true
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// EnableCDL logs code and data accesses, saved in a .cdl file next to the ROM
+// (and in a .ram.cdl file for RAM) together with the game save
+func (ui *UI) EnableCDL() {
+	ui.GameBoy.EnableCDL()
+}
+
+// loadCDL merges the log of previous sessions of the same ROM, so that coverage accumulates
+func (ui *UI) loadCDL(romPath string) {
+	if ui.GameBoy.CDL == nil {
+		return
+	}
+
+	data, err := os.ReadFile(getCDLFileName(romPath, ".cdl"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Println("[WARN] Could not read CDL file:", err)
+		}
+		return
+	}
+	if err := ui.GameBoy.CDL.UnmarshalROM(data); err != nil {
+		log.Println("[WARN] Ignoring CDL file:", err)
+	}
+}
+
+func (ui *UI) saveCDL() {
+	logger := ui.GameBoy.CDL
+	if logger == nil {
+		return
+	}
+
+	if err := os.WriteFile(getCDLFileName(ui.fileName, ".cdl"), logger.MarshalROM(), 0644); err != nil {
+		log.Println("error writing CDL file:", err)
+	}
+	if err := os.WriteFile(getCDLFileName(ui.fileName, ".ram.cdl"), logger.MarshalRAM(), 0644); err != nil {
+		log.Println("error writing CDL file:", err)
+	}
+}
+
+func getCDLFileName(romPath, ext string) string {
+	return romPath[:len(romPath)-len(filepath.Ext(romPath))] + ext
+}
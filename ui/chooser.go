@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Height of a line of the debug font
+const chooserLineHeight = 16
+
+// romChooser is the list of the ROMs of an archive, shown over the screen
+// while the emulation is paused until one is chosen
+type romChooser struct {
+	archive   string
+	entries   []string
+	patchPath string // Patch applied to the chosen ROM
+	selected  int
+	top       int // First visible entry
+}
+
+// chooseROM shows the list of entries of the archive, the chosen one is loaded (with the patch, if any)
+func (ui *UI) chooseROM(archive string, entries []string, patchPath string) {
+	ui.romChooser = &romChooser{archive: archive, entries: entries, patchPath: patchPath}
+	ui.Paused = true
+}
+
+// updateROMChooser moves the selection with the arrows (a page with Page Up/Down) or the
+// mouse, loads the selected ROM with Enter (or a click) and keeps the current ROM with Escape
+func (ui *UI) updateROMChooser() {
+	c := ui.romChooser
+	_, screenHeight := ui.Layout(0, 0)
+	visible := max(screenHeight/chooserLineHeight-1, 1)
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		c.selected = max(c.selected-1, 0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		c.selected = min(c.selected+1, len(c.entries)-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		c.selected = max(c.selected-visible, 0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		c.selected = min(c.selected+visible, len(c.entries)-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		ui.romChooser = nil
+		ui.Paused = false
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		ui.loadChosenROM()
+		return
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		_, y := ebiten.CursorPosition()
+		if i := c.top + y/chooserLineHeight - 1; y >= chooserLineHeight && i < len(c.entries) {
+			c.selected = i
+			ui.loadChosenROM()
+			return
+		}
+	}
+
+	// Scroll to keep the selection visible
+	c.top = min(c.top, c.selected)
+	c.top = max(c.top, c.selected-visible+1)
+}
+
+// loadChosenROM loads the selected entry and restarts the emulation
+func (ui *UI) loadChosenROM() {
+	c := ui.romChooser
+	ui.romChooser = nil
+
+	if err := ui.loadROMEntry(c.archive, c.entries[c.selected], c.patchPath); err != nil {
+		log.Println("[ERROR] Loading ROM:", err)
+		ui.debugString = err.Error()
+		ui.debugStringTimer = 180
+	} else {
+		ui.GameBoy.Reset()
+	}
+	ui.Paused = false
+}
+
+// drawROMChooser draws the list of ROMs over the screen
+func (ui *UI) drawROMChooser(screen *ebiten.Image) {
+	c := ui.romChooser
+	screenHeight := screen.Bounds().Dy()
+	visible := max(screenHeight/chooserLineHeight-1, 1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Choose a ROM (Enter to load, Esc to cancel)\n")
+	for i := c.top; i < len(c.entries) && i < c.top+visible; i++ {
+		marker := "  "
+		if i == c.selected {
+			marker = "> "
+		}
+		b.WriteString(marker + c.entries[i] + "\n")
+	}
+
+	screen.Fill(color.Black)
+	ebitenutil.DebugPrint(screen, b.String())
+}
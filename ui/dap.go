@@ -0,0 +1,20 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/ui/debugger"
+)
+
+// ListenDAP starts a Debug Adapter Protocol server on the specified local port (4711 or :4711),
+// so that editors (e.g. VS Code) can debug the game with the debugger
+func (ui *UI) ListenDAP(socketPort string) error {
+	return ui.debugger.ListenDAP("localhost:"+strings.TrimPrefix(socketPort, ":"), debugger.DAPHost{
+		Activate: func() {
+			if !ui.debugger.Active {
+				ui.ToggleDebugger()
+			}
+		},
+		Load: ui.LoadROM,
+	})
+}
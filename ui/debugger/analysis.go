@@ -0,0 +1,87 @@
+package debugger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+)
+
+// LoadROM prepares the debugger for a new ROM: the code flow analysis is run,
+// its cheats are applied and labels are loaded from the symbol file next to it (RGBDS .sym)
+func (d *Debugger) LoadROM(romPath string) error {
+	d.romPath = romPath
+
+	// Loading replaced the game boy components
+	if d.Active {
+		d.installHooks()
+	}
+
+	cart := d.gameBoy.Memory.Cartridge
+	rom := make([]uint8, 0, cart.Header().ROMBanks*0x4000)
+	for bank := range cart.Header().ROMBanks {
+		for addr := range uint16(0x4000) {
+			rom = append(rom, cart.ReadROMBank(bank, addr))
+		}
+	}
+	d.analysis = disasm.Analyze(rom)
+	d.disassembler.analysis = d.analysis
+
+	// Events and cycles counted belong to the previous game
+	d.timeline.Clear()
+	d.profiler.Reset()
+
+	if err := d.loadCheats(); err != nil {
+		log.Println("[WARN] Could not load cheats:", err)
+	}
+
+	base := romPath[:len(romPath)-len(filepath.Ext(romPath))]
+	return d.LoadSymbols(base + ".sym")
+}
+
+// LearnCode marks the instruction at pc as code, so that the disassembler
+// follows code paths only known at runtime (e.g. jump tables)
+func (d *Debugger) LearnCode(pc uint16) {
+	if d.analysis != nil && pc < 0x8000 {
+		d.analysis.Learn(disasm.Location{Bank: d.gameBoy.Memory.DebugBank(pc), Addr: pc})
+	}
+}
+
+// ExportASM writes the disassembly of the whole ROM as RGBDS assembly next to the ROM
+func (d *Debugger) ExportASM() {
+	if d.analysis == nil {
+		return
+	}
+
+	path := d.romPath[:len(d.romPath)-len(filepath.Ext(d.romPath))] + ".asm"
+	if err := d.writeASM(path); err != nil {
+		d.SetStatus(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	d.SetStatus("Disassembly exported to " + path)
+}
+
+func (d *Debugger) writeASM(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := d.analysis.WriteASM(f, d.asmLabels()); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// asmLabels returns the labels of the symbol file for WriteASM (nil if there is none)
+func (d *Debugger) asmLabels() func(disasm.Location) (string, bool) {
+	if d.symbols == nil {
+		return nil
+	}
+	return func(loc disasm.Location) (string, bool) {
+		return d.symbols.Label(loc.Bank, loc.Addr)
+	}
+}
@@ -0,0 +1,46 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+)
+
+// bankAddress is an address qualified by the memory bank it belongs to (BB:AAAA),
+// so that the same address in different ROM/RAM banks can be told apart
+type bankAddress struct {
+	bank uint
+	addr uint16
+}
+
+func (ba bankAddress) String() string {
+	return fmt.Sprintf("%02X:%04X", ba.bank, ba.addr)
+}
+
+// currentBankAddress qualifies addr with the bank currently mapped
+func currentBankAddress(gb *gameboy.GameBoy, addr uint16) bankAddress {
+	return bankAddress{bank: gb.Memory.DebugBank(addr), addr: addr}
+}
+
+// parseBankAddress parses an address in the form "BB:AAAA" or "AAAA" (hexadecimal),
+// hasBank is false if the bank was not specified
+func parseBankAddress(s string) (ba bankAddress, hasBank bool, err error) {
+	bankStr, addrStr, hasBank := strings.Cut(strings.TrimSpace(s), ":")
+	if !hasBank {
+		addrStr = bankStr
+	} else {
+		bank, err := parseHex(bankStr, 16)
+		if err != nil {
+			return ba, false, err
+		}
+		ba.bank = uint(bank)
+	}
+
+	addr, err := parseHex(addrStr, 16)
+	if err != nil {
+		return ba, false, err
+	}
+	ba.addr = uint16(addr)
+	return ba, hasBank, nil
+}
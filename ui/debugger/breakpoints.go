@@ -0,0 +1,194 @@
+package debugger
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/util/expr"
+)
+
+// breakpoint stops the execution (or logs a message for tracepoints)
+// when the instruction at address is about to be executed
+type breakpoint struct {
+	address bankAddress
+
+	// Optional condition, the breakpoint is hit only if it is true
+	condition *expr.Expr
+
+	// Stop only from the hitTarget-th hit (0 or 1: every hit)
+	hits      int
+	hitTarget int
+
+	// Tracepoint message: if set, log it and continue
+	message *expr.Template
+
+	// Start ("start") or stop ("stop") the instruction trace and continue
+	traceAction string
+}
+
+func (bp *breakpoint) String() string {
+	s := bp.address.String()
+	if bp.condition != nil {
+		s += " if " + bp.condition.String()
+	}
+	if bp.hitTarget > 1 {
+		s += fmt.Sprintf(" hits %d/%d", bp.hits, bp.hitTarget)
+	} else {
+		s += fmt.Sprintf(" (%d hits)", bp.hits)
+	}
+	if bp.traceAction != "" {
+		s += " trace " + bp.traceAction
+	}
+	if bp.message != nil {
+		s += " log " + bp.message.String()
+	}
+	return s
+}
+
+// parseBreakpoint parses a breakpoint in the form
+// "[BB:]AAAA|LABEL [if COND] [hits N] [trace start|stop] [log MESSAGE]",
+// e.g. "01:4123 if A == $3F && [$C100] < 10 hits 3" or "4123 log HP={[$C100]:d}".
+// Without a bank the one currently mapped is used
+func (d *Debugger) parseBreakpoint(spec string) (*breakpoint, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty breakpoint")
+	}
+
+	// Address
+	addrStr, rest, _ := strings.Cut(spec, " ")
+	ba, hasBank, err := d.resolveAddress(addrStr)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBank {
+		ba = currentBankAddress(d.gameBoy, ba.addr)
+	}
+	bp := &breakpoint{address: ba}
+
+	// Message is always the last part (it can contain any text)
+	rest = " " + rest + " "
+	if i := strings.Index(rest, " log "); i >= 0 {
+		if bp.message, err = expr.ParseTemplate(strings.TrimSpace(rest[i+len(" log "):])); err != nil {
+			return nil, err
+		}
+		rest = rest[:i+1]
+	}
+
+	// Instruction trace trigger
+	if i := strings.Index(rest, " trace "); i >= 0 {
+		fields := strings.Fields(rest[i+len(" trace "):])
+		if len(fields) == 0 || fields[0] != "start" && fields[0] != "stop" {
+			return nil, fmt.Errorf("trace action must be start or stop")
+		}
+		bp.traceAction = fields[0]
+		rest = rest[:i+1] + strings.Join(fields[1:], " ") + " "
+	}
+
+	// Hit count
+	if i := strings.Index(rest, " hits "); i >= 0 {
+		fields := strings.Fields(rest[i+len(" hits "):])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("missing hit count")
+		}
+		if bp.hitTarget, err = strconv.Atoi(fields[0]); err != nil || bp.hitTarget < 0 {
+			return nil, fmt.Errorf("invalid hit count %q", fields[0])
+		}
+		// Anything after the hit count is part of the condition
+		rest = rest[:i+1] + strings.Join(fields[1:], " ")
+	}
+
+	// Condition
+	rest = strings.TrimSpace(rest)
+	if cond, ok := strings.CutPrefix(rest, "if "); ok {
+		if bp.condition, err = expr.Parse(cond); err != nil {
+			return nil, err
+		}
+	} else if rest != "" {
+		return nil, fmt.Errorf("unexpected %q", rest)
+	}
+
+	return bp, nil
+}
+
+// breakpointLabel describes the breakpoint, with the label of its address (if any)
+func (d *Debugger) breakpointLabel(bp *breakpoint) string {
+	if label, ok := d.label(bp.address); ok {
+		return label + " " + bp.String()
+	}
+	return bp.String()
+}
+
+// AddBreakpoint adds (or replaces) a breakpoint parsed from spec (see parseBreakpoint)
+func (d *Debugger) AddBreakpoint(spec string) error {
+	_, err := d.addBreakpoint(spec)
+	return err
+}
+
+func (d *Debugger) addBreakpoint(spec string) (*breakpoint, error) {
+	bp, err := d.parseBreakpoint(spec)
+	if err != nil {
+		return nil, err
+	}
+	d.disassembler.breakpoints[bp.address] = bp
+	d.disassembler.refresh()
+	return bp, nil
+}
+
+// RemoveBreakpoint removes the breakpoint at address
+func (d *Debugger) RemoveBreakpoint(address bankAddress) {
+	delete(d.disassembler.breakpoints, address)
+	d.disassembler.refresh()
+}
+
+// hit evaluates the breakpoint and returns true if the execution should stop
+func (bp *breakpoint) hit(d *Debugger) bool {
+	env := debugEnv{d}
+
+	if bp.condition != nil {
+		ok, err := bp.condition.True(env)
+		if err != nil {
+			// Stop to let the user fix the condition
+			d.SetStatus(fmt.Sprintf("Breakpoint %s: %v", bp.address, err))
+			return true
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	bp.hits++
+	if bp.hits < bp.hitTarget {
+		return false
+	}
+
+	if bp.traceAction != "" {
+		if tracer := d.gameBoy.Tracer; tracer != nil {
+			tracer.SetActive(bp.traceAction == "start")
+		}
+	}
+	if bp.message != nil {
+		log.Printf("[TRACE] %s: %s\n", bp.address, bp.message.Format(env))
+	}
+	if bp.traceAction != "" || bp.message != nil {
+		return false
+	}
+
+	d.SetStatus("Breakpoint " + d.breakpointLabel(bp))
+	return true
+}
+
+// matches returns true if the breakpoint would stop the execution at the current state,
+// ignoring the hit count (used to search it while going back in the history)
+func (bp *breakpoint) matches(d *Debugger) bool {
+	if bp.traceAction != "" || bp.message != nil {
+		return false
+	}
+	if bp.condition == nil {
+		return true
+	}
+	ok, err := bp.condition.True(debugEnv{d})
+	return ok || err != nil
+}
@@ -0,0 +1,105 @@
+package debugger
+
+import (
+	"slices"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+type breakpointsViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	list       *widget.Container
+	errorLabel *widget.Text
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newBreakpointsViewer() *breakpointsViewer {
+	bv := &breakpointsViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	input := newTextInput("01:4123 if A == $3F && [$C100] < 10", 480, func(text string) {
+		if err := d.AddBreakpoint(text); err != nil {
+			bv.errorLabel.Label = err.Error()
+			return
+		}
+		bv.errorLabel.Label = ""
+		bv.Sync(d.gameBoy)
+	})
+
+	help := newLabel("[BB:]AAAA|LABEL [if COND] [hits N] [log MESSAGE {EXPR}]", theme.Debugger.HeaderColor)
+	bv.errorLabel = newLabel("", theme.Debugger.Disassembler.BreakpointHoverColor)
+	bv.list = newContainer(widget.DirectionVertical)
+
+	root.AddChild(help, input, bv.errorLabel, bv.list)
+
+	bv.windowInfo = newWindow("Breakpoints", root, &bv.closeWindow)
+	return bv
+}
+
+func (bv *breakpointsViewer) Window() *widget.Window {
+	return bv.windowInfo.Window
+}
+
+func (bv *breakpointsViewer) Contents() *widget.Container {
+	return bv.windowInfo.Contents
+}
+
+func (bv *breakpointsViewer) TitleBar() *widget.Container {
+	return bv.windowInfo.TitleBar
+}
+
+func (bv *breakpointsViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := bv.closeWindow
+	bv.closeWindow = closeFunc
+	return old
+}
+
+func (bv *breakpointsViewer) Sync(_ *gameboy.GameBoy) {
+	bv.list.RemoveChildren()
+
+	// Sort by bank and address
+	breakpoints := make([]*breakpoint, 0, len(bv.d.disassembler.breakpoints))
+	for _, bp := range bv.d.disassembler.breakpoints {
+		breakpoints = append(breakpoints, bp)
+	}
+	slices.SortFunc(breakpoints, func(a, b *breakpoint) int {
+		if a.address.bank != b.address.bank {
+			return int(a.address.bank) - int(b.address.bank)
+		}
+		return int(a.address.addr) - int(b.address.addr)
+	})
+
+	for _, bp := range breakpoints {
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+		row.AddChild(
+			newButton("X", func() {
+				bv.d.RemoveBreakpoint(bp.address)
+				bv.Sync(bv.d.gameBoy)
+			}),
+			newLabel(bv.d.breakpointLabel(bp), theme.Debugger.LabelColor),
+		)
+		bv.list.AddChild(row)
+	}
+}
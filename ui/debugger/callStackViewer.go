@@ -0,0 +1,102 @@
+package debugger
+
+import (
+	"fmt"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+// callFrames returns the call stack innermost first: the current instruction, then the
+// instruction that entered each routine (the interrupted one for interrupts)
+func (d *Debugger) callFrames() []bankAddress {
+	stack := d.gameBoy.CPU.CallStack()
+
+	frames := make([]bankAddress, 0, len(stack)+1)
+	frames = append(frames, currentBankAddress(d.gameBoy, d.gameBoy.CPU.ReadPC()))
+	for i := len(stack) - 1; i >= 0; i-- {
+		frames = append(frames, bankAddress{bank: stack[i].ReturnBank, addr: stack[i].CallPC})
+	}
+	return frames
+}
+
+type callStackViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	list *widget.Container
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newCallStackViewer() *callStackViewer {
+	cv := &callStackViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	help := newLabel("Routines called, innermost first (Step Out: F4)", theme.Debugger.HeaderColor)
+	cv.list = newContainer(widget.DirectionVertical)
+	root.AddChild(help, cv.list)
+
+	cv.windowInfo = newWindow("Call Stack", root, &cv.closeWindow)
+	return cv
+}
+
+func (cv *callStackViewer) Window() *widget.Window {
+	return cv.windowInfo.Window
+}
+
+func (cv *callStackViewer) Contents() *widget.Container {
+	return cv.windowInfo.Contents
+}
+
+func (cv *callStackViewer) TitleBar() *widget.Container {
+	return cv.windowInfo.TitleBar
+}
+
+func (cv *callStackViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := cv.closeWindow
+	cv.closeWindow = closeFunc
+	return old
+}
+
+func (cv *callStackViewer) Sync(gb *gameboy.GameBoy) {
+	cv.list.RemoveChildren()
+
+	stack := gb.CPU.CallStack()
+	for i, ba := range cv.d.callFrames() {
+		text := fmt.Sprintf("#%-2d %s %s", i, ba, cv.d.addressName(ba))
+		if i > 0 {
+			// Routine entered from this frame
+			f := stack[len(stack)-i]
+			target := bankAddress{bank: f.TargetBank, addr: f.Target}
+			text += fmt.Sprintf("  %s %s", f.Kind, cv.d.addressName(target))
+		}
+
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+		row.AddChild(
+			newButton("Go", func() {
+				cv.d.disassembler.goTo(ba, true)
+			}),
+			newLabel(text, theme.Debugger.LabelColor),
+		)
+		cv.list.AddChild(row)
+	}
+}
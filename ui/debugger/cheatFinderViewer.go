@@ -0,0 +1,150 @@
+package debugger
+
+import (
+	"fmt"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cheats"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+// maxFinderResults is the number of candidates listed by the cheat finder
+const maxFinderResults = 16
+
+type cheatFinderViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	// Current search (nil before the first snapshot)
+	finder *cheats.Finder
+
+	statusLabel *widget.Text
+	results     *widget.Container
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newCheatFinderViewer() *cheatFinderViewer {
+	fv := &cheatFinderViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	help := newLabel("Take a snapshot, change the value in game, then compare", theme.Debugger.HeaderColor)
+
+	buttons := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+	buttons.AddChild(newButton("New search", func() {
+		fv.finder = cheats.NewFinder(d.gameBoy.Memory)
+		fv.Sync(d.gameBoy)
+	}))
+	for _, cmp := range []struct {
+		name string
+		cmp  cheats.Comparison
+	}{
+		{"Equal", cheats.Equal},
+		{"Changed", cheats.Changed},
+		{"Increased", cheats.Increased},
+		{"Decreased", cheats.Decreased},
+	} {
+		buttons.AddChild(newButton(cmp.name, func() { fv.filter(cmp.cmp, 0) }))
+	}
+
+	value := newTextInput("Equal to value (hex)", 360, func(text string) {
+		v, err := parseHex(text, 8)
+		if err != nil {
+			fv.statusLabel.Label = err.Error()
+			return
+		}
+		fv.filter(cheats.EqualTo, uint8(v))
+	})
+
+	fv.statusLabel = newLabel("", theme.Debugger.LabelColor)
+	fv.results = newContainer(widget.DirectionVertical)
+
+	root.AddChild(help, buttons, value, fv.statusLabel, fv.results)
+
+	fv.windowInfo = newWindow("Cheat finder", root, &fv.closeWindow)
+	return fv
+}
+
+// filter narrows the candidates (the first snapshot is taken if there is no search)
+func (fv *cheatFinderViewer) filter(cmp cheats.Comparison, value uint8) {
+	if fv.finder == nil {
+		fv.finder = cheats.NewFinder(fv.d.gameBoy.Memory)
+		if cmp != cheats.EqualTo {
+			fv.Sync(fv.d.gameBoy)
+			return
+		}
+	}
+	fv.finder.Filter(cmp, value)
+	fv.Sync(fv.d.gameBoy)
+}
+
+func (fv *cheatFinderViewer) Window() *widget.Window {
+	return fv.windowInfo.Window
+}
+
+func (fv *cheatFinderViewer) Contents() *widget.Container {
+	return fv.windowInfo.Contents
+}
+
+func (fv *cheatFinderViewer) TitleBar() *widget.Container {
+	return fv.windowInfo.TitleBar
+}
+
+func (fv *cheatFinderViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := fv.closeWindow
+	fv.closeWindow = closeFunc
+	return old
+}
+
+func (fv *cheatFinderViewer) Sync(_ *gameboy.GameBoy) {
+	fv.results.RemoveChildren()
+	if fv.finder == nil {
+		fv.statusLabel.Label = "No search"
+		return
+	}
+
+	fv.statusLabel.Label = fmt.Sprintf("%d candidates", fv.finder.Len())
+	locs, values := fv.finder.Results(maxFinderResults)
+	for i, loc := range locs {
+		bank, addr := loc.Address()
+		name := fmt.Sprintf("%04X", addr)
+		if bank >= 0 {
+			name = bankAddress{bank: uint(bank), addr: addr}.String()
+		}
+
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+		// Freeze the value found
+		code := cheats.GameSharkCode(bank, addr, values[i])
+		row.AddChild(
+			newButton("Add", func() {
+				if err := fv.d.AddCheat(code + " " + name); err != nil {
+					fv.statusLabel.Label = err.Error()
+				}
+			}),
+			newLabel(fmt.Sprintf("%s = %02X (%s)", name, values[i], code), theme.Debugger.LabelColor),
+		)
+		fv.results.AddChild(row)
+	}
+}
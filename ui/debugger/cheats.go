@@ -0,0 +1,76 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/cheats"
+)
+
+// cheatsPath returns the file storing the cheats of the ROM (next to it, .cht)
+func (d *Debugger) cheatsPath() string {
+	return d.romPath[:len(d.romPath)-len(filepath.Ext(d.romPath))] + ".cht"
+}
+
+// loadCheats applies the cheats saved for the ROM
+func (d *Debugger) loadCheats() error {
+	list := cheats.NewList()
+	d.gameBoy.SetCheats(list)
+
+	data, err := os.ReadFile(d.cheatsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := list.Unmarshal(data); err != nil {
+		return fmt.Errorf("%s: %w", d.cheatsPath(), err)
+	}
+	log.Printf("Loaded %d cheats from %s\n", len(list.Cheats()), d.cheatsPath())
+	return nil
+}
+
+// saveCheats saves the cheats of the ROM (the file is removed when there are none)
+func (d *Debugger) saveCheats() {
+	list := d.gameBoy.Cheats
+	if len(list.Cheats()) == 0 {
+		if err := os.Remove(d.cheatsPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			d.SetStatus(fmt.Sprintf("Could not save cheats: %v", err))
+		}
+		return
+	}
+
+	if err := os.WriteFile(d.cheatsPath(), list.Marshal(), 0644); err != nil {
+		d.SetStatus(fmt.Sprintf("Could not save cheats: %v", err))
+	}
+}
+
+// AddCheat adds a cheat parsed from spec (see cheats.ParseCheat) and saves the cheats
+func (d *Debugger) AddCheat(spec string) error {
+	c, err := cheats.ParseCheat(spec)
+	if err != nil {
+		return err
+	}
+	d.gameBoy.Cheats.Add(c)
+	d.saveCheats()
+	return nil
+}
+
+// RemoveCheat removes the i-th cheat and saves the cheats
+func (d *Debugger) RemoveCheat(i int) {
+	d.gameBoy.Cheats.Remove(i)
+	d.saveCheats()
+}
+
+// ToggleCheat enables or disables the i-th cheat and saves the cheats
+func (d *Debugger) ToggleCheat(i int) {
+	list := d.gameBoy.Cheats.Cheats()
+	if i < len(list) {
+		d.gameBoy.Cheats.SetEnabled(i, !list[i].Enabled)
+		d.saveCheats()
+	}
+}
@@ -0,0 +1,103 @@
+package debugger
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+type cheatsViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	list       *widget.Container
+	errorLabel *widget.Text
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newCheatsViewer() *cheatsViewer {
+	cv := &cheatsViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	input := newTextInput("010238CD+010339CD Infinite lives", 360, func(text string) {
+		if err := d.AddCheat(text); err != nil {
+			cv.errorLabel.Label = err.Error()
+			return
+		}
+		cv.errorLabel.Label = ""
+		cv.Sync(d.gameBoy)
+	})
+
+	help := newLabel("GameShark TTVVAAAA or Game Genie ABC-DEF[-GHI] [+ CODE...] [NAME]", theme.Debugger.HeaderColor)
+	cv.errorLabel = newLabel("", theme.Debugger.Disassembler.BreakpointHoverColor)
+	cv.list = newContainer(widget.DirectionVertical)
+
+	root.AddChild(help, input, cv.errorLabel, cv.list)
+
+	cv.windowInfo = newWindow("Cheats", root, &cv.closeWindow)
+	return cv
+}
+
+func (cv *cheatsViewer) Window() *widget.Window {
+	return cv.windowInfo.Window
+}
+
+func (cv *cheatsViewer) Contents() *widget.Container {
+	return cv.windowInfo.Contents
+}
+
+func (cv *cheatsViewer) TitleBar() *widget.Container {
+	return cv.windowInfo.TitleBar
+}
+
+func (cv *cheatsViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := cv.closeWindow
+	cv.closeWindow = closeFunc
+	return old
+}
+
+func (cv *cheatsViewer) Sync(gb *gameboy.GameBoy) {
+	cv.list.RemoveChildren()
+	if gb.Cheats == nil {
+		return
+	}
+
+	for i, c := range gb.Cheats.Cheats() {
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+
+		state := "Off"
+		if c.Enabled {
+			state = "On "
+		}
+		row.AddChild(
+			newButton("X", func() {
+				cv.d.RemoveCheat(i)
+				cv.Sync(cv.d.gameBoy)
+			}),
+			newButton(state, func() {
+				cv.d.ToggleCheat(i)
+				cv.Sync(cv.d.gameBoy)
+			}),
+			newLabel(c.String(), theme.Debugger.LabelColor),
+		)
+		cv.list.AddChild(row)
+	}
+}
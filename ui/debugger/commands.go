@@ -4,12 +4,54 @@ func (d *Debugger) Toggle() {
 	d.Active = !d.Active
 	if d.Active {
 		defer d.Sync()
+		d.installHooks()
+		d.gameBoy.EnableHistory()
+		d.gameBoy.SetTimeline(d.timeline)
 		d.Stop()
+	} else {
+		// Watchpoints, calls, history and events are only recorded while debugging
+		d.gameBoy.Memory.SetAccessHook(accessHookOwner, nil)
+		d.gameBoy.CPU.TrackCalls(false)
+		d.gameBoy.DisableHistory()
+		d.gameBoy.SetTimeline(nil)
+
+		if s := d.dap.Load(); s != nil {
+			s.continued()
+		}
 	}
 }
 
+// Owner of the memory access hook of the debugger
+const accessHookOwner = "debugger"
+
+// installHooks starts the call stack tracking and sets the memory hook used by the debugger
+func (d *Debugger) installHooks() {
+	d.gameBoy.CPU.TrackCalls(true)
+	d.gameBoy.Memory.SetAccessHook(accessHookOwner, d.onMemoryAccess)
+}
+
+// CheckBreakpoint returns true if there is a breakpoint at addr in the bank currently mapped
+// and its condition and hit count say that execution should stop (tracepoints only log a message)
 func (d *Debugger) CheckBreakpoint(addr uint16) bool {
-	return d.disassembler.IsBreakpoint(addr)
+	if len(d.disassembler.breakpoints) == 0 {
+		return false
+	}
+
+	bp, ok := d.disassembler.breakpoints[currentBankAddress(d.gameBoy, addr)]
+	return ok && bp.hit(d)
+}
+
+// CheckTraceTrigger evaluates the breakpoint at addr if it starts or stops the instruction
+// trace, so that the triggers work while the debugger is closed (other breakpoints are ignored)
+func (d *Debugger) CheckTraceTrigger(addr uint16) {
+	if len(d.disassembler.breakpoints) == 0 {
+		return
+	}
+
+	bp, ok := d.disassembler.breakpoints[currentBankAddress(d.gameBoy, addr)]
+	if ok && bp.traceAction != "" {
+		bp.hit(d)
+	}
 }
 
 // Run commands
@@ -21,31 +63,64 @@ func (d *Debugger) Step() {
 
 	defer d.Sync()
 
-	d.gameBoy.CPU.ExecuteInstruction()
+	d.SetStatus("")
+	d.gameBoy.Step()
+	d.CheckWatchpoint()
 }
 
+// Next executes an instruction, running the routine it calls until it returns
 func (d *Debugger) Next() {
 	if d.Running {
 		return
 	}
 
-	d.NextInstruction = true
-	d.CallDepth = 0
+	d.stepping = true
+	d.stepDepth = d.gameBoy.CPU.CallDepth()
+	d.Continue()
+}
+
+// StepOut runs until the current routine returns
+func (d *Debugger) StepOut() {
+	if d.Running {
+		return
+	}
+
+	depth := d.gameBoy.CPU.CallDepth()
+	if depth == 0 {
+		d.SetStatus("No routine call recorded to step out of")
+		return
+	}
+	d.stepping = true
+	d.stepDepth = depth - 1
 	d.Continue()
 }
 
+// StepDone returns true when Next or Step Out are done (the call stack is back to the depth wanted)
+func (d *Debugger) StepDone() bool {
+	if !d.stepping || d.gameBoy.CPU.CallDepth() > d.stepDepth {
+		return false
+	}
+	d.stepping = false
+	return true
+}
+
 func (d *Debugger) Continue() {
 	if d.Running {
 		return
 	}
 
 	d.Running = true
+	d.SetStatus("")
 
 	// Unselect current entry
 	d.disassembler.currentInstruction = -1
 
 	// TODO Disable control buttons
 	d.disassembler.refresh()
+
+	if s := d.dap.Load(); s != nil {
+		s.continued()
+	}
 }
 
 func (d *Debugger) NextVBlank() {
@@ -70,7 +145,12 @@ func (d *Debugger) Stop() {
 	defer d.Sync()
 
 	d.Running = false
+	d.stepping = false
 	// TODO Enable control buttons
+
+	if s := d.dap.Load(); s != nil {
+		s.stopped(d.statusBar.Label)
+	}
 }
 
 func (d *Debugger) Reset() {
@@ -79,12 +159,5 @@ func (d *Debugger) Reset() {
 	// Stop if active
 	d.Stop()
 	d.gameBoy.Reset()
-
-	callHook := func() {
-		d.CallDepth++
-	}
-	retHook := func() {
-		d.CallDepth--
-	}
-	d.gameBoy.CPU.SetHooks(callHook, retHook)
+	d.installHooks()
 }
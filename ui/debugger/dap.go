@@ -0,0 +1,552 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/danielecanzoneri/lucky-boy/util/dap"
+	"github.com/danielecanzoneri/lucky-boy/util/srcmap"
+)
+
+// Debug Adapter Protocol server: editors (e.g. VS Code) control the debugger and show
+// the game state in the assembly sources. Requests are handled in Update, as the
+// commands of the debugger window, and the run commands are the debugger ones.
+//
+// Editors connect to the port of -dap, e.g. with "debugServer": 4711 in a VS Code launch
+// configuration. launch loads the program (if any) and maps the sources (glob patterns)
+// besides those with breakpoints, stopOnEntry stops before running. Breakpoints are set
+// on source lines (see srcmap) or labels, with conditions, hit counts and log messages.
+// Code outside of the sources is shown in the ROM disassembly, variables are the CPU and
+// I/O registers and the WRAM labels, and the debug console and hovers evaluate the
+// expressions of the breakpoints
+
+// dapThreadID is the only thread (the CPU)
+const dapThreadID = 1
+
+// DAPHost is what the DAP server needs from the emulator
+type DAPHost struct {
+	// Activate shows the debugger, if it is not active
+	Activate func()
+
+	// Load loads a ROM (launch request with a program)
+	Load func(path string) error
+}
+
+type dapSession struct {
+	d    *Debugger
+	host DAPHost
+	conn *dap.Conn
+	rw   io.ReadWriteCloser
+
+	// Execution starts when the client is attached (launch or attach request)
+	// and configured (configurationDone request, after the breakpoints are set)
+	attached, configured bool
+	stopOnEntry          bool
+
+	// Source files (by path) and breakpoints set by the client (by source path or function name)
+	sources             map[string]*srcmap.Map
+	sourceBreakpoints   map[string][]bankAddress
+	functionBreakpoints []bankAddress
+
+	// Disassembly of the ROM, shown for code outside of the sources
+	listing *dapListing
+
+	// Function run after the response to the current request (e.g. to send an event)
+	after func()
+
+	// Reason of the next stop, set when the client resumes the execution
+	// (execution stops in the audio goroutine)
+	mu     sync.Mutex
+	reason string
+}
+
+// ListenDAP accepts DAP clients on address (one at a time) in background
+func (d *Debugger) ListenDAP(address string, host DAPHost) error {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	d.requests = make(chan func(), 64)
+
+	log.Println("[DAP] Listening on", ln.Addr())
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				log.Println("[ERROR] Accepting DAP client:", err)
+				return
+			}
+
+			log.Println("[DAP] Client attached from", c.RemoteAddr())
+			d.serveDAP(c, host)
+			log.Println("[DAP] Client detached")
+		}
+	}()
+	return nil
+}
+
+// HandleRequests handles the requests received from the DAP client (called every frame)
+func (d *Debugger) HandleRequests() {
+	for {
+		select {
+		case handle := <-d.requests:
+			handle()
+		default:
+			return
+		}
+	}
+}
+
+// serveDAP reads the requests of a client until it disconnects
+func (d *Debugger) serveDAP(rw io.ReadWriteCloser, host DAPHost) {
+	s := &dapSession{
+		d:                 d,
+		host:              host,
+		conn:              dap.NewConn(rw),
+		rw:                rw,
+		sources:           make(map[string]*srcmap.Map),
+		sourceBreakpoints: make(map[string][]bankAddress),
+	}
+	d.dap.Store(s)
+
+	for {
+		req, err := s.conn.ReadRequest()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Println("[DAP]", err)
+			}
+			break
+		}
+		d.requests <- func() { s.handle(req) }
+	}
+
+	done := make(chan struct{})
+	d.requests <- func() {
+		s.detach()
+		close(done)
+	}
+	<-done
+	rw.Close()
+}
+
+var dapHandlers = map[string]func(s *dapSession, args json.RawMessage) (any, error){
+	"initialize":              (*dapSession).initialize,
+	"launch":                  (*dapSession).launch,
+	"attach":                  (*dapSession).attach,
+	"configurationDone":       (*dapSession).configurationDone,
+	"disconnect":              (*dapSession).disconnect,
+	"setBreakpoints":          (*dapSession).setBreakpoints,
+	"setFunctionBreakpoints":  (*dapSession).setFunctionBreakpoints,
+	"setExceptionBreakpoints": (*dapSession).setExceptionBreakpoints,
+	"threads":                 (*dapSession).threads,
+	"stackTrace":              (*dapSession).stackTrace,
+	"scopes":                  (*dapSession).scopes,
+	"variables":               (*dapSession).variables,
+	"source":                  (*dapSession).source,
+	"evaluate":                (*dapSession).evaluate,
+	"continue":                (*dapSession).continueRequest,
+	"next":                    (*dapSession).next,
+	"stepIn":                  (*dapSession).stepIn,
+	"stepOut":                 (*dapSession).stepOut,
+	"stepBack":                (*dapSession).stepBack,
+	"reverseContinue":         (*dapSession).reverseContinue,
+	"pause":                   (*dapSession).pause,
+}
+
+func (s *dapSession) handle(req *dap.Request) {
+	handler, ok := dapHandlers[req.Command]
+	if !ok {
+		s.conn.RespondError(req, fmt.Errorf("unsupported request %q", req.Command))
+		return
+	}
+
+	s.after = nil
+	body, err := handler(s, req.Arguments)
+	if err != nil {
+		s.conn.RespondError(req, err)
+		return
+	}
+	if err := s.conn.Respond(req, body); err != nil {
+		log.Println("[DAP]", err)
+	}
+	if s.after != nil {
+		s.after()
+	}
+}
+
+// decodeArguments decodes the arguments of a request (they are optional for some requests)
+func decodeArguments(raw json.RawMessage, args any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, args)
+}
+
+// Session
+
+func (s *dapSession) initialize(json.RawMessage) (any, error) {
+	s.after = func() {
+		s.conn.SendEvent("initialized", nil)
+	}
+	return dap.Capabilities{
+		SupportsConfigurationDoneRequest:  true,
+		SupportsFunctionBreakpoints:       true,
+		SupportsConditionalBreakpoints:    true,
+		SupportsHitConditionalBreakpoints: true,
+		SupportsLogPoints:                 true,
+		SupportsEvaluateForHovers:         true,
+		SupportsStepBack:                  true,
+	}, nil
+}
+
+type attachArguments struct {
+	StopOnEntry bool `json:"stopOnEntry"`
+
+	// Assembly files (glob patterns) mapped to the ROM, besides those with breakpoints
+	Sources []string `json:"sources"`
+}
+
+type launchArguments struct {
+	attachArguments
+
+	// ROM loaded before starting (the one already loaded if empty)
+	Program string `json:"program"`
+}
+
+func (s *dapSession) launch(raw json.RawMessage) (any, error) {
+	var args launchArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	s.host.Activate()
+	if args.Program != "" {
+		if err := s.host.Load(args.Program); err != nil {
+			return nil, err
+		}
+		// Labels and code changed
+		s.sources = make(map[string]*srcmap.Map)
+		s.listing = nil
+	}
+	return s.start(args.attachArguments)
+}
+
+func (s *dapSession) attach(raw json.RawMessage) (any, error) {
+	var args attachArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	s.host.Activate()
+	return s.start(args)
+}
+
+func (s *dapSession) start(args attachArguments) (any, error) {
+	for _, pattern := range args.Sources {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if _, err := s.sourceMap(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.attached = true
+	s.stopOnEntry = args.StopOnEntry
+	s.after = s.run
+	return nil, nil
+}
+
+func (s *dapSession) configurationDone(json.RawMessage) (any, error) {
+	s.configured = true
+	s.after = s.run
+	return nil, nil
+}
+
+// run starts the execution when the client is attached and configured
+func (s *dapSession) run() {
+	if !s.attached || !s.configured {
+		return
+	}
+	if s.stopOnEntry {
+		s.sendStopped("entry", "")
+	} else {
+		s.d.Continue()
+	}
+}
+
+func (s *dapSession) disconnect(json.RawMessage) (any, error) {
+	s.after = func() {
+		s.detach()
+		s.rw.Close()
+	}
+	return nil, nil
+}
+
+// detach removes the breakpoints of the client and lets the game run
+func (s *dapSession) detach() {
+	if s.d.dap.Load() != s {
+		return
+	}
+	s.d.dap.Store(nil)
+
+	for _, addresses := range s.sourceBreakpoints {
+		for _, ba := range addresses {
+			s.d.RemoveBreakpoint(ba)
+		}
+	}
+	for _, ba := range s.functionBreakpoints {
+		s.d.RemoveBreakpoint(ba)
+	}
+	if s.attached && s.d.Active {
+		s.d.Continue()
+	}
+}
+
+// checkAttached returns an error if the client is not debugging the game yet
+func (s *dapSession) checkAttached() error {
+	if !s.attached || !s.d.Active {
+		return errors.New("not debugging, send a launch or attach request")
+	}
+	return nil
+}
+
+// Breakpoints
+
+type setBreakpointsArguments struct {
+	Source      dap.Source             `json:"source"`
+	Breakpoints []dap.SourceBreakpoint `json:"breakpoints"`
+}
+
+func (s *dapSession) setBreakpoints(raw json.RawMessage) (any, error) {
+	var args setBreakpointsArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	var m *srcmap.Map
+	key := args.Source.Path
+	if ref := args.Source.SourceReference; ref != 0 {
+		if s.listing == nil || s.listing.ref != ref {
+			return nil, errors.New("the disassembly changed, reopen it")
+		}
+		m, key = s.listing.m, "<disassembly>"
+	} else {
+		var err error
+		if m, err = s.sourceMap(args.Source.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ba := range s.sourceBreakpoints[key] {
+		s.d.RemoveBreakpoint(ba)
+	}
+	s.sourceBreakpoints[key] = nil
+
+	breakpoints := make([]dap.Breakpoint, 0, len(args.Breakpoints))
+	for _, sb := range args.Breakpoints {
+		line, loc, ok := m.Resolve(sb.Line)
+		if !ok {
+			breakpoints = append(breakpoints, dap.Breakpoint{Message: "No code at this line"})
+			continue
+		}
+
+		ba, err := s.addBreakpoint(bankAddress{bank: loc.Bank, addr: loc.Addr}.String(), sb.Condition, sb.HitCondition, sb.LogMessage)
+		if err != nil {
+			breakpoints = append(breakpoints, dap.Breakpoint{Message: err.Error()})
+			continue
+		}
+		s.sourceBreakpoints[key] = append(s.sourceBreakpoints[key], ba)
+		breakpoints = append(breakpoints, dap.Breakpoint{Verified: true, Line: line})
+	}
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+// setFunctionBreakpoints sets breakpoints on labels (or addresses, see parseBreakpoint)
+func (s *dapSession) setFunctionBreakpoints(raw json.RawMessage) (any, error) {
+	var args struct {
+		Breakpoints []dap.FunctionBreakpoint `json:"breakpoints"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	for _, ba := range s.functionBreakpoints {
+		s.d.RemoveBreakpoint(ba)
+	}
+	s.functionBreakpoints = nil
+
+	breakpoints := make([]dap.Breakpoint, 0, len(args.Breakpoints))
+	for _, fb := range args.Breakpoints {
+		ba, err := s.addBreakpoint(fb.Name, fb.Condition, fb.HitCondition, "")
+		if err != nil {
+			breakpoints = append(breakpoints, dap.Breakpoint{Message: err.Error()})
+			continue
+		}
+		s.functionBreakpoints = append(s.functionBreakpoints, ba)
+		breakpoints = append(breakpoints, dap.Breakpoint{Verified: true})
+	}
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+func (s *dapSession) setExceptionBreakpoints(json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+// addBreakpoint adds a debugger breakpoint, with the condition, hit count (a number,
+// optionally preceded by >=) and log message in the DAP syntax (it is the same of
+// the debugger: expressions in braces are replaced with their value)
+func (s *dapSession) addBreakpoint(address, condition, hitCondition, logMessage string) (bankAddress, error) {
+	spec := address
+	if condition != "" {
+		spec += " if " + condition
+	}
+	if hitCondition != "" {
+		spec += " hits " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(hitCondition), ">="))
+	}
+	if logMessage != "" {
+		spec += " log " + logMessage
+	}
+	bp, err := s.d.addBreakpoint(spec)
+	if err != nil {
+		return bankAddress{}, err
+	}
+	return bp.address, nil
+}
+
+// Execution
+
+func (s *dapSession) threads(json.RawMessage) (any, error) {
+	return map[string]any{"threads": []dap.Thread{{ID: dapThreadID, Name: "CPU"}}}, nil
+}
+
+func (s *dapSession) continueRequest(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	s.d.Continue()
+	return map[string]any{"allThreadsContinued": true}, nil
+}
+
+func (s *dapSession) next(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	s.setReason("step")
+	s.d.Next()
+	return nil, nil
+}
+
+func (s *dapSession) stepIn(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	s.d.Step()
+	s.after = func() {
+		s.sendStopped(stopReason(s.d.statusBar.Label, "step"), s.d.statusBar.Label)
+	}
+	return nil, nil
+}
+
+func (s *dapSession) stepOut(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	if s.d.gameBoy.CPU.CallDepth() == 0 {
+		return nil, errors.New("no routine call recorded to step out of")
+	}
+	s.setReason("step")
+	s.d.StepOut()
+	return nil, nil
+}
+
+func (s *dapSession) stepBack(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	s.d.ReverseStep()
+	s.after = func() {
+		s.sendStopped("step", s.d.statusBar.Label)
+	}
+	return nil, nil
+}
+
+func (s *dapSession) reverseContinue(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	s.d.ReverseContinue()
+	s.after = func() {
+		s.sendStopped(stopReason(s.d.statusBar.Label, "pause"), s.d.statusBar.Label)
+	}
+	return nil, nil
+}
+
+func (s *dapSession) pause(json.RawMessage) (any, error) {
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+	if s.d.Running {
+		s.setReason("pause")
+		s.d.Stop()
+	} else {
+		s.after = func() {
+			s.sendStopped("pause", "")
+		}
+	}
+	return nil, nil
+}
+
+func (s *dapSession) setReason(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reason = reason
+}
+
+// stopReason returns the DAP reason of a stop from the debugger status
+func stopReason(status, fallback string) string {
+	switch {
+	case strings.HasPrefix(status, "Breakpoint"):
+		return "breakpoint"
+	case strings.HasPrefix(status, "Watchpoint"):
+		return "data breakpoint"
+	}
+	return fallback
+}
+
+// stopped notifies the client that the execution stopped (called by Stop)
+func (s *dapSession) stopped(status string) {
+	s.mu.Lock()
+	reason := s.reason
+	s.reason = ""
+	s.mu.Unlock()
+
+	if reason == "" {
+		reason = "pause"
+	}
+	s.sendStopped(stopReason(status, reason), status)
+}
+
+func (s *dapSession) sendStopped(reason, description string) {
+	s.conn.SendEvent("stopped", dap.StoppedEvent{
+		Reason:            reason,
+		Description:       description,
+		ThreadID:          dapThreadID,
+		AllThreadsStopped: true,
+	})
+}
+
+// continued notifies the client that the execution resumed (called by Continue)
+func (s *dapSession) continued() {
+	s.conn.SendEvent("continued", dap.ContinuedEvent{ThreadID: dapThreadID, AllThreadsContinued: true})
+}
@@ -0,0 +1,177 @@
+package debugger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/danielecanzoneri/lucky-boy/util/dap"
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+	"github.com/danielecanzoneri/lucky-boy/util/srcmap"
+)
+
+// sourceResolver locates the labels of the sources with the symbol file
+type sourceResolver struct {
+	d *Debugger
+}
+
+func (r sourceResolver) Label(name string) (disasm.Location, bool) {
+	if r.d.symbols == nil {
+		return disasm.Location{}, false
+	}
+	sym, ok := r.d.symbols.Lookup(name)
+	return disasm.Location{Bank: sym.Bank, Addr: sym.Addr}, ok
+}
+
+func (r sourceResolver) Read(loc disasm.Location) uint8 {
+	return r.d.gameBoy.Memory.Cartridge.ReadROMBank(loc.Bank, loc.Addr&0x3FFF)
+}
+
+// sourceMap returns the map of a source file, parsing it the first time
+func (s *dapSession) sourceMap(path string) (*srcmap.Map, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := s.sources[path]; ok {
+		return m, nil
+	}
+
+	m, err := srcmap.Load(path, sourceResolver{s.d})
+	if err != nil {
+		return nil, err
+	}
+	s.sources[path] = m
+	return m, nil
+}
+
+// dapListing is the disassembly of the whole ROM (as exported by ExportASM), sent
+// to the client as a source without a file. A new one is made when the code
+// analysis learns new code, with a new reference so that the client reloads it
+type dapListing struct {
+	ref     int
+	content string
+	m       *srcmap.Map
+
+	// Code not in the listing even after making a new one (e.g. overlapping instructions)
+	missing map[disasm.Location]bool
+}
+
+// updateListing disassembles the ROM again
+func (s *dapSession) updateListing() error {
+	if s.d.analysis == nil {
+		return errors.New("no ROM loaded")
+	}
+
+	var buf bytes.Buffer
+	if err := s.d.analysis.WriteASM(&buf, s.d.asmLabels()); err != nil {
+		return err
+	}
+	m, err := srcmap.Parse(bytes.NewReader(buf.Bytes()), sourceResolver{s.d})
+	if err != nil {
+		return err
+	}
+
+	ref := 1
+	if s.listing != nil {
+		ref = s.listing.ref + 1
+	}
+	s.listing = &dapListing{ref: ref, content: buf.String(), m: m, missing: make(map[disasm.Location]bool)}
+	return nil
+}
+
+// sourceLine returns the source and the line of the instruction at ba:
+// the sources of the client or, if they do not have it, the ROM disassembly
+func (s *dapSession) sourceLine(ba bankAddress) (*dap.Source, int, bool) {
+	if ba.addr >= 0x8000 {
+		return nil, 0, false
+	}
+	loc := disasm.Location{Bank: ba.bank, Addr: ba.addr}
+
+	for path, m := range s.sources {
+		if line, ok := m.Line(loc); ok {
+			return &dap.Source{Name: filepath.Base(path), Path: path}, line, true
+		}
+	}
+
+	// Code found after the disassembly was made is written there as data
+	if s.listing == nil || s.listing.outdated(loc, s.d.analysis) {
+		if err := s.updateListing(); err != nil {
+			return nil, 0, false
+		}
+	}
+	line, ok := s.listing.m.Line(loc)
+	if !ok {
+		s.listing.missing[loc] = true
+		return nil, 0, false
+	}
+	name := filepath.Base(s.d.romPath)
+	name = name[:len(name)-len(filepath.Ext(name))] + ".asm"
+	return &dap.Source{Name: name, SourceReference: s.listing.ref}, line, true
+}
+
+// outdated returns true if the code at loc was found after the listing was made
+func (l *dapListing) outdated(loc disasm.Location, analysis *disasm.Analysis) bool {
+	if _, ok := l.m.Line(loc); ok || l.missing[loc] {
+		return false
+	}
+	return analysis.IsCode(loc)
+}
+
+// source sends the content of the ROM disassembly
+func (s *dapSession) source(raw json.RawMessage) (any, error) {
+	var args struct {
+		SourceReference int `json:"sourceReference"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	if s.listing == nil || s.listing.ref != args.SourceReference {
+		return nil, fmt.Errorf("unknown source %d", args.SourceReference)
+	}
+	return map[string]any{"content": s.listing.content, "mimeType": "text/x-asm"}, nil
+}
+
+// stackTrace returns the call stack (see callFrames)
+func (s *dapSession) stackTrace(raw json.RawMessage) (any, error) {
+	var args struct {
+		StartFrame int `json:"startFrame"`
+		Levels     int `json:"levels"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttached(); err != nil {
+		return nil, err
+	}
+
+	calls := s.d.callFrames()
+	start := min(max(args.StartFrame, 0), len(calls))
+	end := len(calls)
+	if args.Levels > 0 {
+		end = min(start+args.Levels, end)
+	}
+
+	frames := make([]dap.StackFrame, 0, end-start)
+	for i := start; i < end; i++ {
+		frames = append(frames, s.stackFrame(i, calls[i]))
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(calls)}, nil
+}
+
+// stackFrame describes the code at ba, named after the label before it
+func (s *dapSession) stackFrame(id int, ba bankAddress) dap.StackFrame {
+	frame := dap.StackFrame{
+		ID:                          id,
+		Name:                        s.d.addressName(ba),
+		InstructionPointerReference: fmt.Sprintf("0x%04X", ba.addr),
+	}
+	if source, line, ok := s.sourceLine(ba); ok {
+		frame.Source, frame.Line, frame.Column = source, line, 1
+	} else {
+		frame.PresentationHint = "subtle"
+	}
+	return frame
+}
@@ -0,0 +1,190 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/util/dap"
+	"github.com/danielecanzoneri/lucky-boy/util/expr"
+)
+
+// Variables references of the scopes
+const (
+	registersReference = iota + 1
+	ioReference
+	wramReference
+	wramMemoryReference
+)
+
+// CPU registers and flags (names of debugEnv.Variable)
+var (
+	registers8  = []string{"A", "F", "B", "C", "D", "E", "H", "L"}
+	registers16 = []string{"AF", "BC", "DE", "HL", "SP", "PC"}
+	flags       = []string{"ZF", "NF", "HF", "CF", "IME"}
+)
+
+// ioRegisters are the I/O registers shown by the editors
+var ioRegisters = []struct {
+	addr uint16
+	name string
+}{
+	{0xFF00, "P1"}, {0xFF01, "SB"}, {0xFF02, "SC"},
+	{0xFF04, "DIV"}, {0xFF05, "TIMA"}, {0xFF06, "TMA"}, {0xFF07, "TAC"}, {0xFF0F, "IF"},
+	{0xFF10, "NR10"}, {0xFF11, "NR11"}, {0xFF12, "NR12"}, {0xFF13, "NR13"}, {0xFF14, "NR14"},
+	{0xFF16, "NR21"}, {0xFF17, "NR22"}, {0xFF18, "NR23"}, {0xFF19, "NR24"},
+	{0xFF1A, "NR30"}, {0xFF1B, "NR31"}, {0xFF1C, "NR32"}, {0xFF1D, "NR33"}, {0xFF1E, "NR34"},
+	{0xFF20, "NR41"}, {0xFF21, "NR42"}, {0xFF22, "NR43"}, {0xFF23, "NR44"},
+	{0xFF24, "NR50"}, {0xFF25, "NR51"}, {0xFF26, "NR52"},
+	{0xFF40, "LCDC"}, {0xFF41, "STAT"}, {0xFF42, "SCY"}, {0xFF43, "SCX"}, {0xFF44, "LY"}, {0xFF45, "LYC"},
+	{0xFF46, "DMA"}, {0xFF47, "BGP"}, {0xFF48, "OBP0"}, {0xFF49, "OBP1"}, {0xFF4A, "WY"}, {0xFF4B, "WX"},
+	{0xFF4D, "KEY1"}, {0xFF4F, "VBK"},
+	{0xFF51, "HDMA1"}, {0xFF52, "HDMA2"}, {0xFF53, "HDMA3"}, {0xFF54, "HDMA4"}, {0xFF55, "HDMA5"},
+	{0xFF68, "BCPS"}, {0xFF69, "BCPD"}, {0xFF6A, "OCPS"}, {0xFF6B, "OCPD"}, {0xFF70, "SVBK"},
+	{0xFFFF, "IE"},
+}
+
+// ioRegisterName returns the name of an I/O register (its address if it has none)
+func ioRegisterName(addr uint16) string {
+	for _, reg := range ioRegisters {
+		if reg.addr == addr {
+			return reg.name
+		}
+	}
+	return fmt.Sprintf("$%04X", addr)
+}
+
+func (s *dapSession) scopes(json.RawMessage) (any, error) {
+	return map[string]any{"scopes": []dap.Scope{
+		{Name: "CPU Registers", VariablesReference: registersReference},
+		{Name: "I/O Registers", VariablesReference: ioReference},
+		{Name: "WRAM", VariablesReference: wramReference},
+	}}, nil
+}
+
+func (s *dapSession) variables(raw json.RawMessage) (any, error) {
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	var vars []dap.Variable
+	switch args.VariablesReference {
+	case registersReference:
+		vars = s.registerVariables()
+	case ioReference:
+		for _, reg := range ioRegisters {
+			vars = append(vars, dap.Variable{
+				Name:  fmt.Sprintf("%s ($%04X)", reg.name, reg.addr),
+				Value: fmt.Sprintf("$%02X", s.d.gameBoy.Memory.DebugRead(reg.addr)),
+			})
+		}
+	case wramReference:
+		vars = s.wramVariables()
+	case wramMemoryReference:
+		for addr := 0xC000; addr < 0xE000; addr += 0x10 {
+			vars = append(vars, dap.Variable{
+				Name:  s.d.wramAddress(uint16(addr)).String(),
+				Value: s.d.hexDump(uint16(addr), 0x10),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+	return map[string]any{"variables": vars}, nil
+}
+
+func (s *dapSession) registerVariables() []dap.Variable {
+	env := debugEnv{s.d}
+
+	var vars []dap.Variable
+	for _, name := range registers8 {
+		v, _ := env.Variable(name)
+		vars = append(vars, dap.Variable{Name: name, Value: fmt.Sprintf("$%02X", v)})
+	}
+	for _, name := range registers16 {
+		v, _ := env.Variable(name)
+		vars = append(vars, dap.Variable{Name: name, Value: fmt.Sprintf("$%04X", v)})
+	}
+	for _, name := range flags {
+		v, _ := env.Variable(name)
+		vars = append(vars, dap.Variable{Name: name, Value: fmt.Sprint(v)})
+	}
+	return vars
+}
+
+// wramVariables returns the labels of the WRAM mapped (from the symbol file) and its content
+func (s *dapSession) wramVariables() []dap.Variable {
+	var vars []dap.Variable
+	if s.d.symbols != nil {
+		for _, start := range []uint16{0xC000, 0xD000} {
+			ba := s.d.wramAddress(start)
+			for _, sym := range s.d.symbols.InRange(ba.bank, start, start+0x1000) {
+				vars = append(vars, dap.Variable{
+					Name:  sym.Name,
+					Value: fmt.Sprintf("$%02X", s.d.gameBoy.Memory.DebugRead(sym.Addr)),
+				})
+			}
+		}
+	}
+	return append(vars, dap.Variable{
+		Name:               "Memory",
+		Value:              "$C000-$DFFF",
+		VariablesReference: wramMemoryReference,
+	})
+}
+
+// wramAddress qualifies a WRAM address with its bank (0 for C000-CFFF, the one mapped for D000-DFFF)
+func (d *Debugger) wramAddress(addr uint16) bankAddress {
+	if addr < 0xD000 {
+		return bankAddress{bank: 0, addr: addr}
+	}
+	return currentBankAddress(d.gameBoy, addr)
+}
+
+// hexDump returns n bytes from addr as hexadecimal
+func (d *Debugger) hexDump(addr uint16, n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("%02X", d.gameBoy.Memory.DebugRead(addr+uint16(i)))
+	}
+	return strings.Join(values, " ")
+}
+
+// evaluate evaluates a debugger expression (see util/expr). Labels in RAM,
+// as in hovers over variables, are evaluated to the byte at their address
+func (s *dapSession) evaluate(raw json.RawMessage) (any, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(args.Expression)
+	if s.d.symbols != nil {
+		if sym, ok := s.d.symbols.Lookup(name); ok && sym.Addr >= 0x8000 {
+			v := s.d.gameBoy.Memory.DebugRead(sym.Addr)
+			return evaluateResult(fmt.Sprintf("$%02X (%d) at $%04X", v, v, sym.Addr)), nil
+		}
+	}
+
+	e, err := expr.Parse(args.Expression)
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.Eval(debugEnv{s.d})
+	if err != nil {
+		return nil, err
+	}
+	if v < 0 {
+		return evaluateResult(fmt.Sprint(v)), nil
+	}
+	return evaluateResult(fmt.Sprintf("$%X (%d)", v, v)), nil
+}
+
+func evaluateResult(result string) map[string]any {
+	return map[string]any{"result": result, "variablesReference": 0}
+}
@@ -1,8 +1,14 @@
 package debugger
 
 import (
+	"sync/atomic"
+
 	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/profiler"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
 	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+	"github.com/danielecanzoneri/lucky-boy/util/symbols"
 	"github.com/ebitenui/ebitenui"
 	"github.com/ebitenui/ebitenui/image"
 	"github.com/ebitenui/ebitenui/widget"
@@ -19,18 +25,50 @@ type Debugger struct {
 	memoryViewer    *memoryViewer
 	registersViewer *registersViewer
 
-	oamViewer   *oamViewer
-	bgViewer    *bgViewer
-	tilesViewer *tilesViewer
+	oamViewer      *oamViewer
+	bgViewer       *bgViewer
+	tilesViewer    *tilesViewer
+	timelineViewer *timelineViewer
+
+	breakpointsViewer *breakpointsViewer
+	callStackViewer   *callStackViewer
+	profilerViewer    *profilerViewer
+	watchpointsViewer *watchpointsViewer
+	cheatsViewer      *cheatsViewer
+	cheatFinderViewer *cheatFinderViewer
+
+	// Shows why execution stopped
+	statusBar *widget.Text
 
 	// State
 	gameBoy *gameboy.GameBoy
 	Active  bool
 	Running bool // True when debugger is active and we are stepping until breakpoint
 
-	// Run until next instruction
-	NextInstruction bool
-	CallDepth       int
+	// Run until the call stack is back to stepDepth frames (Next and Step Out)
+	stepping  bool
+	stepDepth int
+
+	// Labels loaded from the symbol file (nil if there is none)
+	symbols *symbols.Table
+
+	// Code/data separation of the loaded ROM
+	romPath  string
+	analysis *disasm.Analysis
+
+	// Hardware events of the last frames (recorded while debugging)
+	timeline *timeline.Recorder
+
+	// Cycles spent by the CPU (counted when started from the profiler window)
+	profiler *profiler.Profiler
+
+	// Memory watchpoints and last one hit
+	watchpoints   []*watchpoint
+	watchpointHit *watchpointHit
+
+	// Requests of the DAP client (handled in HandleRequests) and its session
+	requests chan func()
+	dap      atomic.Pointer[dapSession]
 }
 
 func New(gb *gameboy.GameBoy) *Debugger {
@@ -46,8 +84,10 @@ func New(gb *gameboy.GameBoy) *Debugger {
 	)
 
 	d := &Debugger{
-		UI:      &ebitenui.UI{Container: root},
-		gameBoy: gb,
+		UI:       &ebitenui.UI{Container: root},
+		gameBoy:  gb,
+		timeline: timeline.NewRecorder(timelineFrames),
+		profiler: profiler.New(profilerFrames),
 	}
 
 	// Create widgets
@@ -60,6 +100,17 @@ func New(gb *gameboy.GameBoy) *Debugger {
 	d.oamViewer = d.newOamViewer()
 	d.bgViewer = d.newBGViewer()
 	d.tilesViewer = d.newTilesViewer()
+	d.timelineViewer = d.newTimelineViewer()
+	d.breakpointsViewer = d.newBreakpointsViewer()
+	d.callStackViewer = d.newCallStackViewer()
+	d.profilerViewer = d.newProfilerViewer()
+	d.watchpointsViewer = d.newWatchpointsViewer()
+	d.cheatsViewer = d.newCheatsViewer()
+	d.cheatFinderViewer = d.newCheatFinderViewer()
+	d.statusBar = widget.NewText(
+		widget.TextOpts.Text("", &font, theme.Debugger.HeaderColor),
+		widget.TextOpts.Padding(theme.Debugger.Insets),
+	)
 
 	// Add widgets to the root container
 	registersContainer := widget.NewContainer(
@@ -75,15 +126,32 @@ func New(gb *gameboy.GameBoy) *Debugger {
 	)
 	registersContainer.AddChild(d.registersViewer)
 
+	// Addresses can be qualified by bank (BB:AAAA) or searched by label
+	disassemblerGoTo := newTextInput("Go to BB:AAAA or label", 0, func(text string) {
+		if ba, hasBank, ok := d.searchAddress(text); ok {
+			d.disassembler.goTo(ba, hasBank)
+		}
+	})
+	memoryGoTo := newTextInput(`Go to BB:AAAA or label, edit with ADDR = 3E 05 or ADDR = "TEXT"`, 0, d.memoryCommand)
+	memorySearch := newTextInput(`Search bytes (3E 05) or "TEXT"`, 0, d.memorySearch)
+
+	// Memory viewer regions
+	memoryViews := newContainer(widget.DirectionHorizontal)
+	for view, name := range memoryViewNames {
+		memoryViews.AddChild(newButton(name, func() {
+			d.memoryViewer.setView(memoryView(view))
+		}))
+	}
+
 	main := newContainer(widget.DirectionHorizontal,
 		newContainer(widget.DirectionVertical,
-			d.screen, d.disassembler,
+			d.screen, disassemblerGoTo, d.disassembler,
 		),
 		newContainer(widget.DirectionVertical,
-			registersContainer, d.memoryViewer,
+			registersContainer, memoryViews, memoryGoTo, memorySearch, d.memoryViewer,
 		),
 	)
-	root.AddChild(d.toolbar, main)
+	root.AddChild(d.toolbar, main, d.statusBar)
 	return d
 }
 
@@ -92,10 +160,41 @@ func (d *Debugger) Sync() {
 	d.disassembler.Sync(d.gameBoy)
 	d.memoryViewer.Sync(d.gameBoy)
 	d.registersViewer.Sync(d.gameBoy)
+
+	// Update hit counts
+	if d.UI.IsWindowOpen(d.breakpointsViewer.Window()) {
+		d.breakpointsViewer.Sync(d.gameBoy)
+	}
+	if d.UI.IsWindowOpen(d.callStackViewer.Window()) {
+		d.callStackViewer.Sync(d.gameBoy)
+	}
+	if d.UI.IsWindowOpen(d.timelineViewer.Window()) {
+		d.timelineViewer.Sync(d.gameBoy)
+	}
+	if d.UI.IsWindowOpen(d.profilerViewer.Window()) {
+		d.profilerViewer.Sync(d.gameBoy)
+	}
+}
+
+// SetStatus shows a message in the status bar
+func (d *Debugger) SetStatus(msg string) {
+	d.statusBar.Label = msg
+}
+
+// TextInputFocused returns true if the user is typing in a text input
+func (d *Debugger) TextInputFocused() bool {
+	_, ok := d.UI.GetFocusedWidget().(*widget.TextInput)
+	return ok
 }
 
 func (d *Debugger) Update() error {
 	d.registersViewer.Sync(d.gameBoy)
+	if d.Running && d.UI.IsWindowOpen(d.timelineViewer.Window()) {
+		d.timelineViewer.Sync(d.gameBoy)
+	}
+	if d.Running && d.UI.IsWindowOpen(d.profilerViewer.Window()) {
+		d.profilerViewer.refresh()
+	}
 	d.UI.Update()
 	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"image/color"
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+	"github.com/danielecanzoneri/lucky-boy/util/symbols"
 	"github.com/ebitenui/ebitenui/image"
 	"github.com/ebitenui/ebitenui/widget"
 )
@@ -41,14 +43,17 @@ var (
 
 // disassemblyEntry represents a single line in the disassembler
 type disassemblerEntry struct {
-	address uint16
+	address bankAddress
 	name    string
 	bytes   []uint8
+	label   bool // Label line (name is the label)
 }
 
 type disassembler struct {
 	*widget.Container
 
+	gb *gameboy.GameBoy
+
 	slider *widget.Slider
 
 	entries      []*disassemblerEntry
@@ -57,43 +62,107 @@ type disassembler struct {
 
 	// Address of entry to highlight (-1 if no entry has to be highlighted)
 	currentInstruction int
+	currentBank        uint
+
+	// ROM bank shown at 4000-7FFF
+	romBank uint
+
+	// Labels shown in the disassembly (can be nil)
+	symbols *symbols.Table
+
+	// Code/data separation of the ROM (can be nil)
+	analysis *disasm.Analysis
 
 	// Entries to show
 	first  int
 	length int
 
 	// Map with all breakpoints
-	breakpoints map[uint16]struct{}
+	breakpoints map[bankAddress]*breakpoint
 }
 
 // Sync scans through memory and marks which addresses contain
 // executable code vs data bytes that are part of multibyte instructions.
 // This is used to properly display the disassembly view.
 func (d *disassembler) Sync(gb *gameboy.GameBoy) {
+	d.gb = gb
 	d.currentInstruction = int(gb.CPU.PC)
+	d.currentBank = gb.Memory.DebugBank(gb.CPU.PC)
 
-	counter := 0
-	var scrollTo int
+	// Current instruction is always code
+	if d.analysis != nil {
+		d.analysis.Learn(disasm.Location{Bank: d.currentBank, Addr: gb.CPU.PC})
+	}
+
+	// Show the ROM bank currently mapped
+	d.romBank = gb.Memory.DebugBank(0x4000)
+	d.disassemble()
+
+	// Selected instruction always at center
+	d.scrollToAddress(gb.CPU.PC)
+}
 
+// bankAt returns the bank disassembled at addr
+func (d *disassembler) bankAt(addr uint16) uint {
+	if 0x4000 <= addr && addr < 0x8000 {
+		return d.romBank
+	}
+	return d.gb.Memory.DebugBank(addr)
+}
+
+// disassemble decodes the whole address space, using romBank for the switchable ROM bank.
+// ROM bytes that the analysis does not mark as code are shown as data
+func (d *disassembler) disassemble() {
+	read := func(addr uint16) uint8 {
+		return d.gb.Memory.DebugReadBank(d.bankAt(addr), addr)
+	}
+	var label func(uint16) (string, bool)
+	if d.symbols != nil {
+		label = func(addr uint16) (string, bool) {
+			return d.symbols.Label(d.bankAt(addr), addr)
+		}
+	}
+
+	counter := 0
 	for addr := 0; addr < 0x10000; {
-		if 0x104 <= addr && addr < 0x150 { // Header memory
-			d.entries[counter].name = "Cart Header"
-			d.entries[counter].address = uint16(addr)
-			d.entries[counter].bytes = []uint8{gb.Memory.DebugRead(uint16(addr))}
+		address := bankAddress{bank: d.bankAt(uint16(addr)), addr: uint16(addr)}
+
+		// Label lines
+		if d.symbols != nil {
+			for _, name := range d.symbols.At(address.bank, address.addr) {
+				entry := d.entry(counter)
+				entry.name = name + ":"
+				entry.address = address
+				entry.bytes = nil
+				entry.label = true
+				counter++
+			}
+		}
+
+		isData := 0x104 <= addr && addr < 0x150 // Header memory
+		if d.analysis != nil && addr < 0x8000 {
+			isData = !d.analysis.IsCode(disasm.Location{Bank: address.bank, Addr: address.addr})
+		}
+		if isData {
+			entry := d.entry(counter)
+			entry.name = fmt.Sprintf("DB $%02X", read(uint16(addr)))
+			if 0x104 <= addr && addr < 0x150 {
+				entry.name = "Cart Header"
+			}
+			entry.address = address
+			entry.bytes = []uint8{read(uint16(addr))}
+			entry.label = false
 			counter++
 			addr++
 			continue
 		}
 
-		if addr == d.currentInstruction {
-			// Entry to be highlighted
-			scrollTo = counter
-		}
-
-		name, length, b := getOpcodeInfo(gb, uint16(addr))
-		d.entries[counter].name = name
-		d.entries[counter].address = uint16(addr)
-		d.entries[counter].bytes = b
+		name, length, b := getOpcodeInfo(read, label, uint16(addr))
+		entry := d.entry(counter)
+		entry.name = name
+		entry.address = address
+		entry.bytes = b
+		entry.label = false
 		counter++
 
 		addr += length
@@ -102,13 +171,38 @@ func (d *disassembler) Sync(gb *gameboy.GameBoy) {
 	// Update number of entries
 	d.totalEntries = counter
 	d.slider.Max = counter - d.length
+}
 
-	// Scroll to correct offset
-	scrollTo -= d.length / 2 // Selected instruction always at center
-	d.scrollTo(scrollTo)
-	d.slider.Current = scrollTo
+// entry returns the i-th entry, allocating it if needed (label lines can exceed 0x10000 entries)
+func (d *disassembler) entry(i int) *disassemblerEntry {
+	for i >= len(d.entries) {
+		d.entries = append(d.entries, &disassemblerEntry{})
+	}
+	return d.entries[i]
+}
 
-	d.refresh()
+// scrollToAddress scrolls so that the entry at addr is at the center
+func (d *disassembler) scrollToAddress(addr uint16) {
+	entry := 0
+	for entry < d.totalEntries-1 && d.entries[entry+1].address.addr <= addr {
+		entry++
+	}
+
+	d.scrollTo(entry - d.length/2)
+}
+
+// goTo shows the address (in the bank currently shown if hasBank is false)
+func (d *disassembler) goTo(ba bankAddress, hasBank bool) {
+	if d.gb == nil {
+		return
+	}
+
+	if hasBank && 0x4000 <= ba.addr && ba.addr < 0x8000 {
+		d.romBank = ba.bank
+		d.disassemble()
+	}
+
+	d.scrollToAddress(ba.addr)
 }
 
 func newDisassembler() *disassembler {
@@ -116,14 +210,14 @@ func newDisassembler() *disassembler {
 		entries:      make([]*disassemblerEntry, 0x10000),
 		totalEntries: 0x10000,
 		length:       numRows,
-		breakpoints:  make(map[uint16]struct{}),
+		breakpoints:  make(map[bankAddress]*breakpoint),
 	}
 	dis.rowsWidget = make([]widget.PreferredSizeLocateableWidget, dis.length)
 
 	// Initialize the disassembler with dummy data
 	for i := range 0x10000 {
 		dis.entries[i] = &disassemblerEntry{
-			address: uint16(i),
+			address: bankAddress{addr: uint16(i)},
 			name:    fmt.Sprintf("%04X    NOP    ; No operation", i),
 		}
 	}
@@ -197,15 +291,15 @@ func newDisassembler() *disassembler {
 	return dis
 }
 
-func (d *disassembler) ToggleBreakpoint(addr uint16) {
+func (d *disassembler) ToggleBreakpoint(addr bankAddress) {
 	if d.IsBreakpoint(addr) {
 		delete(d.breakpoints, addr)
 	} else {
-		d.breakpoints[addr] = struct{}{}
+		d.breakpoints[addr] = &breakpoint{address: addr}
 	}
 }
 
-func (d *disassembler) IsBreakpoint(addr uint16) bool {
+func (d *disassembler) IsBreakpoint(addr bankAddress) bool {
 	_, ok := d.breakpoints[addr]
 	return ok
 }
@@ -247,6 +341,13 @@ func (d *disassembler) refreshEntry(entryId int) {
 	entry := d.entries[d.first+entryId]
 	button := d.rowsWidget[entryId].(*widget.Button)
 
+	// Label line
+	if entry.label {
+		button.SetText(fmt.Sprintf("%s: %s", entry.address, entry.name))
+		button.SetImage(entryImage)
+		return
+	}
+
 	// Update label
 	bytesStr := ""
 	for _, b := range entry.bytes {
@@ -257,10 +358,10 @@ func (d *disassembler) refreshEntry(entryId int) {
 	for len(bytesStr) < 9 { // 3 chars per byte, up to 3 bytes
 		bytesStr += "   "
 	}
-	button.SetText(fmt.Sprintf("%04X: %s  %s", entry.address, bytesStr, entry.name))
+	button.SetText(fmt.Sprintf("%s: %s  %s", entry.address, bytesStr, entry.name))
 
 	// Update color
-	isCurr := int(entry.address) == d.currentInstruction
+	isCurr := int(entry.address.addr) == d.currentInstruction && entry.address.bank == d.currentBank
 	isBreakpoint := d.IsBreakpoint(entry.address)
 
 	if isCurr {
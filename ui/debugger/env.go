@@ -0,0 +1,90 @@
+package debugger
+
+import (
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/util"
+)
+
+// debugEnv evaluates debugger expressions (see util/expr) on the emulator state
+type debugEnv struct {
+	d *Debugger
+}
+
+// Variable resolves CPU registers (A, HL, ...), flags (ZF, NF, HF, CF), IME, PPU state
+// (LY, LYC, MODE), frame counter (FRAME) and mapped banks (BANK, WBANK)
+func (env debugEnv) Variable(name string) (int, bool) {
+	gb := env.d.gameBoy
+	cpu := gb.CPU
+
+	var v int
+	switch strings.ToUpper(name) {
+	case "A":
+		v = int(cpu.A)
+	case "F":
+		v = int(cpu.F)
+	case "B":
+		v = int(cpu.B)
+	case "C":
+		v = int(cpu.C)
+	case "D":
+		v = int(cpu.D)
+	case "E":
+		v = int(cpu.E)
+	case "H":
+		v = int(cpu.H)
+	case "L":
+		v = int(cpu.L)
+	case "AF":
+		v = int(util.CombineBytes(cpu.A, cpu.F))
+	case "BC":
+		v = int(util.CombineBytes(cpu.B, cpu.C))
+	case "DE":
+		v = int(util.CombineBytes(cpu.D, cpu.E))
+	case "HL":
+		v = int(util.CombineBytes(cpu.H, cpu.L))
+	case "SP":
+		v = int(cpu.SP)
+	case "PC":
+		v = int(cpu.PC)
+	case "ZF":
+		v = int(util.ReadBit(cpu.F, 7))
+	case "NF":
+		v = int(util.ReadBit(cpu.F, 6))
+	case "HF":
+		v = int(util.ReadBit(cpu.F, 5))
+	case "CF":
+		v = int(util.ReadBit(cpu.F, 4))
+	case "IME":
+		if cpu.IME {
+			v = 1
+		}
+	case "LY":
+		v = int(gb.PPU.LY)
+	case "LYC":
+		v = int(gb.PPU.LYC)
+	case "MODE":
+		v = int(gb.PPU.DebugGetMode())
+	case "FRAME":
+		v = int(gb.PPU.DebugGetFrameCount())
+	case "BANK":
+		v = int(gb.Memory.DebugBank(0x4000))
+	case "WBANK":
+		v = int(gb.Memory.DebugBank(0xD000))
+	default:
+		return 0, false
+	}
+	return v, true
+}
+
+func (env debugEnv) Symbol(name string) (int, bool) {
+	if env.d.symbols == nil {
+		return 0, false
+	}
+	sym, ok := env.d.symbols.Lookup(name)
+	return int(sym.Addr), ok
+}
+
+func (env debugEnv) Read(addr uint16) uint8 {
+	return env.d.gameBoy.Memory.DebugRead(addr)
+}
@@ -0,0 +1,135 @@
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseBytes parses hexadecimal bytes separated by spaces (e.g. "3E 05")
+// or a quoted ASCII string (e.g. "\"HELLO\"")
+func parseBytes(s string) ([]uint8, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, `"`) {
+		str, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return []uint8(str), nil
+	}
+
+	var data []uint8
+	for _, field := range strings.Fields(s) {
+		v, err := parseHex(field, 8)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, uint8(v))
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no bytes specified")
+	}
+	return data, nil
+}
+
+// memoryCommand goes to an address ("ADDR") or writes bytes starting from it
+// ("ADDR = 3E 05" or "ADDR = \"TEXT\""). In the ROM view ADDR can also be an offset
+func (d *Debugger) memoryCommand(cmd string) {
+	target, value, isWrite := strings.Cut(cmd, "=")
+
+	offset, ok := d.memoryOffset(target)
+	if !ok {
+		return
+	}
+	mv := d.memoryViewer
+
+	if isWrite {
+		data, err := parseBytes(value)
+		if err != nil {
+			d.SetStatus(err.Error())
+			return
+		}
+		if offset+len(data) > mv.size() {
+			d.SetStatus("Write goes past the end of " + memoryViewNames[mv.view])
+			return
+		}
+
+		for i, v := range data {
+			mv.write(offset+i, v)
+		}
+		d.SetStatus(fmt.Sprintf("Wrote %d bytes at %s", len(data), mv.rowName(offset)))
+
+		// Code and data shown elsewhere may have changed
+		defer d.Sync()
+	}
+
+	mv.goToOffset(offset)
+}
+
+// memoryOffset resolves an address or label (or an offset in the ROM view) to an offset in the memory view
+func (d *Debugger) memoryOffset(s string) (int, bool) {
+	mv := d.memoryViewer
+
+	s = strings.TrimSpace(s)
+	if mv.view == viewROM && !strings.Contains(s, ":") {
+		if offset, err := parseHex(s, 32); err == nil {
+			return int(offset), true
+		}
+	}
+
+	ba, hasBank, ok := d.searchAddress(s)
+	if !ok {
+		return 0, false
+	}
+	if mv.view == viewCPU {
+		mv.selectBank(ba, hasBank)
+	}
+	offset, ok := mv.offset(ba, hasBank)
+	if !ok {
+		d.SetStatus(fmt.Sprintf("%s is not in %s", ba, memoryViewNames[mv.view]))
+	}
+	return offset, ok
+}
+
+// memorySearch looks for a byte pattern or a string (see parseBytes) in the memory view,
+// starting after the last match (or from the first row shown) and wrapping around
+func (d *Debugger) memorySearch(s string) {
+	pattern, err := parseBytes(s)
+	if err != nil {
+		d.SetStatus(err.Error())
+		return
+	}
+
+	mv := d.memoryViewer
+	size := mv.size()
+	if len(pattern) > size {
+		d.SetStatus("Not found")
+		return
+	}
+
+	data := make([]uint8, size)
+	for i := range data {
+		data[i] = mv.read(i)
+	}
+
+	start := mv.first * 16
+	if mv.lastMatch >= 0 {
+		start = mv.lastMatch + 1
+	}
+	start = min(start, size)
+
+	i := bytes.Index(data[start:], pattern)
+	if i >= 0 {
+		i += start
+	} else if i = bytes.Index(data[:min(start+len(pattern)-1, size)], pattern); i < 0 {
+		mv.lastMatch = -1
+		d.SetStatus("Not found")
+		return
+	}
+
+	mv.lastMatch = i
+	mv.goToOffset(i)
+	d.SetStatus("Found at " + mv.rowName(i))
+}
@@ -6,44 +6,77 @@ import (
 	"image/color"
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/mmu"
+	"github.com/danielecanzoneri/lucky-boy/util/symbols"
 	"github.com/ebitenui/ebitenui/image"
 	"github.com/ebitenui/ebitenui/widget"
 	"golang.org/x/image/colornames"
 )
 
-// disassemblyEntry represents a single line in the disassembler
+// memoryView selects what the memory viewer shows
+type memoryView int
+
+const (
+	viewCPU  memoryView = iota // 0000-FFFF as seen by the CPU
+	viewROM                    // Whole ROM by offset
+	viewVRAM                   // All vRAM banks
+	viewWRAM                   // All wRAM banks
+	viewSRAM                   // All external RAM banks
+)
+
+var memoryViewNames = [...]string{"CPU", "ROM", "VRAM", "WRAM", "SRAM"}
+
+// memoryRegions maps the views to the MMU regions
+var memoryRegions = [...]mmu.Region{
+	viewROM:  mmu.RegionROM,
+	viewVRAM: mmu.RegionVRAM,
+	viewWRAM: mmu.RegionWRAM,
+	viewSRAM: mmu.RegionSRAM,
+}
+
+// memoryRow is a single line of the memory viewer
 type memoryRow struct {
-	baseAddress uint16
-	data        [16]uint8
+	offset int
+	data   [16]uint8
 }
 
 type memoryViewer struct {
 	*widget.Container
 
+	gb *gameboy.GameBoy
+
+	// Labels shown next to the rows (can be nil)
+	symbols *symbols.Table
+
+	view memoryView
+
+	// Banks shown at 4000-7FFF and D000-DFFF in the CPU view (-1 to show the one currently mapped)
+	romBank  int
+	wRAMBank int
+
+	// Offset of the last search match (-1 if none)
+	lastMatch int
+
 	slider *widget.Slider
 
-	entries    []*memoryRow
+	rows       []memoryRow
 	rowsWidget []widget.PreferredSizeLocateableWidget
 
-	// Entries to show
+	// Rows to show
 	first  int
 	length int
 }
 
 func newMemoryViewer() *memoryViewer {
 	mv := &memoryViewer{
-		entries: make([]*memoryRow, 0x10000/16),
-		length:  16,
+		length:    16,
+		romBank:   -1,
+		wRAMBank:  -1,
+		lastMatch: -1,
 	}
+	mv.rows = make([]memoryRow, mv.length)
 	mv.rowsWidget = make([]widget.PreferredSizeLocateableWidget, mv.length)
 
-	// Initialize the rows
-	for i := range mv.entries {
-		mv.entries[i] = &memoryRow{
-			baseAddress: uint16(i * 16),
-		}
-	}
-
 	entryList := widget.NewContainer(
 		widget.ContainerOpts.Layout(widget.NewRowLayout(
 			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
@@ -64,7 +97,7 @@ func newMemoryViewer() *memoryViewer {
 		}, theme.Debugger.Button.Image),
 		widget.SliderOpts.MinHandleSize(15), // Width of handle
 		widget.SliderOpts.Orientation(widget.DirectionVertical),
-		widget.SliderOpts.MinMax(0, len(mv.entries)-mv.length),
+		widget.SliderOpts.MinMax(0, 0x10000/16-mv.length),
 		widget.SliderOpts.PageSizeFunc(func() int { return mv.length / 2 }),
 		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
 			mv.scrollTo(args.Slider.Current)
@@ -113,17 +146,167 @@ func newMemoryViewer() *memoryViewer {
 
 // Sync data from memory
 func (mv *memoryViewer) Sync(gb *gameboy.GameBoy) {
-	for _, entry := range mv.entries {
-		for i := range 16 {
-			entry.data[i] = gb.Memory.DebugRead(entry.baseAddress + uint16(i))
+	mv.gb = gb
+
+	// The size of the regions depends on the cartridge
+	mv.slider.Max = max(mv.rowCount()-mv.length, 0)
+	mv.scrollTo(mv.first)
+}
+
+// load reads the rows shown
+func (mv *memoryViewer) load() {
+	if mv.gb == nil {
+		return
+	}
+
+	for i := range mv.rows {
+		row := &mv.rows[i]
+		row.offset = (mv.first + i) * 16
+		for j := range row.data {
+			row.data[j] = mv.read(row.offset + j)
 		}
 	}
+}
 
-	mv.refresh()
+// size returns the number of bytes of the view
+func (mv *memoryViewer) size() int {
+	if mv.view == viewCPU {
+		return 0x10000
+	}
+	return mv.gb.Memory.DebugRegionSize(memoryRegions[mv.view])
+}
+
+func (mv *memoryViewer) rowCount() int {
+	return (mv.size() + 15) / 16
+}
+
+// read returns the byte at offset in the view (FF outside it)
+func (mv *memoryViewer) read(offset int) uint8 {
+	if mv.view == viewCPU {
+		if offset >= 0x10000 {
+			return 0xFF
+		}
+		addr := uint16(offset)
+		return mv.gb.Memory.DebugReadBank(mv.bankAt(addr), addr)
+	}
+	return mv.gb.Memory.DebugReadRegion(memoryRegions[mv.view], uint(offset))
+}
+
+// write changes the byte at offset in the view, without side effects
+func (mv *memoryViewer) write(offset int, v uint8) {
+	if mv.view == viewCPU {
+		if offset < 0x10000 {
+			addr := uint16(offset)
+			mv.gb.Memory.DebugWriteBank(mv.bankAt(addr), addr, v)
+		}
+		return
+	}
+	mv.gb.Memory.DebugWriteRegion(memoryRegions[mv.view], uint(offset), v)
+}
+
+// location returns the bank-qualified address of the byte at offset in the view
+func (mv *memoryViewer) location(offset int) bankAddress {
+	switch mv.view {
+	case viewROM:
+		bank := uint(offset >> 14)
+		if bank == 0 {
+			return bankAddress{bank: 0, addr: uint16(offset)}
+		}
+		return bankAddress{bank: bank, addr: 0x4000 | uint16(offset&0x3FFF)}
+	case viewVRAM:
+		return bankAddress{bank: uint(offset >> 13), addr: 0x8000 | uint16(offset&0x1FFF)}
+	case viewWRAM:
+		bank := uint(offset >> 12)
+		if bank == 0 {
+			return bankAddress{bank: 0, addr: 0xC000 | uint16(offset)}
+		}
+		return bankAddress{bank: bank, addr: 0xD000 | uint16(offset&0x0FFF)}
+	case viewSRAM:
+		return bankAddress{bank: uint(offset >> 13), addr: 0xA000 | uint16(offset&0x1FFF)}
+	default:
+		addr := uint16(offset)
+		return bankAddress{bank: mv.bankAt(addr), addr: addr}
+	}
+}
+
+// offset returns the offset of the bank-qualified address in the view
+// (without a bank the one currently mapped is used), false if the view does not contain it
+func (mv *memoryViewer) offset(ba bankAddress, hasBank bool) (int, bool) {
+	if mv.view == viewCPU {
+		return int(ba.addr), true
+	}
+
+	bank := int(ba.bank)
+	if !hasBank {
+		if mv.view == viewVRAM {
+			bank = int(mv.gb.PPU.DebugVRAMBank())
+		} else {
+			bank = int(mv.gb.Memory.DebugBank(ba.addr))
+		}
+	}
+
+	addr := int(ba.addr)
+	switch {
+	case mv.view == viewROM && addr < 0x4000:
+		return addr, true
+	case mv.view == viewROM && addr < 0x8000:
+		return bank<<14 | addr&0x3FFF, true
+	case mv.view == viewVRAM && 0x8000 <= addr && addr < 0xA000:
+		return bank<<13 | addr&0x1FFF, true
+	case mv.view == viewWRAM && 0xC000 <= addr && addr < 0xD000:
+		return addr & 0x0FFF, true
+	case mv.view == viewWRAM && 0xD000 <= addr && addr < 0xE000:
+		return max(bank, 1)<<12 | addr&0x0FFF, true
+	case mv.view == viewSRAM && 0xA000 <= addr && addr < 0xC000:
+		return bank<<13 | addr&0x1FFF, true
+	}
+	return 0, false
+}
+
+// bankAt returns the bank shown at addr in the CPU view
+func (mv *memoryViewer) bankAt(addr uint16) uint {
+	switch {
+	case 0x4000 <= addr && addr < 0x8000 && mv.romBank >= 0:
+		return uint(mv.romBank)
+	case 0xD000 <= addr && addr < 0xE000 && mv.wRAMBank >= 0:
+		return uint(mv.wRAMBank)
+	default:
+		return mv.gb.Memory.DebugBank(addr)
+	}
+}
+
+// setView shows another memory view, from its beginning
+func (mv *memoryViewer) setView(view memoryView) {
+	mv.view = view
+	mv.first = 0
+	mv.lastMatch = -1
+	mv.Sync(mv.gb)
+}
+
+// selectBank shows the bank of the address in the CPU view, for switchable ROM
+// and wRAM (if hasBank is false the bank currently mapped is shown)
+func (mv *memoryViewer) selectBank(ba bankAddress, hasBank bool) {
+	// Without a bank go back to the one currently mapped
+	bank := -1
+	if hasBank {
+		bank = int(ba.bank)
+	}
+	switch {
+	case 0x4000 <= ba.addr && ba.addr < 0x8000:
+		mv.romBank = bank
+	case 0xD000 <= ba.addr && ba.addr < 0xE000:
+		mv.wRAMBank = bank
+	}
+}
+
+// goToOffset scrolls to the row containing offset
+func (mv *memoryViewer) goToOffset(offset int) {
+	mv.first = offset / 16
+	mv.Sync(mv.gb)
 }
 
 func (mv *memoryViewer) createRow() widget.PreferredSizeLocateableWidget {
-	dummyText := "0000  00 00 00 00 00 00 00 00 | 00 00 00 00 00 00 00 00 | ................"
+	dummyText := "00:0000  00 00 00 00 00 00 00 00 | 00 00 00 00 00 00 00 00 | ................"
 	label := widget.NewText(
 		widget.TextOpts.Text(dummyText, &font, colornames.White), // Font and text
 	)
@@ -131,34 +314,81 @@ func (mv *memoryViewer) createRow() widget.PreferredSizeLocateableWidget {
 }
 
 func (mv *memoryViewer) refresh() {
+	if mv.gb == nil {
+		return
+	}
+
 	// Update all rows
 	for i, r := range mv.rowsWidget {
 		label := r.(*widget.Text)
-		entry := mv.entries[mv.first+i]
+		row := &mv.rows[i]
+		if row.offset >= mv.size() {
+			label.Label = ""
+			continue
+		}
+
 		ascii := make([]byte, 16)
-		for i, b := range entry.data {
+		for i, b := range row.data {
 			if b >= 32 && b <= 126 {
 				ascii[i] = b
 			} else {
 				ascii[i] = '.'
 			}
 		}
-		label.Label = fmt.Sprintf("%04X  %02X %02X %02X %02X %02X %02X %02X %02X | %02X %02X %02X %02X %02X %02X %02X %02X | %s",
-			entry.baseAddress,
-			entry.data[0], entry.data[1], entry.data[2], entry.data[3],
-			entry.data[4], entry.data[5], entry.data[6], entry.data[7],
-			entry.data[8], entry.data[9], entry.data[10], entry.data[11],
-			entry.data[12], entry.data[13], entry.data[14], entry.data[15],
+		label.Label = fmt.Sprintf("%s  %02X %02X %02X %02X %02X %02X %02X %02X | %02X %02X %02X %02X %02X %02X %02X %02X | %s",
+			mv.rowName(row.offset),
+			row.data[0], row.data[1], row.data[2], row.data[3],
+			row.data[4], row.data[5], row.data[6], row.data[7],
+			row.data[8], row.data[9], row.data[10], row.data[11],
+			row.data[12], row.data[13], row.data[14], row.data[15],
 			string(ascii),
 		)
+		label.Label += mv.rowLabels(row)
+	}
+}
+
+// rowName returns the address shown at the beginning of a row (BB:AAAA, the offset for ROM)
+func (mv *memoryViewer) rowName(offset int) string {
+	if mv.view == viewROM {
+		return fmt.Sprintf("%07X", offset)
+	}
+	return mv.location(offset).String()
+}
+
+// maxRowLabelLength limits the labels shown next to a row, to keep the layout stable
+const maxRowLabelLength = 20
+
+// rowLabels returns the labels of the row (first one and number of others)
+func (mv *memoryViewer) rowLabels(row *memoryRow) string {
+	if mv.symbols == nil {
+		return ""
+	}
+
+	ba := mv.location(row.offset)
+	syms := mv.symbols.InRange(ba.bank, ba.addr, ba.addr+16)
+	if ba.addr == 0xFFF0 { // Range end would overflow
+		syms = mv.symbols.InRange(ba.bank, ba.addr, 0xFFFF)
+	}
+	if len(syms) == 0 {
+		return ""
+	}
+
+	s := syms[0].Name
+	if len(s) > maxRowLabelLength {
+		s = s[:maxRowLabelLength-1] + "~"
+	}
+	if len(syms) > 1 {
+		s += fmt.Sprintf(" +%d", len(syms)-1)
 	}
+	return "  " + s
 }
 
 func (mv *memoryViewer) scrollTo(newOffset int) {
 	mv.first = newOffset
-	mv.first = max(mv.first, 0)                         // Reset to 0 if too low
-	mv.first = min(mv.first, len(mv.entries)-mv.length) // Reset to maximum if too high
+	mv.first = min(mv.first, mv.slider.Max) // Reset to maximum if too high
+	mv.first = max(mv.first, 0)             // Reset to 0 if too low
 
 	mv.slider.Current = mv.first
+	mv.load()
 	mv.refresh()
 }
@@ -95,3 +95,42 @@ func newWindow(title string, content *widget.Container, closeWindow *widget.Remo
 		Contents: c,
 	}
 }
+
+// newTextInput creates a single line text input calling onSubmit when Enter is pressed
+func newTextInput(placeholder string, width int, onSubmit func(text string)) *widget.TextInput {
+	return widget.NewTextInput(
+		widget.TextInputOpts.Image(&widget.TextInputImage{
+			Idle:     image.NewNineSliceColor(theme.Debugger.Main.Color),
+			Disabled: image.NewNineSliceColor(theme.Debugger.Main.Color),
+		}),
+		widget.TextInputOpts.Color(&widget.TextInputColor{
+			Idle:          theme.Debugger.LabelColor,
+			Disabled:      theme.Debugger.LabelColor,
+			Caret:         theme.Debugger.LabelColor,
+			DisabledCaret: theme.Debugger.LabelColor,
+		}),
+		widget.TextInputOpts.Face(&font),
+		widget.TextInputOpts.Padding(theme.Debugger.Insets),
+		widget.TextInputOpts.Placeholder(placeholder),
+		widget.TextInputOpts.CaretWidth(2),
+		widget.TextInputOpts.WidgetOpts(
+			widget.WidgetOpts.MinSize(width, 0),
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+		widget.TextInputOpts.SubmitHandler(func(args *widget.TextInputChangedEventArgs) {
+			onSubmit(args.InputText)
+		}),
+	)
+}
+
+// newButton creates a button with the debugger theme
+func newButton(text string, onClick func()) *widget.Button {
+	return widget.NewButton(
+		widget.ButtonOpts.Image(theme.Debugger.Button.Image),
+		widget.ButtonOpts.Text(text, &font, theme.Debugger.Button.TextColor),
+		widget.ButtonOpts.TextPadding(&widget.Insets{Left: theme.Debugger.Padding, Right: theme.Debugger.Padding}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			onClick()
+		}),
+	)
+}
@@ -0,0 +1,240 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/profiler"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+const (
+	// Frames kept by the profiler and the ones listed
+	profilerFrames      = 600
+	profilerShownFrames = 8
+
+	// Hotspots listed
+	maxHotspots = 20
+
+	// The table is refreshed every this many updates while running
+	profilerRefreshInterval = 30
+)
+
+type profilerViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	// Hotspots listed by function (when symbols are loaded) or by address
+	byFunction bool
+	order      profiler.Order
+	updates    int
+
+	startButton *widget.Button
+	totalLabel  *widget.Text
+	framesLabel *widget.Text
+	list        *widget.Container
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newProfilerViewer() *profilerViewer {
+	pv := &profilerViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	help := newLabel("Cycles spent by the CPU, halted cycles are counted at the HALT instruction",
+		theme.Debugger.HeaderColor)
+
+	buttons := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+	pv.startButton = newButton("Start", pv.toggle)
+	buttons.AddChild(
+		pv.startButton,
+		newButton("Reset", func() {
+			d.profiler.Reset()
+			pv.Sync(d.gameBoy)
+		}),
+		newButton("By function", func() {
+			pv.byFunction = !pv.byFunction
+			pv.Sync(d.gameBoy)
+		}),
+		newButton("Export pprof", d.ExportProfile),
+	)
+
+	// Column headers sort the table
+	columns := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+	for _, column := range []struct {
+		name  string
+		order profiler.Order
+	}{
+		{"Location", profiler.ByLocation},
+		{"Instructions", profiler.ByCount},
+		{"Cycles", profiler.ByCycles},
+		{"Halted", profiler.ByHalted},
+	} {
+		columns.AddChild(newButton(column.name, func() {
+			pv.order = column.order
+			pv.Sync(d.gameBoy)
+		}))
+	}
+
+	pv.totalLabel = newLabel("", theme.Debugger.LabelColor)
+	pv.framesLabel = newLabel("", theme.Debugger.LabelColor)
+	pv.list = newContainer(widget.DirectionVertical)
+	root.AddChild(help, buttons, pv.totalLabel, pv.framesLabel, columns, pv.list)
+
+	pv.windowInfo = newWindow("Profiler", root, &pv.closeWindow)
+	return pv
+}
+
+func (pv *profilerViewer) Window() *widget.Window {
+	return pv.windowInfo.Window
+}
+
+func (pv *profilerViewer) Contents() *widget.Container {
+	return pv.windowInfo.Contents
+}
+
+func (pv *profilerViewer) TitleBar() *widget.Container {
+	return pv.windowInfo.TitleBar
+}
+
+func (pv *profilerViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := pv.closeWindow
+	pv.closeWindow = closeFunc
+	return old
+}
+
+// toggle starts or stops profiling
+func (pv *profilerViewer) toggle() {
+	if pv.d.gameBoy.Profiler == nil {
+		pv.d.gameBoy.SetProfiler(pv.d.profiler)
+	} else {
+		pv.d.gameBoy.SetProfiler(nil)
+	}
+	pv.Sync(pv.d.gameBoy)
+}
+
+// refresh syncs the viewer every profilerRefreshInterval calls
+func (pv *profilerViewer) refresh() {
+	pv.updates++
+	if pv.updates%profilerRefreshInterval == 0 {
+		pv.Sync(pv.d.gameBoy)
+	}
+}
+
+func (pv *profilerViewer) Sync(gb *gameboy.GameBoy) {
+	p := pv.d.profiler
+	if gb.Profiler == nil {
+		pv.startButton.Text().Label = "Start"
+	} else {
+		pv.startButton.Text().Label = "Stop"
+	}
+
+	total := p.Total()
+	pv.totalLabel.Label = fmt.Sprintf("Total: %d instructions, %d cycles executed, %d halted (%.1f%% busy)",
+		total.Count, total.Cycles, total.Halted, 100*profiler.Frame{Stats: total}.Busy())
+
+	// Last frames, the busiest ones are the lag candidates
+	frames := p.Frames()
+	text := "Last frames (busy):"
+	for _, f := range frames[max(len(frames)-profilerShownFrames, 0):] {
+		text += fmt.Sprintf(" #%d %.0f%%", f.Number, 100*f.Busy())
+	}
+	pv.framesLabel.Label = text
+
+	hotspots := p.Hotspots()
+	if pv.byFunction {
+		hotspots = profiler.Functions(hotspots, pv.d.profileName)
+	}
+	profiler.SortBy(hotspots, pv.order)
+
+	pv.list.RemoveChildren()
+	for _, h := range hotspots[:min(len(hotspots), maxHotspots)] {
+		ba := bankAddress{bank: h.Location.Bank, addr: h.Location.PC}
+		name := h.Name
+		if !pv.byFunction {
+			name = pv.d.addressName(ba)
+		}
+
+		percent := 0.0
+		if total.Cycles > 0 {
+			percent = 100 * float64(h.Cycles) / float64(total.Cycles)
+		}
+		text := fmt.Sprintf("%-32.32s %10d %12d %5.1f%% %12d", name, h.Count, h.Cycles, percent, h.Halted)
+
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+		row.AddChild(
+			newButton("Go", func() {
+				pv.d.disassembler.goTo(ba, true)
+			}),
+			newLabel(text, theme.Debugger.LabelColor),
+		)
+		pv.list.AddChild(row)
+	}
+}
+
+// profileName returns the function of the location (its address if no label precedes it)
+func (d *Debugger) profileName(loc profiler.Location) string {
+	ba := bankAddress{bank: loc.Bank, addr: loc.PC}
+	if name := d.functionName(ba); name != "" {
+		return name
+	}
+	return ba.String()
+}
+
+// ExportProfile writes the profile in the pprof format next to the ROM (see go tool pprof)
+func (d *Debugger) ExportProfile() {
+	if d.romPath == "" {
+		return
+	}
+
+	path := d.romPath[:len(d.romPath)-len(filepath.Ext(d.romPath))] + ".pprof"
+	if err := d.writeProfile(path); err != nil {
+		d.SetStatus(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	d.SetStatus("Profile exported to " + path)
+}
+
+func (d *Debugger) writeProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := func(loc profiler.Location) string {
+		return d.functionName(bankAddress{bank: loc.Bank, addr: loc.PC})
+	}
+	if err := d.profiler.WritePprof(f, filepath.Base(d.romPath), name); err != nil {
+		return err
+	}
+	return f.Close()
+}
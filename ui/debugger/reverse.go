@@ -0,0 +1,62 @@
+package debugger
+
+// Reverse execution: the game boy history goes back to a checkpoint
+// and executes again the instructions up to the state wanted
+
+func (d *Debugger) ReverseStep() {
+	if d.Running {
+		return
+	}
+	history := d.gameBoy.History
+	if history == nil {
+		return
+	}
+
+	defer d.Sync()
+
+	if !history.StepBack() {
+		d.SetStatus("No previous instruction recorded")
+	} else {
+		d.SetStatus("")
+	}
+	d.watchpointHit = nil
+}
+
+func (d *Debugger) ReverseContinue() {
+	if d.Running {
+		return
+	}
+	history := d.gameBoy.History
+	if history == nil {
+		return
+	}
+
+	defer d.Sync()
+
+	d.SetStatus("")
+	if !history.ContinueBack(d.reverseStop) {
+		d.SetStatus("No breakpoint or watchpoint hit recorded, back to the oldest state")
+	}
+	d.watchpointHit = nil
+}
+
+// reverseStop returns true if a watchpoint was hit by the last instruction
+// or a breakpoint is at the next one (its status is shown if it is the last hit)
+func (d *Debugger) reverseStop() bool {
+	if hit := d.watchpointHit; hit != nil {
+		d.watchpointHit = nil
+		d.SetStatus(hit.String())
+		return true
+	}
+
+	if len(d.disassembler.breakpoints) == 0 {
+		return false
+	}
+	pc := d.gameBoy.CPU.ReadPC()
+	bp, ok := d.disassembler.breakpoints[currentBankAddress(d.gameBoy, pc)]
+	if ok && bp.matches(d) {
+		d.SetStatus("Breakpoint " + d.breakpointLabel(bp))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/util/symbols"
+)
+
+// maxSearchResults is the number of matching labels listed when a search is ambiguous
+const maxSearchResults = 8
+
+// LoadSymbols loads the labels of a symbol file (RGBDS .sym or no$gmb format).
+// If the file does not exist, labels of the previous ROM are removed
+func (d *Debugger) LoadSymbols(path string) error {
+	table, err := symbols.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		table, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if table != nil {
+		for _, line := range table.Skipped() {
+			log.Printf("[WARN] %s: skipped %s\n", path, line)
+		}
+		log.Printf("Loaded %d symbols from %s\n", table.Len(), path)
+	}
+	d.symbols = table
+	d.disassembler.symbols = table
+	d.memoryViewer.symbols = table
+	return nil
+}
+
+// label returns the first label at the bank-qualified address
+func (d *Debugger) label(ba bankAddress) (string, bool) {
+	if d.symbols == nil {
+		return "", false
+	}
+	return d.symbols.Label(ba.bank, ba.addr)
+}
+
+// addressName returns the label before ba with the offset from it (e.g. Main+$12), or ba
+func (d *Debugger) addressName(ba bankAddress) string {
+	if d.symbols != nil && ba.addr < 0xFFFF {
+		if syms := d.symbols.InRange(ba.bank, 0, ba.addr+1); len(syms) > 0 {
+			sym := syms[len(syms)-1]
+			if sym.Addr == ba.addr {
+				return sym.Name
+			}
+			return fmt.Sprintf("%s+$%X", sym.Name, ba.addr-sym.Addr)
+		}
+	}
+	return ba.String()
+}
+
+// functionName returns the last global label before ba (local labels belong to it),
+// "" if there is none
+func (d *Debugger) functionName(ba bankAddress) string {
+	if d.symbols == nil || ba.addr == 0xFFFF {
+		return ""
+	}
+	syms := d.symbols.InRange(ba.bank, 0, ba.addr+1)
+	for i := len(syms) - 1; i >= 0; i-- {
+		if !strings.Contains(syms[i].Name, ".") {
+			return syms[i].Name
+		}
+	}
+	return ""
+}
+
+// resolveAddress parses a label, "BB:AAAA" or "AAAA" (see parseBankAddress).
+// Labels are always bank-qualified
+func (d *Debugger) resolveAddress(s string) (ba bankAddress, hasBank bool, err error) {
+	s = strings.TrimSpace(s)
+	if d.symbols != nil {
+		if sym, ok := d.symbols.Lookup(s); ok {
+			return bankAddress{bank: sym.Bank, addr: sym.Addr}, true, nil
+		}
+	}
+
+	ba, hasBank, err = parseBankAddress(s)
+	if err != nil && d.symbols != nil {
+		return ba, false, fmt.Errorf("unknown address or label %q", s)
+	}
+	return ba, hasBank, err
+}
+
+// searchAddress resolves s as resolveAddress does, otherwise searches labels containing s:
+// if exactly one matches it is used, otherwise matches are listed in the status bar
+func (d *Debugger) searchAddress(s string) (ba bankAddress, hasBank bool, ok bool) {
+	ba, hasBank, err := d.resolveAddress(s)
+	if err == nil {
+		return ba, hasBank, true
+	}
+
+	var found []symbols.Symbol
+	if d.symbols != nil && strings.TrimSpace(s) != "" {
+		found = d.symbols.Search(strings.TrimSpace(s))
+	}
+
+	switch len(found) {
+	case 0:
+		d.SetStatus(err.Error())
+		return ba, false, false
+	case 1:
+		return bankAddress{bank: found[0].Bank, addr: found[0].Addr}, true, true
+	}
+
+	names := make([]string, 0, maxSearchResults)
+	for _, sym := range found[:min(len(found), maxSearchResults)] {
+		names = append(names, sym.Name)
+	}
+	msg := fmt.Sprintf("%d labels match: %s", len(found), strings.Join(names, ", "))
+	if len(found) > maxSearchResults {
+		msg += ", ..."
+	}
+	d.SetStatus(msg)
+	return ba, false, false
+}
@@ -0,0 +1,269 @@
+package debugger
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/timeline"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// Frames kept by the recorder
+	timelineFrames = 16
+
+	timelineLines  = 154
+	timelineWidth  = timeline.LineLength
+	timelineHeight = timelineLines * 2 // 2 pixels per line with one frame, 1 with two
+
+	// Events listed when hovering a dot are at most this many dots away
+	timelineHoverDots = 4
+)
+
+var (
+	// Background of each PPU mode
+	timelineModeColors = [4]color.RGBA{
+		0: {R: 40, G: 60, B: 110, A: 255}, // HBlank
+		1: {R: 60, G: 40, B: 80, A: 255},  // VBlank
+		2: {R: 40, G: 100, B: 60, A: 255}, // OAM scan
+		3: {R: 130, G: 90, B: 30, A: 255}, // Drawing
+	}
+
+	timelineRequestColor = color.RGBA{R: 255, G: 220, A: 255}
+	timelineServiceColor = color.RGBA{R: 255, G: 60, B: 60, A: 255}
+	timelineWriteColor   = color.RGBA{R: 240, G: 240, B: 240, A: 255}
+	timelineHDMAColor    = color.RGBA{G: 220, B: 255, A: 255}
+)
+
+var interruptNames = map[uint8]string{
+	0b1: "VBlank", 0b10: "STAT", 0b100: "Timer", 0b1000: "Serial", 0b10000: "Joypad",
+}
+
+type timelineViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	// Frames shown, the selected one is back frames before the one being recorded
+	frames []timeline.Frame
+	back   int
+	count  int // 1 or 2 (the one selected and the previous)
+
+	image  *ebiten.Image
+	pixels []byte
+
+	frameLabel *widget.Text
+	countLabel *widget.Text
+	infoLabel  *widget.Text
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newTimelineViewer() *timelineViewer {
+	tv := &timelineViewer{ui: d.UI, d: d, back: 1, count: 1}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	help := newLabel("Lines by dots: HBlank blue, VBlank purple, OAM scan green, Drawing orange,\n"+
+		"HALT darker, OAM DMA red. Interrupt requests yellow, services red, writes white, HDMA cyan",
+		theme.Debugger.HeaderColor)
+
+	tv.frameLabel = newLabel("", theme.Debugger.LabelColor)
+	tv.countLabel = newLabel("", theme.Debugger.LabelColor)
+	buttons := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+	buttons.AddChild(
+		newButton("<", func() { tv.selectFrame(tv.back + 1) }),
+		newButton(">", func() { tv.selectFrame(tv.back - 1) }),
+		newButton("Latest", func() { tv.selectFrame(1) }),
+		newButton("Frames", func() {
+			tv.count = 3 - tv.count
+			tv.Sync(d.gameBoy)
+		}),
+		tv.countLabel,
+		tv.frameLabel,
+	)
+
+	tv.image = ebiten.NewImage(timelineWidth, timelineHeight)
+	tv.pixels = make([]byte, timelineWidth*timelineHeight*4)
+	graphic := widget.NewGraphic(
+		widget.GraphicOpts.Image(tv.image),
+		widget.GraphicOpts.WidgetOpts(
+			widget.WidgetOpts.CursorMoveHandler(func(args *widget.WidgetCursorMoveEventArgs) {
+				tv.hover(args.OffsetX, args.OffsetY)
+			}),
+		),
+	)
+
+	tv.infoLabel = newLabel("", theme.Debugger.LabelColor)
+	root.AddChild(help, buttons, graphic, tv.infoLabel)
+
+	tv.windowInfo = newWindow("Event Timeline", root, &tv.closeWindow)
+	return tv
+}
+
+func (tv *timelineViewer) Window() *widget.Window {
+	return tv.windowInfo.Window
+}
+
+func (tv *timelineViewer) Contents() *widget.Container {
+	return tv.windowInfo.Contents
+}
+
+func (tv *timelineViewer) TitleBar() *widget.Container {
+	return tv.windowInfo.TitleBar
+}
+
+func (tv *timelineViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := tv.closeWindow
+	tv.closeWindow = closeFunc
+	return old
+}
+
+// selectFrame selects the frame back frames before the one being recorded
+func (tv *timelineViewer) selectFrame(back int) {
+	tv.back = back
+	tv.Sync(tv.d.gameBoy)
+}
+
+// shown returns the frames shown, from top to bottom
+func (tv *timelineViewer) shown() []timeline.Frame {
+	last := len(tv.frames) - 1 - tv.back
+	first := max(last-tv.count+1, 0)
+	return tv.frames[first : last+1]
+}
+
+func (tv *timelineViewer) Sync(gb *gameboy.GameBoy) {
+	tv.frames = tv.d.timeline.Frames()
+	tv.back = min(max(tv.back, 0), len(tv.frames)-1)
+
+	shown := tv.shown()
+	selected := shown[len(shown)-1]
+	status := "recording"
+	if selected.Complete {
+		status = fmt.Sprintf("%d events", len(selected.Events))
+	}
+	if !tv.d.Active {
+		status = "recorded while debugging"
+	}
+	tv.frameLabel.Label = fmt.Sprintf("Frame #%d (%s)", selected.Number, status)
+	tv.countLabel.Label = fmt.Sprint(tv.count)
+
+	tv.render(shown)
+}
+
+// render draws the frames, one above the other
+func (tv *timelineViewer) render(frames []timeline.Frame) {
+	clear(tv.pixels)
+
+	rows := timelineHeight / timelineLines / tv.count
+	for slot, f := range frames {
+		top := slot * timelineLines * rows
+		fill := func(start, end int, paint func(c color.RGBA) color.RGBA) {
+			for pos := start; pos < end; pos++ {
+				ly, dot := pos/timelineWidth, pos%timelineWidth
+				for r := range rows {
+					i := ((top+ly*rows+r)*timelineWidth + dot) * 4
+					c := paint(color.RGBA{R: tv.pixels[i], G: tv.pixels[i+1], B: tv.pixels[i+2], A: tv.pixels[i+3]})
+					tv.pixels[i], tv.pixels[i+1], tv.pixels[i+2], tv.pixels[i+3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+
+		for _, s := range f.Modes() {
+			fill(s.Start, s.End, func(color.RGBA) color.RGBA { return timelineModeColors[s.Value&3] })
+		}
+		for _, s := range f.Halts() {
+			fill(s.Start, s.End, func(c color.RGBA) color.RGBA {
+				return color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: c.A}
+			})
+		}
+		for _, s := range f.DMAs() {
+			fill(s.Start, s.End, func(c color.RGBA) color.RGBA {
+				return color.RGBA{R: max(c.R, 180), G: c.G, B: c.B, A: c.A}
+			})
+		}
+
+		for _, e := range f.Events {
+			var mark color.RGBA
+			switch e.Kind {
+			case timeline.Request:
+				mark = timelineRequestColor
+			case timeline.Service:
+				mark = timelineServiceColor
+			case timeline.Write:
+				mark = timelineWriteColor
+			case timeline.HDMA:
+				mark = timelineHDMAColor
+			default:
+				continue
+			}
+			pos := e.Position()
+			fill(pos, pos+1, func(color.RGBA) color.RGBA { return mark })
+		}
+	}
+
+	tv.image.WritePixels(tv.pixels)
+}
+
+// hover lists the events near the dot under the cursor
+func (tv *timelineViewer) hover(x, y int) {
+	shown := tv.shown()
+	rows := timelineHeight / timelineLines / tv.count
+	slot, line := y/(timelineLines*rows), y%(timelineLines*rows)/rows
+	if x < 0 || x >= timelineWidth || y < 0 || slot >= len(shown) {
+		return
+	}
+	f := shown[slot]
+
+	var near []string
+	for _, e := range f.Events {
+		if int(e.LY) == line && e.Kind != timeline.Mode && abs(e.Dot-x) <= timelineHoverDots {
+			near = append(near, fmt.Sprintf("%d: %s", e.Dot, describeEvent(e)))
+		}
+	}
+	tv.infoLabel.Label = fmt.Sprintf("Frame #%d LY %d dot %d  %s", f.Number, line, x, strings.Join(near, ", "))
+}
+
+// describeEvent returns a description of the event for the timeline
+func describeEvent(e timeline.Event) string {
+	switch e.Kind {
+	case timeline.Mode:
+		return fmt.Sprintf("Mode %d", e.Value)
+	case timeline.Request, timeline.Service:
+		return fmt.Sprintf("%s %s", interruptNames[e.Value], strings.ToLower(e.Kind.String()))
+	case timeline.DMAStart:
+		return fmt.Sprintf("OAM DMA from $%02X00", e.Value)
+	case timeline.HDMA:
+		return fmt.Sprintf("HDMA to $%04X (%d left)", e.Addr, e.Value)
+	case timeline.Write:
+		return fmt.Sprintf("%s = $%02X", ioRegisterName(e.Addr), e.Value)
+	default:
+		return e.Kind.String()
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -54,8 +54,14 @@ func (d *Debugger) newToolbar() *toolbar {
 		ebiten.KeyF3)
 	runMenu.addEntryWithShortcut("Next", d.Next,
 		ebiten.KeyF8)
+	runMenu.addEntryWithShortcut("Step Out", d.StepOut,
+		ebiten.KeyF4)
 	runMenu.addEntryWithShortcut("Continue", d.Continue,
 		ebiten.KeyF9)
+	runMenu.addEntryWithShortcut("Reverse Step", d.ReverseStep,
+		ebiten.KeyF2)
+	runMenu.addEntryWithShortcut("Reverse Continue", d.ReverseContinue,
+		ebiten.KeyF7)
 	runMenu.addEntryWithShortcut("Stop", d.Stop,
 		ebiten.KeyShift, ebiten.KeyF9)
 	runMenu.addEntryWithShortcut("Reset", d.Reset,
@@ -71,6 +77,27 @@ func (d *Debugger) newToolbar() *toolbar {
 		ebiten.KeyShift, ebiten.KeyB)
 	ppuMenu.addEntryWithShortcut("TilesViewer", func() { d.showWindow(d.tilesViewer) },
 		ebiten.KeyShift, ebiten.KeyT)
+	ppuMenu.addEntryWithShortcut("Event Timeline", func() { d.showWindow(d.timelineViewer) },
+		ebiten.KeyShift, ebiten.KeyE)
+
+	// Debug menu
+	debugMenu := t.newMenu("Debug")
+	debugMenu.addEntryWithShortcut("Breakpoints", func() { d.showWindow(d.breakpointsViewer) },
+		ebiten.KeyControl, ebiten.KeyB)
+	debugMenu.addEntryWithShortcut("Watchpoints", func() { d.showWindow(d.watchpointsViewer) },
+		ebiten.KeyControl, ebiten.KeyW)
+	debugMenu.addEntryWithShortcut("Call Stack", func() { d.showWindow(d.callStackViewer) },
+		ebiten.KeyControl, ebiten.KeyK)
+	debugMenu.addEntryWithShortcut("Profiler", func() { d.showWindow(d.profilerViewer) },
+		ebiten.KeyControl, ebiten.KeyP)
+	debugMenu.addEntry("Export disassembly (.asm)", d.ExportASM)
+
+	// Cheats menu
+	cheatsMenu := t.newMenu("Cheats")
+	cheatsMenu.addEntryWithShortcut("Cheats", func() { d.showWindow(d.cheatsViewer) },
+		ebiten.KeyControl, ebiten.KeyG)
+	cheatsMenu.addEntryWithShortcut("Cheat finder", func() { d.showWindow(d.cheatFinderViewer) },
+		ebiten.KeyControl, ebiten.KeyF)
 	return t
 }
 
@@ -0,0 +1,228 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type watchType uint8
+
+const (
+	watchRead watchType = 1 << iota
+	watchWrite
+	watchAccess = watchRead | watchWrite
+)
+
+func (t watchType) String() string {
+	switch t {
+	case watchRead:
+		return "R"
+	case watchWrite:
+		return "W"
+	default:
+		return "RW"
+	}
+}
+
+// valueCondition compares the value read or written with a constant
+type valueCondition struct {
+	op    string
+	value uint8
+}
+
+var conditionOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (c *valueCondition) matches(v uint8) bool {
+	switch c.op {
+	case "==":
+		return v == c.value
+	case "!=":
+		return v != c.value
+	case "<=":
+		return v <= c.value
+	case ">=":
+		return v >= c.value
+	case "<":
+		return v < c.value
+	case ">":
+		return v > c.value
+	}
+	return false
+}
+
+type watchpoint struct {
+	start, end uint16 // Inclusive range
+	kind       watchType
+	condition  *valueCondition // Optional
+
+	// Only fire while the bank is mapped (if hasBank)
+	bank    uint
+	hasBank bool
+}
+
+func (wp *watchpoint) String() string {
+	s := fmt.Sprintf("%-2s $%04X", wp.kind, wp.start)
+	if wp.hasBank {
+		s = fmt.Sprintf("%-2s %02X:$%04X", wp.kind, wp.bank, wp.start)
+	}
+	if wp.end != wp.start {
+		s += fmt.Sprintf("-$%04X", wp.end)
+	}
+	if wp.condition != nil {
+		s += fmt.Sprintf(" %s $%02X", wp.condition.op, wp.condition.value)
+	}
+	return s
+}
+
+// matches returns true if the access triggers the watchpoint
+func (wp *watchpoint) matches(addr uint16, value uint8, write bool) bool {
+	if addr < wp.start || addr > wp.end {
+		return false
+	}
+	if write && wp.kind&watchWrite == 0 || !write && wp.kind&watchRead == 0 {
+		return false
+	}
+	return wp.condition == nil || wp.condition.matches(value)
+}
+
+// watchpointHit describes the access that triggered a watchpoint
+type watchpointHit struct {
+	watchpoint *watchpoint
+	pc         uint16
+	addr       uint16
+	old, new   uint8
+	write      bool
+}
+
+func (hit *watchpointHit) String() string {
+	if hit.write {
+		return fmt.Sprintf("Watchpoint [%s]: PC=$%04X wrote $%04X: $%02X -> $%02X",
+			hit.watchpoint, hit.pc, hit.addr, hit.old, hit.new)
+	}
+	return fmt.Sprintf("Watchpoint [%s]: PC=$%04X read $%04X: $%02X",
+		hit.watchpoint, hit.pc, hit.addr, hit.new)
+}
+
+// parseWatchpoint parses a watchpoint in the form "[BB:]ADDR[-END] [r|w|rw] [OP VALUE]"
+// (numbers are hexadecimal, optionally prefixed by $ or 0x), e.g. "C000-C0FF w == 05".
+// With a bank the watchpoint only fires while the bank is mapped, e.g. "02:D000 w"
+func parseWatchpoint(spec string) (*watchpoint, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errors.New("empty watchpoint")
+	}
+
+	wp := &watchpoint{kind: watchAccess}
+
+	// Address range
+	startStr, endStr, isRange := strings.Cut(fields[0], "-")
+	start, hasBank, err := parseBankAddress(startStr)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(start.addr)
+	if isRange {
+		if end, err = parseHex(endStr, 16); err != nil {
+			return nil, err
+		}
+	}
+	if end < uint64(start.addr) {
+		return nil, errors.New("invalid address range")
+	}
+	wp.start, wp.end = start.addr, uint16(end)
+	wp.bank, wp.hasBank = start.bank, hasBank
+	fields = fields[1:]
+
+	// Access type
+	if len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case "r":
+			wp.kind = watchRead
+			fields = fields[1:]
+		case "w":
+			wp.kind = watchWrite
+			fields = fields[1:]
+		case "rw", "a":
+			fields = fields[1:]
+		}
+	}
+
+	// Value condition (operator can be attached to the value)
+	if len(fields) > 0 {
+		cond := strings.Join(fields, "")
+		for _, op := range conditionOperators {
+			if valueStr, ok := strings.CutPrefix(cond, op); ok {
+				value, err := parseHex(valueStr, 8)
+				if err != nil {
+					return nil, err
+				}
+				wp.condition = &valueCondition{op: op, value: uint8(value)}
+				return wp, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid condition %q", cond)
+	}
+
+	return wp, nil
+}
+
+// parseHex parses a hexadecimal number, optionally prefixed by $ or 0x
+func parseHex(s string, bitSize int) (uint64, error) {
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	v, err := strconv.ParseUint(s, 16, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// AddWatchpoint adds a watchpoint parsed from spec (see parseWatchpoint)
+func (d *Debugger) AddWatchpoint(spec string) error {
+	wp, err := parseWatchpoint(spec)
+	if err != nil {
+		return err
+	}
+	d.watchpoints = append(d.watchpoints, wp)
+	return nil
+}
+
+// RemoveWatchpoint removes the i-th watchpoint
+func (d *Debugger) RemoveWatchpoint(i int) {
+	d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+}
+
+// onMemoryAccess is the MMU access hook checking watchpoints
+func (d *Debugger) onMemoryAccess(addr uint16, old, new uint8, write bool) {
+	// Only report the first hit of an instruction
+	if d.watchpointHit != nil {
+		return
+	}
+
+	for _, wp := range d.watchpoints {
+		if wp.matches(addr, new, write) && (!wp.hasBank || d.gameBoy.Memory.DebugBank(addr) == wp.bank) {
+			d.watchpointHit = &watchpointHit{
+				watchpoint: wp,
+				pc:         d.gameBoy.CPU.InstructionPC(),
+				addr:       addr,
+				old:        old,
+				new:        new,
+				write:      write,
+			}
+			return
+		}
+	}
+}
+
+// CheckWatchpoint returns true if a watchpoint was hit by the last instruction
+func (d *Debugger) CheckWatchpoint() bool {
+	if d.watchpointHit == nil {
+		return false
+	}
+
+	d.SetStatus(d.watchpointHit.String())
+	d.watchpointHit = nil
+	return true
+}
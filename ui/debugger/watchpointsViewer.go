@@ -0,0 +1,91 @@
+package debugger
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/ui/graphics"
+	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/widget"
+)
+
+type watchpointsViewer struct {
+	// Pointer to the UI for showing the window
+	ui *ebitenui.UI
+	d  *Debugger
+
+	list       *widget.Container
+	errorLabel *widget.Text
+
+	// Window info
+	windowInfo *windowInfo
+
+	// Handler to close the window
+	closeWindow widget.RemoveWindowFunc
+}
+
+func (d *Debugger) newWatchpointsViewer() *watchpointsViewer {
+	wv := &watchpointsViewer{ui: d.UI, d: d}
+
+	root := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Padding(theme.Debugger.Insets),
+			widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+		)),
+	)
+
+	input := newTextInput("C000-C0FF w == 05", 360, func(text string) {
+		if err := d.AddWatchpoint(text); err != nil {
+			wv.errorLabel.Label = err.Error()
+			return
+		}
+		wv.errorLabel.Label = ""
+		wv.Sync(d.gameBoy)
+	})
+
+	help := newLabel("[BB:]ADDR[-END] [r|w|rw] [==|!=|<|<=|>|>= VALUE]", theme.Debugger.HeaderColor)
+	wv.errorLabel = newLabel("", theme.Debugger.Disassembler.BreakpointHoverColor)
+	wv.list = newContainer(widget.DirectionVertical)
+
+	root.AddChild(help, input, wv.errorLabel, wv.list)
+
+	wv.windowInfo = newWindow("Watchpoints", root, &wv.closeWindow)
+	return wv
+}
+
+func (wv *watchpointsViewer) Window() *widget.Window {
+	return wv.windowInfo.Window
+}
+
+func (wv *watchpointsViewer) Contents() *widget.Container {
+	return wv.windowInfo.Contents
+}
+
+func (wv *watchpointsViewer) TitleBar() *widget.Container {
+	return wv.windowInfo.TitleBar
+}
+
+func (wv *watchpointsViewer) SetCloseHandler(closeFunc widget.RemoveWindowFunc) widget.RemoveWindowFunc {
+	old := wv.closeWindow
+	wv.closeWindow = closeFunc
+	return old
+}
+
+func (wv *watchpointsViewer) Sync(_ *gameboy.GameBoy) {
+	wv.list.RemoveChildren()
+
+	for i, wp := range wv.d.watchpoints {
+		row := widget.NewContainer(
+			widget.ContainerOpts.Layout(widget.NewRowLayout(
+				widget.RowLayoutOpts.Spacing(theme.Debugger.Padding),
+			)),
+		)
+		row.AddChild(
+			newButton("X", func() {
+				wv.d.RemoveWatchpoint(i)
+				wv.Sync(wv.d.gameBoy)
+			}),
+			newLabel(wp.String(), theme.Debugger.LabelColor),
+		)
+		wv.list.AddChild(row)
+	}
+}
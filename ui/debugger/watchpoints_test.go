@@ -0,0 +1,94 @@
+package debugger
+
+import "testing"
+
+func TestParseWatchpoint(t *testing.T) {
+	for _, test := range []struct {
+		spec     string
+		expected watchpoint
+	}{
+		{"C000", watchpoint{start: 0xC000, end: 0xC000, kind: watchAccess}},
+		{"$c000-0xC0FF", watchpoint{start: 0xC000, end: 0xC0FF, kind: watchAccess}},
+		{"FF40 r", watchpoint{start: 0xFF40, end: 0xFF40, kind: watchRead}},
+		{"FF40 W", watchpoint{start: 0xFF40, end: 0xFF40, kind: watchWrite}},
+		{"FF40 rw", watchpoint{start: 0xFF40, end: 0xFF40, kind: watchAccess}},
+		{"02:D000-D0FF w", watchpoint{start: 0xD000, end: 0xD0FF, kind: watchWrite, bank: 2, hasBank: true}},
+		{"00:A000", watchpoint{start: 0xA000, end: 0xA000, kind: watchAccess, hasBank: true}},
+	} {
+		wp, err := parseWatchpoint(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if *wp != test.expected {
+			t.Errorf("%q: got %+v, want %+v", test.spec, *wp, test.expected)
+		}
+	}
+}
+
+func TestParseWatchpointCondition(t *testing.T) {
+	for _, test := range []struct {
+		spec string
+		kind watchType
+		op   string
+		val  uint8
+	}{
+		{"C000-C0FF w == 05", watchWrite, "==", 0x05},
+		{"C000 >=$80", watchAccess, ">=", 0x80},
+		{"C000 r != 0", watchRead, "!=", 0x00},
+		{"C000 <ff", watchAccess, "<", 0xFF},
+	} {
+		wp, err := parseWatchpoint(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if wp.kind != test.kind || wp.condition == nil || *wp.condition != (valueCondition{test.op, test.val}) {
+			t.Errorf("%q: got %s", test.spec, wp)
+		}
+	}
+}
+
+func TestParseWatchpointErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"   ",
+		"XYZ",
+		"10000",
+		"C0FF-C000",
+		"C000-",
+		"C000-10000",
+		"G:C000",
+		"C000 x",
+		"C000 w == 100",
+		"C000 w ==",
+		"C000 w = 05",
+	} {
+		if wp, err := parseWatchpoint(spec); err == nil {
+			t.Errorf("%q: expected error, got %s", spec, wp)
+		}
+	}
+}
+
+func TestWatchpointMatches(t *testing.T) {
+	wp, err := parseWatchpoint("C000-C0FF w > 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		addr     uint16
+		value    uint8
+		write    bool
+		expected bool
+	}{
+		{0xC000, 0x11, true, true},
+		{0xC0FF, 0xFF, true, true},
+		{0xC100, 0x11, true, false},  // Out of range
+		{0xC080, 0x10, true, false},  // Condition false
+		{0xC080, 0x11, false, false}, // Read
+	} {
+		if got := wp.matches(test.addr, test.value, test.write); got != test.expected {
+			t.Errorf("%04X=%02X write=%t: got %t", test.addr, test.value, test.write, got)
+		}
+	}
+}
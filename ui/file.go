@@ -10,10 +10,14 @@ import (
 	"path/filepath"
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy/cartridge"
+	"github.com/danielecanzoneri/lucky-boy/util/romfile"
+	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/sqweek/dialog"
 )
 
 func (ui *UI) Save() {
+	ui.saveCDL()
+
 	ramDump := ui.GameBoy.Memory.Cartridge.RAMDump()
 	if ramDump == nil {
 		return
@@ -40,7 +44,7 @@ func (ui *UI) LoadBootROM(bootRom string) (err error) {
 
 func (ui *UI) AskRomPath() (string, error) {
 	romPath, err := dialog.File().
-		Filter("Game Boy ROMs", "gb", "gbc").
+		Filter("Game Boy ROMs", "gb", "gbc", "zip", "gz").
 		Title("Choose a GameBoy ROM").
 		Load()
 	if err != nil {
@@ -55,12 +59,40 @@ func (ui *UI) AskRomPath() (string, error) {
 	return romPath, nil
 }
 
+// LoadROM loads a ROM, extracting it from .zip and .gz archives. When a zip archive
+// has many ROMs the first one is loaded and the list to choose the ROM is shown
 func (ui *UI) LoadROM(romPath string) error {
-	// Open the ROM file
-	cartridgeData, err := os.ReadFile(romPath)
+	entries, err := romfile.Entries(romPath)
+	if err != nil {
+		return err
+	}
+
+	// The explicit patch only applies to the first ROM loaded (the one chosen from the list)
+	patchPath := ui.patchPath
+	ui.patchPath = ""
+	if len(entries) > 1 {
+		ui.chooseROM(romPath, entries, patchPath)
+		patchPath = ""
+	}
+	return ui.loadROMEntry(romPath, entries[0], patchPath)
+}
+
+// loadROMEntry loads the ROM entry of the file (see romfile.Entries) with the
+// patch (if empty, the one next to the ROM is looked for)
+func (ui *UI) loadROMEntry(romPath, entry, patchPath string) error {
+	cartridgeData, romPath, err := romfile.Read(romPath, entry)
+	if err != nil {
+		return err
+	}
+
+	// Apply soft-patch (if any)
+	cartridgeData, err = applyPatch(romPath, cartridgeData, patchPath)
 	if err != nil {
 		return err
 	}
+	if err := cartridge.CheckHeader(cartridgeData); err != nil {
+		return err
+	}
 
 	// Open the SAV file
 	savFile := getSavFileName(romPath)
@@ -75,6 +107,11 @@ func (ui *UI) LoadROM(romPath string) error {
 
 	rom := cartridge.NewCartridge(cartridgeData, savData)
 	ui.GameBoy.Load(rom)
+	ui.loadCDL(romPath)
+
+	// Rumble cartridges vibrate the gamepad
+	ui.setRumble(false)
+	ui.GameBoy.SetRumbleCallback(ui.setRumble)
 
 	if ui.GameBoy.EmulationModel == gameboy.DMG {
 		ui.palette = theme.DMGPalette{}
@@ -85,21 +122,61 @@ func (ui *UI) LoadROM(romPath string) error {
 	ui.gameTitle = rom.Header().Title
 	ui.fileName = romPath
 
+	// Debugger code analysis and labels from the symbol file next to the ROM (RGBDS .sym)
+	if err := ui.debugger.LoadROM(romPath); err != nil {
+		log.Println("[WARN] Could not load symbols:", err)
+	}
+
+	// Screen size depends on the model (SGB has a border)
+	if !ui.debugger.Active {
+		ebiten.SetWindowSize(ui.Layout(0, 0))
+	}
+
 	return nil
 }
 
+// SetPatch sets the patch applied to the next loaded ROM, instead of looking for one next to it
+func (ui *UI) SetPatch(patchPath string) {
+	ui.patchPath = patchPath
+}
+
+// applyPatch patches the ROM with the patch at patchPath or, if it is
+// empty, with a .ips/.ups/.bps file with the same name of the ROM
+func applyPatch(romPath string, rom []uint8, patchPath string) ([]uint8, error) {
+	if patchPath == "" {
+		base := romPath[:len(romPath)-len(filepath.Ext(romPath))]
+		for _, ext := range cartridge.PatchExtensions {
+			if _, err := os.Stat(base + ext); err == nil {
+				patchPath = base + ext
+				break
+			}
+		}
+	}
+	if patchPath == "" {
+		return rom, nil
+	}
+
+	patch, err := os.ReadFile(patchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := cartridge.ApplyPatch(rom, patch)
+	if err != nil {
+		return nil, fmt.Errorf("applying patch %s: %w", patchPath, err)
+	}
+
+	log.Println("Applied patch", patchPath)
+	return patched, nil
+}
+
 func (ui *UI) SetModel(model string) error {
-	switch model {
-	case "auto":
-		ui.GameBoy.Model = gameboy.Auto
-	case "dmg":
-		ui.GameBoy.Model = gameboy.DMG
-	case "cgb":
-		ui.GameBoy.Model = gameboy.CGB
-	default:
-		return fmt.Errorf("invalid model type: %s", model)
+	m, err := gameboy.ParseModel(model)
+	if err != nil {
+		return err
 	}
 
+	ui.GameBoy.Model = m
 	return nil
 }
 
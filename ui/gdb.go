@@ -0,0 +1,16 @@
+package ui
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/gdb"
+)
+
+// ListenGDB starts a GDB remote debugging server on the specified local port.
+// While a client is attached it controls the execution instead of the debugger
+func (ui *UI) ListenGDB(socketPort string) error {
+	server := gdb.New(ui.GameBoy)
+	if err := server.Listen("localhost:" + socketPort); err != nil {
+		return err
+	}
+	ui.gdbServer = server
+	return nil
+}
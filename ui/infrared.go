@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"github.com/danielecanzoneri/lucky-boy/gameboy/infrared"
+	"log"
+	"net"
+)
+
+// InfraredLoopback makes the infrared port receive the light of its own LED
+func (ui *UI) InfraredLoopback() {
+	ui.GameBoy.SetInfraredTransport(new(infrared.Loopback))
+}
+
+// ListenInfrared waits on the specified port for another emulator to point its infrared port at this one
+func (ui *UI) ListenInfrared(socketPort string) {
+	ln, err := net.Listen("tcp", "localhost:"+socketPort)
+	if err != nil {
+		log.Println("[ERROR] Listening: ", err)
+		return
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Println("[ERROR] Accepting incoming connection", err)
+			return
+		}
+		ui.setInfraredConn(conn)
+	}()
+}
+
+// ConnectInfrared points the infrared port at the emulator listening on the specified port
+func (ui *UI) ConnectInfrared(socketPort string) {
+	conn, err := net.Dial("tcp", "localhost:"+socketPort)
+	if err != nil {
+		log.Println("[ERROR] Connecting to socket: ", err)
+		return
+	}
+	ui.setInfraredConn(conn)
+}
+
+func (ui *UI) setInfraredConn(conn net.Conn) {
+	// Important for light timing
+	if err := conn.(*net.TCPConn).SetNoDelay(true); err != nil {
+		log.Println("[ERROR] Setting socket no delay: ", err)
+	}
+
+	ui.GameBoy.SetInfraredTransport(infrared.NewConnTransport(conn))
+}
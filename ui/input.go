@@ -43,6 +43,11 @@ func (ui *UI) handleInput() {
 		ui.ToggleDebugger()
 	}
 
+	// Keys typed in the debugger text inputs are not shortcuts
+	if ui.debugger.Active && ui.debugger.TextInputFocused() {
+		return
+	}
+
 	// Turbo (play at max speed)
 	ui.turbo = ebiten.IsKeyPressed(ebiten.KeySpace)
 
@@ -64,8 +69,8 @@ func (ui *UI) handleInput() {
 
 		ui.GameBoy.Reset()
 
-		// Start running
-		ui.Paused = false
+		// Start running (after choosing the ROM if the archive has many)
+		ui.Paused = ui.romChooser != nil
 	}
 
 	ui.handleAudioToggle()
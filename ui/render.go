@@ -9,6 +9,7 @@ import (
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy"
 	"github.com/danielecanzoneri/lucky-boy/gameboy/ppu"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
@@ -17,13 +18,6 @@ const (
 	Scale = 3
 )
 
-var (
-	// Original palette
-	frameImage *ebiten.Image
-	// After shader image
-	shaderImage *ebiten.Image
-)
-
 //go:embed graphics/gbc-shader.kage
 var shaderData []byte
 
@@ -68,8 +62,7 @@ func (ui *UI) initRenderer(useShader bool) {
 	ebiten.SetWindowClosingHandled(true)
 
 	// Create a single image for the entire frame
-	frameImage = ebiten.NewImage(ppu.FrameWidth, ppu.FrameHeight)
-	shaderImage = ebiten.NewImage(ppu.FrameWidth, ppu.FrameHeight)
+	ui.resizeFrame(ppu.FrameWidth, ppu.FrameHeight)
 
 	// Initial window size without the debug panel
 	screenWidth, screenHeight := ui.Layout(0, 0)
@@ -90,10 +83,23 @@ func (ui *UI) initRenderer(useShader bool) {
 			"LightenScreen": float32(0.0),
 		}
 	}
+}
+
+// frameSize returns the size of the emulated screen (with the border in SGB mode)
+func (ui *UI) frameSize() (int, int) {
+	if ui.GameBoy.SGB != nil {
+		return sgb.ScreenWidth, sgb.ScreenHeight
+	}
+	return ppu.FrameWidth, ppu.FrameHeight
+}
+
+// resizeFrame creates the frame images with the given size
+func (ui *UI) resizeFrame(width, height int) {
+	ui.frameImage = ebiten.NewImage(width, height)
+	ui.shaderImage = ebiten.NewImage(width, height)
 
 	// Reuse pixel buffer to avoid allocations (RGBA = 4 bytes per pixel)
-	pixelBufferSize := ppu.FrameWidth * ppu.FrameHeight * 4
-	ui.pixelBuffer = make([]byte, pixelBufferSize)
+	ui.pixelBuffer = make([]byte, width*height*4)
 }
 
 // Inherit Ebiten Game interface
@@ -109,10 +115,19 @@ func (ui *UI) Update() error {
 	// If closing, save game
 	if ebiten.IsWindowBeingClosed() {
 		ui.Save()
+		ui.closeTrace()
+		ui.closeWeb()
 		return ebiten.Termination
 	}
 
+	if ui.romChooser != nil {
+		ui.updateROMChooser()
+		return nil
+	}
+
 	ui.handleInput()
+	ui.updateRumble()
+	ui.debugger.HandleRequests()
 
 	if ui.debugger.Active {
 		ebiten.SetWindowTitle(ui.gameTitle + " (debugging)")
@@ -127,18 +142,67 @@ func (ui *UI) Update() error {
 func (ui *UI) applyShader(frame *ebiten.Image) *ebiten.Image {
 	if ui.Shader != nil && ui.GameBoy.Model == gameboy.CGB {
 		ui.shaderOpts.Images[0] = frame
-		shaderImage.DrawRectShader(
-			ppu.FrameWidth, ppu.FrameHeight,
+		ui.shaderImage.DrawRectShader(
+			frame.Bounds().Dx(), frame.Bounds().Dy(),
 			ui.Shader, ui.shaderOpts,
 		)
-		return shaderImage
+		return ui.shaderImage
 	} else {
 		return frame
 	}
 }
 
 func (ui *UI) Draw(screen *ebiten.Image) {
-	// Update the frame image with the current frame in the PPU
+	width, height := ui.frameSize()
+	if ui.frameImage.Bounds().Dx() != width || ui.frameImage.Bounds().Dy() != height {
+		ui.resizeFrame(width, height)
+	}
+
+	if ui.GameBoy.SGB != nil {
+		ui.drawSGBFrame()
+	} else {
+		ui.drawFrame()
+	}
+	ui.pushWebFrame(width, height)
+
+	// Apply shader
+	imageToDraw := ui.applyShader(ui.frameImage)
+	ui.drawScriptOverlay(imageToDraw)
+
+	if ui.debugger.Active {
+		// The debugger only shows the Game Boy screen
+		if ui.GameBoy.SGB != nil {
+			imageToDraw = imageToDraw.SubImage(image.Rect(
+				sgb.GameX, sgb.GameY, sgb.GameX+ppu.FrameWidth, sgb.GameY+ppu.FrameHeight,
+			)).(*ebiten.Image)
+		}
+		ui.debugger.Draw(screen, imageToDraw)
+		if ui.romChooser != nil {
+			ui.drawROMChooser(screen)
+		}
+		return
+	}
+
+	// Draw the entire frame at once with scaling
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(Scale, Scale)
+	screen.DrawImage(imageToDraw, op)
+
+	ui.drawRumbleIndicator(screen)
+
+	if ui.romChooser != nil {
+		ui.drawROMChooser(screen)
+		return
+	}
+
+	if ui.debugStringTimer > 0 {
+		ebitenutil.DebugPrint(screen, ui.debugString)
+		ui.debugStringTimer--
+	}
+}
+
+// drawFrame updates the frame image with the current frame in the PPU
+func (ui *UI) drawFrame() {
 	frameBuffer, previousBuffer := ui.GameBoy.PPU.GetFrame()
 
 	// Convert frame buffer to RGBA pixels
@@ -162,25 +226,26 @@ func (ui *UI) Draw(screen *ebiten.Image) {
 	}
 
 	// Write all pixels at once
-	frameImage.WritePixels(ui.pixelBuffer)
-
-	// Apply shader
-	imageToDraw := ui.applyShader(frameImage)
+	ui.frameImage.WritePixels(ui.pixelBuffer)
+}
 
-	if ui.debugger.Active {
-		ui.debugger.Draw(screen, imageToDraw)
-		return
-	}
+// drawSGBFrame updates the frame image with the colorized SGB frame and its border
+func (ui *UI) drawSGBFrame() {
+	frameBuffer := ui.GameBoy.SGB.GetFrame()
 
-	// Draw the entire frame at once with scaling
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(Scale, Scale)
-	screen.DrawImage(imageToDraw, op)
+	for y := range sgb.ScreenHeight {
+		for x := range sgb.ScreenWidth {
+			r, g, b, a := ui.palette.Get(frameBuffer[y][x]).RGBA()
 
-	if ui.debugStringTimer > 0 {
-		ebitenutil.DebugPrint(screen, ui.debugString)
-		ui.debugStringTimer--
+			idx := (y*sgb.ScreenWidth + x) * 4
+			ui.pixelBuffer[idx] = uint8(r >> 8)
+			ui.pixelBuffer[idx+1] = uint8(g >> 8)
+			ui.pixelBuffer[idx+2] = uint8(b >> 8)
+			ui.pixelBuffer[idx+3] = uint8(a >> 8)
+		}
 	}
+
+	ui.frameImage.WritePixels(ui.pixelBuffer)
 }
 
 func (ui *UI) Layout(_, _ int) (int, int) {
@@ -188,6 +253,7 @@ func (ui *UI) Layout(_, _ int) (int, int) {
 	if ui.debugger.Active {
 		return ui.debugger.Layout(0, 0)
 	} else {
-		return Scale * ppu.FrameWidth, Scale * ppu.FrameHeight
+		width, height := ui.frameSize()
+		return Scale * width, Scale * height
 	}
 }
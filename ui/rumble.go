@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Vibration lasts a bit more than a frame, so it continues until the next update
+const rumbleDuration = 50 * time.Millisecond
+
+// setRumble is called by the game boy (from the emulation goroutine) when the motor state changes
+func (ui *UI) setRumble(on bool) {
+	ui.rumbleOn.Store(on)
+	if on {
+		ui.rumbleTriggered.Store(true)
+	}
+}
+
+// updateRumble vibrates connected gamepads if the motor was on since the last update
+func (ui *UI) updateRumble() {
+	// Games drive the motor with short pulses, so also check if it was turned on during the frame
+	triggered := ui.rumbleTriggered.Swap(false)
+	ui.rumbling = !ui.Paused && (ui.rumbleOn.Load() || triggered)
+	if !ui.rumbling {
+		return
+	}
+
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		ebiten.VibrateGamepad(id, &ebiten.VibrateGamepadOptions{
+			Duration:        rumbleDuration,
+			StrongMagnitude: 1,
+			WeakMagnitude:   1,
+		})
+	}
+}
+
+// drawRumbleIndicator shows when the cartridge motor is rumbling
+func (ui *UI) drawRumbleIndicator(screen *ebiten.Image) {
+	if !ui.rumbling {
+		return
+	}
+
+	width, _ := ui.Layout(0, 0)
+	ebitenutil.DebugPrintAt(screen, "((RUMBLE))", width-70, 0)
+}
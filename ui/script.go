@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/script"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/sgb"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// Size of the overlay text in Game Boy pixels
+const scriptFontSize = 8
+
+// RunScript runs a Lua script driving the emulation (kept across resets and ROM loads).
+// The keys not set by the script are read from the keyboard (and the web controller)
+func (ui *UI) RunScript(path string) error {
+	engine := script.New(ui.GameBoy, ui.inputProvider)
+	if err := engine.Run(path); err != nil {
+		engine.Close()
+		return err
+	}
+
+	source, err := text.NewGoTextFaceSource(bytes.NewReader(gomono.TTF))
+	if err != nil {
+		engine.Close()
+		return err
+	}
+	ui.scriptFont = &text.GoTextFace{Source: source, Size: scriptFontSize}
+
+	ui.script = engine
+	ui.GameBoy.SetInputProvider(engine)
+	return nil
+}
+
+// drawScriptOverlay draws the shapes of the last frame on the frame image
+func (ui *UI) drawScriptOverlay(frame *ebiten.Image) {
+	if ui.script == nil {
+		return
+	}
+
+	// In SGB mode the frame includes the border
+	var offset image.Point
+	if ui.GameBoy.SGB != nil {
+		offset = image.Pt(sgb.GameX, sgb.GameY)
+	}
+
+	for _, s := range ui.script.Overlay() {
+		x1, y1 := float32(s.X1+offset.X), float32(s.Y1+offset.Y)
+		x2, y2 := float32(s.X2+offset.X), float32(s.Y2+offset.Y)
+
+		switch s.Kind {
+		case script.Text:
+			op := &text.DrawOptions{}
+			op.GeoM.Translate(float64(x1), float64(y1))
+			op.ColorScale.ScaleWithColor(s.Color)
+			text.Draw(frame, s.Text, ui.scriptFont, op)
+		case script.Rect:
+			vector.StrokeRect(frame, x1+0.5, y1+0.5, x2-x1-1, y2-y1-1, 1, s.Color, false)
+		case script.FilledRect:
+			vector.FillRect(frame, x1, y1, x2-x1, y2-y1, s.Color, false)
+		case script.Line:
+			vector.StrokeLine(frame, x1+0.5, y1+0.5, x2+0.5, y2+0.5, 1, s.Color, false)
+		case script.Pixel:
+			vector.FillRect(frame, x1, y1, 1, 1, s.Color, false)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"log"
+	"os"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/trace"
+)
+
+// StartTrace writes every executed instruction to path in the Gameboy Doctor format.
+// info lists the extra information of each line ("cycles,ly,bank"), filters the traced
+// PC ranges and banks ("[BB:]AAAA[-AAAA]" or "BB:", comma separated).
+// If wait is true, tracing starts when a breakpoint with "trace start" is hit
+func (ui *UI) StartTrace(path, info, filters string, wait bool) error {
+	opts, err := trace.ParseOptions(info)
+	if err != nil {
+		return err
+	}
+	ranges, err := trace.ParseFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	ui.traceFile = f
+
+	tracer := trace.New(f, opts, ranges)
+	tracer.SetActive(!wait)
+	ui.GameBoy.SetTracer(tracer)
+	return nil
+}
+
+func (ui *UI) closeTrace() {
+	if ui.traceFile == nil {
+		return
+	}
+
+	tracer := ui.GameBoy.Tracer
+	ui.GameBoy.SetTracer(nil)
+	if err := tracer.Flush(); err != nil {
+		log.Println("error writing trace:", err)
+	}
+	ui.traceFile.Close()
+	ui.traceFile = nil
+}
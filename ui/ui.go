@@ -1,20 +1,29 @@
 package ui
 
 import (
+	"os"
+	"sync/atomic"
+
 	theme "github.com/danielecanzoneri/lucky-boy/ui/graphics"
 	"log"
 
 	"github.com/danielecanzoneri/lucky-boy/ui/debugger"
 
 	"github.com/danielecanzoneri/lucky-boy/gameboy"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/gdb"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/remote"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/script"
 	"github.com/ebitengine/oto/v3"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
 )
 
 type UI struct {
 	GameBoy   *gameboy.GameBoy
 	gameTitle string
 	fileName  string
+	patchPath string // Patch applied to the next loaded ROM
 
 	// When true, stop emulation
 	Paused bool
@@ -26,9 +35,17 @@ type UI struct {
 	// Turbo mode
 	turbo bool
 
+	// Cartridge rumble motor
+	rumbleOn        atomic.Bool
+	rumbleTriggered atomic.Bool
+	rumbling        bool
+
 	debugString      string
 	debugStringTimer uint
 
+	// List of the ROMs of an archive to choose from (nil if not choosing)
+	romChooser *romChooser
+
 	// Color Palette
 	palette theme.Palette
 
@@ -36,11 +53,32 @@ type UI struct {
 	Shader     *ebiten.Shader
 	shaderOpts *ebiten.DrawRectShaderOptions
 
+	// Frame with the original palette and after the shader
+	frameImage  *ebiten.Image
+	shaderImage *ebiten.Image
+
 	// Reusable pixel buffer for rendering (avoids allocations)
 	pixelBuffer []byte
 
 	// Debugger
 	debugger *debugger.Debugger
+
+	// Instruction trace output (nil if not tracing)
+	traceFile *os.File
+
+	// GDB remote debugging server (nil if not listening)
+	gdbServer *gdb.Server
+
+	// Lua script driving the emulation (nil if none)
+	script     *script.Engine
+	scriptFont text.Face
+
+	// Joypad input, read by the scripts for the keys they do not set
+	inputProvider joypad.InputProvider
+
+	// Web viewers server (nil if not listening)
+	webServer  *remote.Server
+	webSamples []float32
 }
 
 func New(useShader bool) (*UI, error) {
@@ -63,8 +101,8 @@ func New(useShader bool) (*UI, error) {
 	ui.audioPlayer = player
 
 	// Set up input provider for joypad
-	inputProvider := &ebitenInputProvider{}
-	gb.SetInputProvider(inputProvider)
+	ui.inputProvider = &ebitenInputProvider{}
+	gb.SetInputProvider(ui.inputProvider)
 
 	// Initialize the renderer
 	ui.initRenderer(useShader)
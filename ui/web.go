@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"encoding/binary"
+	"image"
+	"log"
+	"math"
+
+	"github.com/danielecanzoneri/lucky-boy/gameboy/joypad"
+	"github.com/danielecanzoneri/lucky-boy/gameboy/remote"
+)
+
+// ListenWeb streams the screen and audio to web browsers on the address (e.g. ":8080").
+// The buttons of the viewer in control are pressed together with the keyboard ones
+func (ui *UI) ListenWeb(addr string) error {
+	server := remote.New(sampleRate)
+	listenAddr, err := server.Listen(addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Web viewer listening on %s", listenAddr)
+
+	ui.webServer = server
+	ui.inputProvider = anyInputProvider{ui.inputProvider, server}
+	ui.GameBoy.SetInputProvider(ui.inputProvider)
+	return nil
+}
+
+// anyInputProvider presses the keys pressed on any of the providers
+type anyInputProvider []joypad.InputProvider
+
+func (providers anyInputProvider) IsKeyPressed(key joypad.Key) bool {
+	for _, p := range providers {
+		if p.IsKeyPressed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// pushWebFrame sends the frame drawn (without the shader) to the web viewers
+func (ui *UI) pushWebFrame(width, height int) {
+	if ui.webServer == nil {
+		return
+	}
+	ui.webServer.PushFrame(&image.RGBA{
+		Pix:    ui.pixelBuffer,
+		Stride: 4 * width,
+		Rect:   image.Rect(0, 0, width, height),
+	})
+}
+
+// pushWebAudio sends the samples played to the web viewers
+func (ui *UI) pushWebAudio(buf []byte) {
+	if ui.webServer == nil {
+		return
+	}
+	ui.webSamples = ui.webSamples[:0]
+	for i := 0; i+4 <= len(buf); i += 4 {
+		ui.webSamples = append(ui.webSamples, math.Float32frombits(binary.LittleEndian.Uint32(buf[i:])))
+	}
+	ui.webServer.PushAudio(ui.webSamples)
+}
+
+func (ui *UI) closeWeb() {
+	if ui.webServer != nil {
+		ui.webServer.Close()
+	}
+}
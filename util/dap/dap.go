@@ -0,0 +1,151 @@
+// Package dap implements the base protocol of the Debug Adapter Protocol, used by editors
+// (VS Code and others) to talk to debuggers: JSON requests, responses and events, each one
+// preceded by a Content-Length header
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Request is sent by the client, Arguments are decoded by the command handler
+type Request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Response answers a request, Message explains why it failed
+type Response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+// Event notifies the client of something that happened in the debugger (e.g. "stopped")
+type Event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// Conn reads requests and writes responses and events. Writes can be done
+// from any goroutine (events are usually sent while a request is being handled)
+type Conn struct {
+	r *bufio.Reader
+
+	mu  sync.Mutex
+	w   io.Writer
+	seq int
+}
+
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{r: bufio.NewReader(rw), w: rw}
+}
+
+// ReadRequest returns the next request (other messages are skipped)
+func (c *Conn) ReadRequest() (*Request, error) {
+	for {
+		data, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		req := new(Request)
+		if err := json.Unmarshal(data, req); err != nil {
+			return nil, fmt.Errorf("dap: invalid message: %w", err)
+		}
+		if req.Type == "request" {
+			return req, nil
+		}
+	}
+}
+
+// readMessage reads the headers and returns the content of the next message
+func (c *Conn) readMessage() ([]byte, error) {
+	length := -1
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || length < 0 {
+				return nil, fmt.Errorf("dap: invalid content length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("dap: missing content length")
+	}
+
+	data := make([]byte, length)
+	_, err := io.ReadFull(c.r, data)
+	return data, err
+}
+
+// Respond sends the successful response to req
+func (c *Conn) Respond(req *Request, body any) error {
+	return c.write(&Response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    true,
+		Command:    req.Command,
+		Body:       body,
+	})
+}
+
+// RespondError sends a failed response to req
+func (c *Conn) RespondError(req *Request, err error) error {
+	return c.write(&Response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Command:    req.Command,
+		Message:    err.Error(),
+	})
+}
+
+// SendEvent sends an event with the given body
+func (c *Conn) SendEvent(event string, body any) error {
+	return c.write(&Event{Type: "event", Event: event, Body: body})
+}
+
+func (c *Conn) write(msg any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	switch m := msg.(type) {
+	case *Response:
+		m.Seq = c.seq
+	case *Event:
+		m.Seq = c.seq
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(data)
+	return err
+}
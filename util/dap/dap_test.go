@@ -0,0 +1,60 @@
+package dap
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+)
+
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func frame(msg string) string {
+	return "Content-Length: " + strconv.Itoa(len(msg)) + "\r\n\r\n" + msg
+}
+
+func TestConn(t *testing.T) {
+	in := bytes.NewBufferString(
+		frame(`{"seq":1,"type":"request","command":"initialize","arguments":{"adapterID":"lucky-boy"}}`) +
+			frame(`{"seq":2,"type":"response","request_seq":1,"command":"runInTerminal","success":true}`) +
+			frame(`{"seq":3,"type":"request","command":"threads"}`),
+	)
+	var out bytes.Buffer
+	c := NewConn(readWriter{in, &out})
+
+	req, err := c.ReadRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args struct {
+		AdapterID string `json:"adapterID"`
+	}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil || req.Command != "initialize" || args.AdapterID != "lucky-boy" {
+		t.Errorf("got %+v (%v), want initialize request", req, err)
+	}
+
+	// Responses from the client are skipped
+	req, err = c.ReadRequest()
+	if err != nil || req.Seq != 3 || req.Command != "threads" {
+		t.Errorf("got %+v (%v), want threads request", req, err)
+	}
+	if _, err := c.ReadRequest(); err != io.EOF {
+		t.Errorf("got %v, want EOF", err)
+	}
+
+	if err := c.Respond(req, map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendEvent("stopped", StoppedEvent{Reason: "pause", ThreadID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	want := frame(`{"seq":1,"type":"response","request_seq":3,"success":true,"command":"threads","body":{"n":1}}`) +
+		frame(`{"seq":2,"type":"event","event":"stopped","body":{"reason":"pause","threadId":1,"allThreadsStopped":false}}`)
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
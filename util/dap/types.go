@@ -0,0 +1,83 @@
+package dap
+
+// Protocol objects used by the emulator (only the fields it needs)
+
+// Capabilities is the body of the initialize response
+type Capabilities struct {
+	SupportsConfigurationDoneRequest  bool `json:"supportsConfigurationDoneRequest,omitempty"`
+	SupportsFunctionBreakpoints       bool `json:"supportsFunctionBreakpoints,omitempty"`
+	SupportsConditionalBreakpoints    bool `json:"supportsConditionalBreakpoints,omitempty"`
+	SupportsHitConditionalBreakpoints bool `json:"supportsHitConditionalBreakpoints,omitempty"`
+	SupportsLogPoints                 bool `json:"supportsLogPoints,omitempty"`
+	SupportsEvaluateForHovers         bool `json:"supportsEvaluateForHovers,omitempty"`
+	SupportsStepBack                  bool `json:"supportsStepBack,omitempty"`
+	SupportsTerminateRequest          bool `json:"supportsTerminateRequest,omitempty"`
+}
+
+type Source struct {
+	Name            string `json:"name,omitempty"`
+	Path            string `json:"path,omitempty"`
+	SourceReference int    `json:"sourceReference,omitempty"`
+}
+
+type SourceBreakpoint struct {
+	Line         int    `json:"line"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hitCondition,omitempty"`
+	LogMessage   string `json:"logMessage,omitempty"`
+}
+
+type FunctionBreakpoint struct {
+	Name         string `json:"name"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hitCondition,omitempty"`
+}
+
+// Breakpoint is the breakpoint actually set for a requested one
+type Breakpoint struct {
+	Verified bool    `json:"verified"`
+	Message  string  `json:"message,omitempty"`
+	Source   *Source `json:"source,omitempty"`
+	Line     int     `json:"line,omitempty"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type StackFrame struct {
+	ID                          int     `json:"id"`
+	Name                        string  `json:"name"`
+	Source                      *Source `json:"source,omitempty"`
+	Line                        int     `json:"line"`
+	Column                      int     `json:"column"`
+	InstructionPointerReference string  `json:"instructionPointerReference,omitempty"`
+	PresentationHint            string  `json:"presentationHint,omitempty"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// StoppedEvent is the body of the "stopped" event
+type StoppedEvent struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+}
+
+// ContinuedEvent is the body of the "continued" event
+type ContinuedEvent struct {
+	ThreadID            int  `json:"threadId"`
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
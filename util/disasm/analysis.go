@@ -0,0 +1,167 @@
+package disasm
+
+// Byte flags of the analysis
+const (
+	flagCode       uint8 = 1 << iota // First byte of an instruction
+	flagOperand                      // Operand byte of an instruction
+	flagData                         // Known data (cartridge header)
+	flagJumpTarget                   // Target of a jump
+	flagCallTarget                   // Target of a call or RST
+)
+
+// Entry points of the code flow analysis: RST and interrupt vectors and the cartridge entry point
+var entryPoints = []uint16{
+	0x00, 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38, // RST
+	0x40, 0x48, 0x50, 0x58, 0x60, // VBlank, STAT, Timer, Serial, Joypad
+	0x100,
+}
+
+// Cartridge header (data between entry point and code)
+const (
+	headerStart = 0x104
+	headerEnd   = 0x150
+)
+
+// Location is a bank-qualified ROM address
+type Location struct {
+	Bank uint
+	Addr uint16
+}
+
+// Analysis separates code from data in a ROM by following the code flow (jumps, calls, RSTs,
+// returns) from the entry points. Everything not reached is considered data.
+// New code paths can be learned at runtime from the executed instructions (see Learn)
+type Analysis struct {
+	rom   []uint8
+	flags []uint8
+}
+
+// Analyze runs the code flow analysis on the ROM
+func Analyze(rom []uint8) *Analysis {
+	a := &Analysis{
+		rom:   rom,
+		flags: make([]uint8, len(rom)),
+	}
+
+	for i := headerStart; i < headerEnd && i < len(rom); i++ {
+		a.flags[i] = flagData
+	}
+	for _, addr := range entryPoints {
+		a.trace(Location{Bank: 0, Addr: addr})
+	}
+	return a
+}
+
+// offset returns the position in the ROM of a location (false if outside ROM)
+func (a *Analysis) offset(loc Location) (int, bool) {
+	if loc.Addr >= 0x8000 {
+		return 0, false
+	}
+	offset := int(loc.Bank)<<14 | int(loc.Addr&0x3FFF)
+	return offset, offset < len(a.rom)
+}
+
+// IsCode returns true if an instruction starts at loc
+func (a *Analysis) IsCode(loc Location) bool {
+	offset, ok := a.offset(loc)
+	return ok && a.flags[offset]&flagCode != 0
+}
+
+// IsTarget returns true if loc is the target of a jump, call or RST
+func (a *Analysis) IsTarget(loc Location) bool {
+	offset, ok := a.offset(loc)
+	return ok && a.flags[offset]&(flagJumpTarget|flagCallTarget) != 0
+}
+
+// IsCallTarget returns true if loc is the target of a call or RST
+func (a *Analysis) IsCallTarget(loc Location) bool {
+	offset, ok := a.offset(loc)
+	return ok && a.flags[offset]&flagCallTarget != 0
+}
+
+// Learn marks the instruction at loc as executed and follows the code flow from it.
+// Returns true if new code was found
+func (a *Analysis) Learn(loc Location) bool {
+	offset, ok := a.offset(loc)
+	if !ok || a.flags[offset]&flagCode != 0 {
+		return false
+	}
+
+	// Executed code takes precedence over previous guesses
+	a.flags[offset] &^= flagOperand | flagData
+	return a.trace(loc)
+}
+
+// trace follows the code flow from loc, returns true if new code was found
+func (a *Analysis) trace(loc Location) bool {
+	found := false
+	work := []Location{loc}
+
+	for len(work) > 0 {
+		loc := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		for {
+			offset, ok := a.offset(loc)
+			if !ok || a.flags[offset]&(flagCode|flagOperand|flagData) != 0 {
+				break
+			}
+
+			opcode := a.rom[offset]
+			length := Opcodes[opcode].Length
+			// Instructions can't cross bank boundaries
+			if int(loc.Addr&0x3FFF)+length > 0x4000 || offset+length > len(a.rom) {
+				break
+			}
+
+			a.flags[offset] |= flagCode
+			for i := 1; i < length; i++ {
+				a.flags[offset+i] |= flagOperand
+			}
+			found = true
+
+			in := Instruction{Addr: loc.Addr, Bytes: a.rom[offset : offset+length]}
+			kind := in.Flow()
+			if target, ok := in.Target(); ok && kind != FlowNone {
+				if t, ok := targetLocation(loc, target); ok {
+					if kind == FlowCall || kind == FlowConditionalCall {
+						a.markTarget(t, flagCallTarget)
+					} else {
+						a.markTarget(t, flagJumpTarget)
+					}
+					work = append(work, t)
+				}
+			}
+
+			// Execution does not continue after unconditional jumps and returns
+			if kind == FlowJump || kind == FlowReturn || kind == FlowStop {
+				break
+			}
+			loc.Addr += uint16(length)
+		}
+	}
+
+	return found
+}
+
+func (a *Analysis) markTarget(loc Location, flag uint8) {
+	if offset, ok := a.offset(loc); ok {
+		a.flags[offset] |= flag
+	}
+}
+
+// targetLocation resolves the bank of a jump target. Jumps from the switchable bank stay
+// in it, jumps from bank 0 to 4000-7FFF assume the bank mapped at power on (bank 1, the
+// only one without MBC): code reached after switching banks is found when executed
+func targetLocation(from Location, target uint16) (Location, bool) {
+	switch {
+	case target < 0x4000:
+		return Location{Bank: 0, Addr: target}, true
+	case target < 0x8000 && from.Addr >= 0x4000:
+		return Location{Bank: from.Bank, Addr: target}, true
+	case target < 0x8000:
+		return Location{Bank: 1, Addr: target}, true
+	default:
+		return Location{}, false
+	}
+}
@@ -0,0 +1,198 @@
+package disasm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// bytesPerDataLine is the number of bytes of each db directive
+const bytesPerDataLine = 8
+
+// WriteASM writes the whole ROM as RGBDS assembly (rgbasm 0.6 or later), that assembles
+// back to the same bytes: instructions found by the analysis are disassembled, everything
+// else is written as data. labels returns the name of the label at a location (e.g. from
+// a symbol file), jump and call targets without one get a generated name
+func (a *Analysis) WriteASM(w io.Writer, labels func(Location) (string, bool)) error {
+	bw := bufio.NewWriter(w)
+	aw := &asmWriter{Analysis: a, w: bw, labels: labels}
+
+	fmt.Fprintln(bw, "; Code and data are separated by following the code flow from the entry points")
+	fmt.Fprintln(bw, "; and the code executed in the emulator: code never reached is written as data")
+	for bank := uint(0); bank<<14 < uint(len(a.rom)); bank++ {
+		aw.writeBank(bank)
+	}
+
+	return bw.Flush()
+}
+
+type asmWriter struct {
+	*Analysis
+	w      *bufio.Writer
+	labels func(Location) (string, bool)
+}
+
+// location returns the address of a ROM offset as seen by the CPU
+func location(offset int) Location {
+	bank := uint(offset >> 14)
+	addr := uint16(offset & 0x3FFF)
+	if bank > 0 {
+		addr |= 0x4000
+	}
+	return Location{Bank: bank, Addr: addr}
+}
+
+// label returns the label at a location (user label or generated for jump targets)
+func (aw *asmWriter) label(loc Location) (string, bool) {
+	if aw.labels != nil {
+		if name, ok := aw.labels(loc); ok {
+			return name, true
+		}
+	}
+
+	offset, ok := aw.offset(loc)
+	switch {
+	case !ok:
+		return "", false
+	case aw.flags[offset]&flagCallTarget != 0:
+		return fmt.Sprintf("Call_%03X_%04X", loc.Bank, loc.Addr), true
+	case aw.flags[offset]&flagJumpTarget != 0:
+		return fmt.Sprintf("Jump_%03X_%04X", loc.Bank, loc.Addr), true
+	default:
+		return "", false
+	}
+}
+
+// instruction returns the instruction at offset, false if it has to be written as data
+// (not code, or other code or labels inside it)
+func (aw *asmWriter) instruction(offset int) (Instruction, bool) {
+	if aw.flags[offset]&flagCode == 0 {
+		return Instruction{}, false
+	}
+
+	loc := location(offset)
+	length := Opcodes[aw.rom[offset]].Length
+	if int(loc.Addr&0x3FFF)+length > 0x4000 || offset+length > len(aw.rom) {
+		return Instruction{}, false
+	}
+	for i := 1; i < length; i++ {
+		if _, hasLabel := aw.label(location(offset + i)); hasLabel || aw.flags[offset+i]&flagCode != 0 {
+			return Instruction{}, false
+		}
+	}
+
+	return Instruction{Addr: loc.Addr, Bytes: aw.rom[offset : offset+length]}, true
+}
+
+func (aw *asmWriter) writeBank(bank uint) {
+	start := int(bank) << 14
+	end := min(start+0x4000, len(aw.rom))
+
+	if bank == 0 {
+		fmt.Fprintf(aw.w, "\nSECTION \"ROM Bank $%03X\", ROM0[$0000]\n", bank)
+	} else {
+		fmt.Fprintf(aw.w, "\nSECTION \"ROM Bank $%03X\", ROMX[$4000], BANK[$%03X]\n", bank, bank)
+	}
+
+	for offset := start; offset < end; {
+		loc := location(offset)
+		if name, ok := aw.label(loc); ok {
+			fmt.Fprintf(aw.w, "\n%s:\n", name)
+		}
+
+		if in, ok := aw.instruction(offset); ok {
+			if text, ok := aw.format(loc, in); ok {
+				fmt.Fprintf(aw.w, "\t%s\n", text)
+				offset += len(in.Bytes)
+				continue
+			}
+		}
+
+		// Data until next code or label
+		data := []string{fmt.Sprintf("$%02X", aw.rom[offset])}
+		offset++
+		for offset < end && len(data) < bytesPerDataLine {
+			if _, ok := aw.instruction(offset); ok {
+				break
+			}
+			if _, ok := aw.label(location(offset)); ok {
+				break
+			}
+			data = append(data, fmt.Sprintf("$%02X", aw.rom[offset]))
+			offset++
+		}
+		fmt.Fprintf(aw.w, "\tdb %s\n", strings.Join(data, ", "))
+	}
+}
+
+// format writes the instruction in RGBDS syntax, false if it can't be assembled back
+// to the same bytes
+func (aw *asmWriter) format(loc Location, in Instruction) (string, bool) {
+	name := in.Name()
+	switch {
+	case name == "INVALID":
+		return "", false
+	case in.Bytes[0] == 0x10: // STOP is assembled as 10 00
+		return "stop", in.Bytes[1] == 0
+	case strings.HasPrefix(name, "RST"):
+		return fmt.Sprintf("rst $%02X", in.Bytes[0]&0x38), true
+	}
+
+	text := strings.ToLower(name)
+	text = strings.ReplaceAll(text, "[c]", "[$ff00+c]")
+
+	placeholder, value, ok := in.Operand()
+	if !ok {
+		return text, true
+	}
+
+	var operand string
+	switch placeholder {
+	case "n16", "a16":
+		operand = fmt.Sprintf("$%04X", value)
+		if label, ok := aw.targetLabel(loc, uint16(value)); ok {
+			operand = label
+		}
+
+	case "e8":
+		if strings.HasPrefix(name, "JR") {
+			// Relative to the start of the instruction (@)
+			if offset := int(int8(in.Bytes[1])) + 2; offset < 0 {
+				operand = fmt.Sprintf("@ - %d", -offset)
+			} else {
+				operand = fmt.Sprintf("@ + %d", offset)
+			}
+			if label, ok := aw.targetLabel(loc, uint16(value)); ok {
+				operand = label
+			}
+		} else if strings.Contains(text, "sp+e8") {
+			// LD HL, SP+e8
+			sign := "+"
+			if value < 0 {
+				sign, value = "-", -value
+			}
+			text = strings.Replace(text, "sp+e8", "sp "+sign+" e8", 1)
+			operand = fmt.Sprintf("%d", value)
+		} else {
+			operand = fmt.Sprintf("%d", value)
+		}
+
+	case "a8":
+		operand = fmt.Sprintf("$%04X", value)
+
+	case "n8":
+		operand = fmt.Sprintf("$%02X", value)
+	}
+
+	return strings.Replace(text, placeholder, operand, 1), true
+}
+
+// targetLabel returns the label of a ROM address referenced from loc
+func (aw *asmWriter) targetLabel(from Location, target uint16) (string, bool) {
+	t, ok := targetLocation(from, target)
+	if !ok {
+		return "", false
+	}
+	return aw.label(t)
+}
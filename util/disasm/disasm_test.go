@@ -0,0 +1,127 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+)
+
+// testROM returns a 2 banks ROM filled with data (0xFF) and the given code
+func testROM(code map[int][]uint8) []uint8 {
+	rom := make([]uint8, 0x8000)
+	for i := range rom {
+		rom[i] = 0xFF
+	}
+	// Vectors jump to themselves (JR -2)
+	for _, addr := range entryPoints {
+		rom[addr], rom[addr+1] = 0x18, 0xFE
+	}
+	for offset, bytes := range code {
+		copy(rom[offset:], bytes)
+	}
+	return rom
+}
+
+func TestAnalyze(t *testing.T) {
+	rom := testROM(map[int][]uint8{
+		0x100:  {0x00, 0xC3, 0x50, 0x01}, // NOP; JP $0150
+		0x150:  {0xCD, 0x00, 0x02},       // CALL $0200
+		0x153:  {0x20, 0xFB},             // JR NZ, $0150
+		0x155:  {0x18, 0xFE},             // JR $0155
+		0x157:  {0x3E, 0x42},             // Data that looks like code
+		0x200:  {0x3E, 0x01, 0xE0, 0x80}, // LD A, $01; LDH [$FF80], A
+		0x204:  {0xC9},                   // RET
+		0x4000: {0xC9},                   // Bank 1: RET (only reached at runtime)
+	})
+
+	a := Analyze(rom)
+
+	for _, addr := range []uint16{0x100, 0x101, 0x150, 0x153, 0x155, 0x200, 0x202, 0x204} {
+		if !a.IsCode(Location{Bank: 0, Addr: addr}) {
+			t.Errorf("$%04X: expected code", addr)
+		}
+	}
+	for _, addr := range []uint16{0x102, 0x104, 0x151, 0x157, 0x201, 0x205} {
+		if a.IsCode(Location{Bank: 0, Addr: addr}) {
+			t.Errorf("$%04X: expected data", addr)
+		}
+	}
+
+	if !a.IsCallTarget(Location{Bank: 0, Addr: 0x200}) {
+		t.Error("$0200: expected call target")
+	}
+	if !a.IsTarget(Location{Bank: 0, Addr: 0x150}) || a.IsCallTarget(Location{Bank: 0, Addr: 0x150}) {
+		t.Error("$0150: expected jump target")
+	}
+
+	// Learn code from executed instructions
+	loc := Location{Bank: 1, Addr: 0x4000}
+	if a.IsCode(loc) {
+		t.Error("01:4000: expected data before execution")
+	}
+	if !a.Learn(loc) || !a.IsCode(loc) {
+		t.Error("01:4000: expected code after execution")
+	}
+	if a.Learn(loc) {
+		t.Error("01:4000: learned twice")
+	}
+}
+
+func TestAnalyzeBankedTarget(t *testing.T) {
+	rom := testROM(map[int][]uint8{
+		0x100:  {0x00, 0xC3, 0x50, 0x01},       // NOP; JP $0150
+		0x150:  {0xCD, 0x00, 0x40},             // CALL $4000
+		0x153:  {0x18, 0xFE},                   // JR $0153
+		0x4000: {0x3E, 0x01, 0xC3, 0x10, 0x40}, // Bank 1: LD A, $01; JP $4010
+		0x4010: {0xC9},                         // RET
+	})
+
+	a := Analyze(rom)
+	if !a.IsCallTarget(Location{Bank: 1, Addr: 0x4000}) {
+		t.Error("01:4000: expected call target")
+	}
+	for _, addr := range []uint16{0x4000, 0x4002, 0x4010} {
+		if !a.IsCode(Location{Bank: 1, Addr: addr}) {
+			t.Errorf("01:%04X: expected code", addr)
+		}
+	}
+	if a.IsCode(Location{Bank: 1, Addr: 0x4011}) {
+		t.Error("01:4011: expected data")
+	}
+}
+
+func TestWriteASM(t *testing.T) {
+	rom := testROM(map[int][]uint8{
+		0x100: {0x00, 0xC3, 0x50, 0x01}, // NOP; JP $0150
+		0x150: {0xCD, 0x00, 0x02},       // CALL $0200
+		0x153: {0x18, 0xFE},             // JR $0153
+		0x200: {0xF8, 0xFE, 0xE2, 0xC9}, // LD HL, SP-2; LD [C], A; RET
+	})
+
+	a := Analyze(rom)
+	labels := func(loc Location) (string, bool) {
+		if loc == (Location{Bank: 0, Addr: 0x150}) {
+			return "Main", true
+		}
+		return "", false
+	}
+
+	var sb strings.Builder
+	if err := a.WriteASM(&sb, labels); err != nil {
+		t.Fatal(err)
+	}
+	asm := sb.String()
+
+	for _, want := range []string{
+		"SECTION \"ROM Bank $000\", ROM0[$0000]",
+		"SECTION \"ROM Bank $001\", ROMX[$4000], BANK[$001]",
+		"\tjp Main\n",
+		"\nMain:\n\tcall Call_000_0200\n",
+		"\nJump_000_0153:\n\tjr Jump_000_0153\n",
+		"\nCall_000_0200:\n\tld hl, sp - 2\n\tld [$ff00+c], a\n\tret\n",
+		"\tdb $FF, $FF, $FF, $FF, $FF, $FF, $FF, $FF\n",
+	} {
+		if !strings.Contains(asm, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+}
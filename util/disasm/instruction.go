@@ -0,0 +1,105 @@
+package disasm
+
+import "strings"
+
+// FlowKind describes how an instruction affects the code flow
+type FlowKind uint8
+
+const (
+	FlowNone              FlowKind = iota
+	FlowJump                       // JP, JR
+	FlowConditionalJump            // JP cc, JR cc
+	FlowCall                       // CALL, RST
+	FlowConditionalCall            // CALL cc
+	FlowReturn                     // RET, RETI, JP HL (target unknown)
+	FlowConditionalReturn          // RET cc
+	FlowStop                       // Invalid opcodes (lock the CPU)
+)
+
+// Instruction is a decoded instruction
+type Instruction struct {
+	Addr  uint16
+	Bytes []uint8 // Opcode and operands
+}
+
+// Decode decodes the instruction at addr (read with the read function)
+func Decode(read func(uint16) uint8, addr uint16) Instruction {
+	opcode := read(addr)
+	bytes := []uint8{opcode}
+	for i := 1; i < Opcodes[opcode].Length; i++ {
+		bytes = append(bytes, read(addr+uint16(i)))
+	}
+	return Instruction{Addr: addr, Bytes: bytes}
+}
+
+// Name returns the instruction name, with the operand placeholder (n8, n16, e8, a8 or a16)
+func (in Instruction) Name() string {
+	if in.Bytes[0] == 0xCB {
+		return PrefixedOpcodes[in.Bytes[1]]
+	}
+	return Opcodes[in.Bytes[0]].Name
+}
+
+// Flow returns how the instruction affects the code flow
+func (in Instruction) Flow() FlowKind {
+	switch in.Bytes[0] {
+	case 0xC3, 0x18: // JP a16, JR e8
+		return FlowJump
+	case 0xC2, 0xCA, 0xD2, 0xDA, 0x20, 0x28, 0x30, 0x38: // JP cc, a16 and JR cc, e8
+		return FlowConditionalJump
+	case 0xCD, 0xC7, 0xCF, 0xD7, 0xDF, 0xE7, 0xEF, 0xF7, 0xFF: // CALL a16, RST
+		return FlowCall
+	case 0xC4, 0xCC, 0xD4, 0xDC: // CALL cc, a16
+		return FlowConditionalCall
+	case 0xC9, 0xD9, 0xE9: // RET, RETI, JP HL
+		return FlowReturn
+	case 0xC0, 0xC8, 0xD0, 0xD8: // RET cc
+		return FlowConditionalReturn
+	case 0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD:
+		return FlowStop
+	default:
+		return FlowNone
+	}
+}
+
+// Target returns the destination of jumps, calls and RSTs
+func (in Instruction) Target() (uint16, bool) {
+	opcode := in.Bytes[0]
+	switch {
+	case opcode&0xC7 == 0xC7: // RST
+		return uint16(opcode & 0x38), true
+	case opcode == 0x18 || opcode&0xE7 == 0x20: // JR
+		return in.Addr + 2 + uint16(int8(in.Bytes[1])), true
+	case in.Flow() != FlowNone && len(in.Bytes) == 3:
+		return uint16(in.Bytes[1]) | uint16(in.Bytes[2])<<8, true
+	default:
+		return 0, false
+	}
+}
+
+// Operand returns the operand placeholder of the instruction name and its value
+// (the target address for JR), false if the instruction has no operand
+func (in Instruction) Operand() (placeholder string, value int, ok bool) {
+	if in.Bytes[0] == 0xCB || len(in.Bytes) == 1 {
+		return "", 0, false
+	}
+
+	name := in.Name()
+	switch {
+	case strings.Contains(name, "n16"):
+		return "n16", int(in.Bytes[1]) | int(in.Bytes[2])<<8, true
+	case strings.Contains(name, "a16"):
+		return "a16", int(in.Bytes[1]) | int(in.Bytes[2])<<8, true
+	case strings.HasPrefix(name, "JR"):
+		target, _ := in.Target()
+		return "e8", int(target), true
+	case strings.Contains(name, "e8"):
+		return "e8", int(int8(in.Bytes[1])), true
+	case strings.Contains(name, "a8"):
+		return "a8", 0xFF00 | int(in.Bytes[1]), true
+	case strings.Contains(name, "n8"):
+		return "n8", int(in.Bytes[1]), true
+	default:
+		return "", 0, false
+	}
+}
@@ -0,0 +1,528 @@
+package disasm
+
+// Opcode describes an instruction. Placeholders in Name (n8, n16, e8, a8 and a16)
+// stand for the instruction operand, see Instruction.Format
+type Opcode struct {
+	Name   string
+	Length int // Including opcode
+}
+
+// Opcodes describes all the unprefixed instructions
+var Opcodes = [256]Opcode{
+	0x00: {Name: "NOP", Length: 1},
+	0x01: {Name: "LD BC, n16", Length: 3},
+	0x02: {Name: "LD [BC], A", Length: 1},
+	0x03: {Name: "INC BC", Length: 1},
+	0x04: {Name: "INC B", Length: 1},
+	0x05: {Name: "DEC B", Length: 1},
+	0x06: {Name: "LD B, n8", Length: 2},
+	0x07: {Name: "RLCA", Length: 1},
+	0x08: {Name: "LD [a16], SP", Length: 3},
+	0x09: {Name: "ADD HL, BC", Length: 1},
+	0x0A: {Name: "LD A, [BC]", Length: 1},
+	0x0B: {Name: "DEC BC", Length: 1},
+	0x0C: {Name: "INC C", Length: 1},
+	0x0D: {Name: "DEC C", Length: 1},
+	0x0E: {Name: "LD C, n8", Length: 2},
+	0x0F: {Name: "RRCA", Length: 1},
+	0x10: {Name: "STOP n8", Length: 2},
+	0x11: {Name: "LD DE, n16", Length: 3},
+	0x12: {Name: "LD [DE], A", Length: 1},
+	0x13: {Name: "INC DE", Length: 1},
+	0x14: {Name: "INC D", Length: 1},
+	0x15: {Name: "DEC D", Length: 1},
+	0x16: {Name: "LD D, n8", Length: 2},
+	0x17: {Name: "RLA", Length: 1},
+	0x18: {Name: "JR e8", Length: 2},
+	0x19: {Name: "ADD HL, DE", Length: 1},
+	0x1A: {Name: "LD A, [DE]", Length: 1},
+	0x1B: {Name: "DEC DE", Length: 1},
+	0x1C: {Name: "INC E", Length: 1},
+	0x1D: {Name: "DEC E", Length: 1},
+	0x1E: {Name: "LD E, n8", Length: 2},
+	0x1F: {Name: "RRA", Length: 1},
+	0x20: {Name: "JR NZ, e8", Length: 2},
+	0x21: {Name: "LD HL, n16", Length: 3},
+	0x22: {Name: "LD [HL+], A", Length: 1},
+	0x23: {Name: "INC HL", Length: 1},
+	0x24: {Name: "INC H", Length: 1},
+	0x25: {Name: "DEC H", Length: 1},
+	0x26: {Name: "LD H, n8", Length: 2},
+	0x27: {Name: "DAA", Length: 1},
+	0x28: {Name: "JR Z, e8", Length: 2},
+	0x29: {Name: "ADD HL, HL", Length: 1},
+	0x2A: {Name: "LD A, [HL+]", Length: 1},
+	0x2B: {Name: "DEC HL", Length: 1},
+	0x2C: {Name: "INC L", Length: 1},
+	0x2D: {Name: "DEC L", Length: 1},
+	0x2E: {Name: "LD L, n8", Length: 2},
+	0x2F: {Name: "CPL", Length: 1},
+	0x30: {Name: "JR NC, e8", Length: 2},
+	0x31: {Name: "LD SP, n16", Length: 3},
+	0x32: {Name: "LD [HL-], A", Length: 1},
+	0x33: {Name: "INC SP", Length: 1},
+	0x34: {Name: "INC [HL]", Length: 1},
+	0x35: {Name: "DEC [HL]", Length: 1},
+	0x36: {Name: "LD [HL], n8", Length: 2},
+	0x37: {Name: "SCF", Length: 1},
+	0x38: {Name: "JR C, e8", Length: 2},
+	0x39: {Name: "ADD HL, SP", Length: 1},
+	0x3A: {Name: "LD A, [HL-]", Length: 1},
+	0x3B: {Name: "DEC SP", Length: 1},
+	0x3C: {Name: "INC A", Length: 1},
+	0x3D: {Name: "DEC A", Length: 1},
+	0x3E: {Name: "LD A, n8", Length: 2},
+	0x3F: {Name: "CCF", Length: 1},
+	0x40: {Name: "LD B, B", Length: 1},
+	0x41: {Name: "LD B, C", Length: 1},
+	0x42: {Name: "LD B, D", Length: 1},
+	0x43: {Name: "LD B, E", Length: 1},
+	0x44: {Name: "LD B, H", Length: 1},
+	0x45: {Name: "LD B, L", Length: 1},
+	0x46: {Name: "LD B, [HL]", Length: 1},
+	0x47: {Name: "LD B, A", Length: 1},
+	0x48: {Name: "LD C, B", Length: 1},
+	0x49: {Name: "LD C, C", Length: 1},
+	0x4A: {Name: "LD C, D", Length: 1},
+	0x4B: {Name: "LD C, E", Length: 1},
+	0x4C: {Name: "LD C, H", Length: 1},
+	0x4D: {Name: "LD C, L", Length: 1},
+	0x4E: {Name: "LD C, [HL]", Length: 1},
+	0x4F: {Name: "LD C, A", Length: 1},
+	0x50: {Name: "LD D, B", Length: 1},
+	0x51: {Name: "LD D, C", Length: 1},
+	0x52: {Name: "LD D, D", Length: 1},
+	0x53: {Name: "LD D, E", Length: 1},
+	0x54: {Name: "LD D, H", Length: 1},
+	0x55: {Name: "LD D, L", Length: 1},
+	0x56: {Name: "LD D, [HL]", Length: 1},
+	0x57: {Name: "LD D, A", Length: 1},
+	0x58: {Name: "LD E, B", Length: 1},
+	0x59: {Name: "LD E, C", Length: 1},
+	0x5A: {Name: "LD E, D", Length: 1},
+	0x5B: {Name: "LD E, E", Length: 1},
+	0x5C: {Name: "LD E, H", Length: 1},
+	0x5D: {Name: "LD E, L", Length: 1},
+	0x5E: {Name: "LD E, [HL]", Length: 1},
+	0x5F: {Name: "LD E, A", Length: 1},
+	0x60: {Name: "LD H, B", Length: 1},
+	0x61: {Name: "LD H, C", Length: 1},
+	0x62: {Name: "LD H, D", Length: 1},
+	0x63: {Name: "LD H, E", Length: 1},
+	0x64: {Name: "LD H, H", Length: 1},
+	0x65: {Name: "LD H, L", Length: 1},
+	0x66: {Name: "LD H, [HL]", Length: 1},
+	0x67: {Name: "LD H, A", Length: 1},
+	0x68: {Name: "LD L, B", Length: 1},
+	0x69: {Name: "LD L, C", Length: 1},
+	0x6A: {Name: "LD L, D", Length: 1},
+	0x6B: {Name: "LD L, E", Length: 1},
+	0x6C: {Name: "LD L, H", Length: 1},
+	0x6D: {Name: "LD L, L", Length: 1},
+	0x6E: {Name: "LD L, [HL]", Length: 1},
+	0x6F: {Name: "LD L, A", Length: 1},
+	0x70: {Name: "LD [HL], B", Length: 1},
+	0x71: {Name: "LD [HL], C", Length: 1},
+	0x72: {Name: "LD [HL], D", Length: 1},
+	0x73: {Name: "LD [HL], E", Length: 1},
+	0x74: {Name: "LD [HL], H", Length: 1},
+	0x75: {Name: "LD [HL], L", Length: 1},
+	0x76: {Name: "HALT", Length: 1},
+	0x77: {Name: "LD [HL], A", Length: 1},
+	0x78: {Name: "LD A, B", Length: 1},
+	0x79: {Name: "LD A, C", Length: 1},
+	0x7A: {Name: "LD A, D", Length: 1},
+	0x7B: {Name: "LD A, E", Length: 1},
+	0x7C: {Name: "LD A, H", Length: 1},
+	0x7D: {Name: "LD A, L", Length: 1},
+	0x7E: {Name: "LD A, [HL]", Length: 1},
+	0x7F: {Name: "LD A, A", Length: 1},
+	0x80: {Name: "ADD A, B", Length: 1},
+	0x81: {Name: "ADD A, C", Length: 1},
+	0x82: {Name: "ADD A, D", Length: 1},
+	0x83: {Name: "ADD A, E", Length: 1},
+	0x84: {Name: "ADD A, H", Length: 1},
+	0x85: {Name: "ADD A, L", Length: 1},
+	0x86: {Name: "ADD A, [HL]", Length: 1},
+	0x87: {Name: "ADD A, A", Length: 1},
+	0x88: {Name: "ADC A, B", Length: 1},
+	0x89: {Name: "ADC A, C", Length: 1},
+	0x8A: {Name: "ADC A, D", Length: 1},
+	0x8B: {Name: "ADC A, E", Length: 1},
+	0x8C: {Name: "ADC A, H", Length: 1},
+	0x8D: {Name: "ADC A, L", Length: 1},
+	0x8E: {Name: "ADC A, [HL]", Length: 1},
+	0x8F: {Name: "ADC A, A", Length: 1},
+	0x90: {Name: "SUB A, B", Length: 1},
+	0x91: {Name: "SUB A, C", Length: 1},
+	0x92: {Name: "SUB A, D", Length: 1},
+	0x93: {Name: "SUB A, E", Length: 1},
+	0x94: {Name: "SUB A, H", Length: 1},
+	0x95: {Name: "SUB A, L", Length: 1},
+	0x96: {Name: "SUB A, [HL]", Length: 1},
+	0x97: {Name: "SUB A, A", Length: 1},
+	0x98: {Name: "SBC A, B", Length: 1},
+	0x99: {Name: "SBC A, C", Length: 1},
+	0x9A: {Name: "SBC A, D", Length: 1},
+	0x9B: {Name: "SBC A, E", Length: 1},
+	0x9C: {Name: "SBC A, H", Length: 1},
+	0x9D: {Name: "SBC A, L", Length: 1},
+	0x9E: {Name: "SBC A, [HL]", Length: 1},
+	0x9F: {Name: "SBC A, A", Length: 1},
+	0xA0: {Name: "AND A, B", Length: 1},
+	0xA1: {Name: "AND A, C", Length: 1},
+	0xA2: {Name: "AND A, D", Length: 1},
+	0xA3: {Name: "AND A, E", Length: 1},
+	0xA4: {Name: "AND A, H", Length: 1},
+	0xA5: {Name: "AND A, L", Length: 1},
+	0xA6: {Name: "AND A, [HL]", Length: 1},
+	0xA7: {Name: "AND A, A", Length: 1},
+	0xA8: {Name: "XOR A, B", Length: 1},
+	0xA9: {Name: "XOR A, C", Length: 1},
+	0xAA: {Name: "XOR A, D", Length: 1},
+	0xAB: {Name: "XOR A, E", Length: 1},
+	0xAC: {Name: "XOR A, H", Length: 1},
+	0xAD: {Name: "XOR A, L", Length: 1},
+	0xAE: {Name: "XOR A, [HL]", Length: 1},
+	0xAF: {Name: "XOR A, A", Length: 1},
+	0xB0: {Name: "OR A, B", Length: 1},
+	0xB1: {Name: "OR A, C", Length: 1},
+	0xB2: {Name: "OR A, D", Length: 1},
+	0xB3: {Name: "OR A, E", Length: 1},
+	0xB4: {Name: "OR A, H", Length: 1},
+	0xB5: {Name: "OR A, L", Length: 1},
+	0xB6: {Name: "OR A, [HL]", Length: 1},
+	0xB7: {Name: "OR A, A", Length: 1},
+	0xB8: {Name: "CP A, B", Length: 1},
+	0xB9: {Name: "CP A, C", Length: 1},
+	0xBA: {Name: "CP A, D", Length: 1},
+	0xBB: {Name: "CP A, E", Length: 1},
+	0xBC: {Name: "CP A, H", Length: 1},
+	0xBD: {Name: "CP A, L", Length: 1},
+	0xBE: {Name: "CP A, [HL]", Length: 1},
+	0xBF: {Name: "CP A, A", Length: 1},
+	0xC0: {Name: "RET NZ", Length: 1},
+	0xC1: {Name: "POP BC", Length: 1},
+	0xC2: {Name: "JP NZ, a16", Length: 3},
+	0xC3: {Name: "JP a16", Length: 3},
+	0xC4: {Name: "CALL NZ, a16", Length: 3},
+	0xC5: {Name: "PUSH BC", Length: 1},
+	0xC6: {Name: "ADD A, n8", Length: 2},
+	0xC7: {Name: "RST 00H", Length: 1},
+	0xC8: {Name: "RET Z", Length: 1},
+	0xC9: {Name: "RET", Length: 1},
+	0xCA: {Name: "JP Z, a16", Length: 3},
+	0xCB: {Name: "PREFIX CB", Length: 2},
+	0xCC: {Name: "CALL Z, a16", Length: 3},
+	0xCD: {Name: "CALL a16", Length: 3},
+	0xCE: {Name: "ADC A, n8", Length: 2},
+	0xCF: {Name: "RST 08H", Length: 1},
+	0xD0: {Name: "RET NC", Length: 1},
+	0xD1: {Name: "POP DE", Length: 1},
+	0xD2: {Name: "JP NC, a16", Length: 3},
+	0xD3: {Name: "INVALID", Length: 1},
+	0xD4: {Name: "CALL NC, a16", Length: 3},
+	0xD5: {Name: "PUSH DE", Length: 1},
+	0xD6: {Name: "SUB A, n8", Length: 2},
+	0xD7: {Name: "RST 10H", Length: 1},
+	0xD8: {Name: "RET C", Length: 1},
+	0xD9: {Name: "RETI", Length: 1},
+	0xDA: {Name: "JP C, a16", Length: 3},
+	0xDB: {Name: "INVALID", Length: 1},
+	0xDC: {Name: "CALL C, a16", Length: 3},
+	0xDD: {Name: "INVALID", Length: 1},
+	0xDE: {Name: "SBC A, n8", Length: 2},
+	0xDF: {Name: "RST 18H", Length: 1},
+	0xE0: {Name: "LDH [a8], A", Length: 2},
+	0xE1: {Name: "POP HL", Length: 1},
+	0xE2: {Name: "LD [C], A", Length: 1},
+	0xE3: {Name: "INVALID", Length: 1},
+	0xE4: {Name: "INVALID", Length: 1},
+	0xE5: {Name: "PUSH HL", Length: 1},
+	0xE6: {Name: "AND A, n8", Length: 2},
+	0xE7: {Name: "RST 20H", Length: 1},
+	0xE8: {Name: "ADD SP, e8", Length: 2},
+	0xE9: {Name: "JP HL", Length: 1},
+	0xEA: {Name: "LD [a16], A", Length: 3},
+	0xEB: {Name: "INVALID", Length: 1},
+	0xEC: {Name: "INVALID", Length: 1},
+	0xED: {Name: "INVALID", Length: 1},
+	0xEE: {Name: "XOR A, n8", Length: 2},
+	0xEF: {Name: "RST 28H", Length: 1},
+	0xF0: {Name: "LDH A, [a8]", Length: 2},
+	0xF1: {Name: "POP AF", Length: 1},
+	0xF2: {Name: "LD A, [C]", Length: 1},
+	0xF3: {Name: "DI", Length: 1},
+	0xF4: {Name: "INVALID", Length: 1},
+	0xF5: {Name: "PUSH AF", Length: 1},
+	0xF6: {Name: "OR A, n8", Length: 2},
+	0xF7: {Name: "RST 30H", Length: 1},
+	0xF8: {Name: "LD HL, SP+e8", Length: 2},
+	0xF9: {Name: "LD SP, HL", Length: 1},
+	0xFA: {Name: "LD A, [a16]", Length: 3},
+	0xFB: {Name: "EI", Length: 1},
+	0xFC: {Name: "INVALID", Length: 1},
+	0xFD: {Name: "INVALID", Length: 1},
+	0xFE: {Name: "CP A, n8", Length: 2},
+	0xFF: {Name: "RST 38H", Length: 1},
+}
+
+// PrefixedOpcodes names the instructions prefixed by $CB (indexed by the second byte)
+var PrefixedOpcodes = [256]string{
+	0x00: "RLC B",
+	0x01: "RLC C",
+	0x02: "RLC D",
+	0x03: "RLC E",
+	0x04: "RLC H",
+	0x05: "RLC L",
+	0x06: "RLC [HL]",
+	0x07: "RLC A",
+	0x08: "RRC B",
+	0x09: "RRC C",
+	0x0A: "RRC D",
+	0x0B: "RRC E",
+	0x0C: "RRC H",
+	0x0D: "RRC L",
+	0x0E: "RRC [HL]",
+	0x0F: "RRC A",
+	0x10: "RL B",
+	0x11: "RL C",
+	0x12: "RL D",
+	0x13: "RL E",
+	0x14: "RL H",
+	0x15: "RL L",
+	0x16: "RL [HL]",
+	0x17: "RL A",
+	0x18: "RR B",
+	0x19: "RR C",
+	0x1A: "RR D",
+	0x1B: "RR E",
+	0x1C: "RR H",
+	0x1D: "RR L",
+	0x1E: "RR [HL]",
+	0x1F: "RR A",
+	0x20: "SLA B",
+	0x21: "SLA C",
+	0x22: "SLA D",
+	0x23: "SLA E",
+	0x24: "SLA H",
+	0x25: "SLA L",
+	0x26: "SLA [HL]",
+	0x27: "SLA A",
+	0x28: "SRA B",
+	0x29: "SRA C",
+	0x2A: "SRA D",
+	0x2B: "SRA E",
+	0x2C: "SRA H",
+	0x2D: "SRA L",
+	0x2E: "SRA [HL]",
+	0x2F: "SRA A",
+	0x30: "SWAP B",
+	0x31: "SWAP C",
+	0x32: "SWAP D",
+	0x33: "SWAP E",
+	0x34: "SWAP H",
+	0x35: "SWAP L",
+	0x36: "SWAP [HL]",
+	0x37: "SWAP A",
+	0x38: "SRL B",
+	0x39: "SRL C",
+	0x3A: "SRL D",
+	0x3B: "SRL E",
+	0x3C: "SRL H",
+	0x3D: "SRL L",
+	0x3E: "SRL [HL]",
+	0x3F: "SRL A",
+	0x40: "BIT 0, B",
+	0x41: "BIT 0, C",
+	0x42: "BIT 0, D",
+	0x43: "BIT 0, E",
+	0x44: "BIT 0, H",
+	0x45: "BIT 0, L",
+	0x46: "BIT 0, [HL]",
+	0x47: "BIT 0, A",
+	0x48: "BIT 1, B",
+	0x49: "BIT 1, C",
+	0x4A: "BIT 1, D",
+	0x4B: "BIT 1, E",
+	0x4C: "BIT 1, H",
+	0x4D: "BIT 1, L",
+	0x4E: "BIT 1, [HL]",
+	0x4F: "BIT 1, A",
+	0x50: "BIT 2, B",
+	0x51: "BIT 2, C",
+	0x52: "BIT 2, D",
+	0x53: "BIT 2, E",
+	0x54: "BIT 2, H",
+	0x55: "BIT 2, L",
+	0x56: "BIT 2, [HL]",
+	0x57: "BIT 2, A",
+	0x58: "BIT 3, B",
+	0x59: "BIT 3, C",
+	0x5A: "BIT 3, D",
+	0x5B: "BIT 3, E",
+	0x5C: "BIT 3, H",
+	0x5D: "BIT 3, L",
+	0x5E: "BIT 3, [HL]",
+	0x5F: "BIT 3, A",
+	0x60: "BIT 4, B",
+	0x61: "BIT 4, C",
+	0x62: "BIT 4, D",
+	0x63: "BIT 4, E",
+	0x64: "BIT 4, H",
+	0x65: "BIT 4, L",
+	0x66: "BIT 4, [HL]",
+	0x67: "BIT 4, A",
+	0x68: "BIT 5, B",
+	0x69: "BIT 5, C",
+	0x6A: "BIT 5, D",
+	0x6B: "BIT 5, E",
+	0x6C: "BIT 5, H",
+	0x6D: "BIT 5, L",
+	0x6E: "BIT 5, [HL]",
+	0x6F: "BIT 5, A",
+	0x70: "BIT 6, B",
+	0x71: "BIT 6, C",
+	0x72: "BIT 6, D",
+	0x73: "BIT 6, E",
+	0x74: "BIT 6, H",
+	0x75: "BIT 6, L",
+	0x76: "BIT 6, [HL]",
+	0x77: "BIT 6, A",
+	0x78: "BIT 7, B",
+	0x79: "BIT 7, C",
+	0x7A: "BIT 7, D",
+	0x7B: "BIT 7, E",
+	0x7C: "BIT 7, H",
+	0x7D: "BIT 7, L",
+	0x7E: "BIT 7, [HL]",
+	0x7F: "BIT 7, A",
+	0x80: "RES 0, B",
+	0x81: "RES 0, C",
+	0x82: "RES 0, D",
+	0x83: "RES 0, E",
+	0x84: "RES 0, H",
+	0x85: "RES 0, L",
+	0x86: "RES 0, [HL]",
+	0x87: "RES 0, A",
+	0x88: "RES 1, B",
+	0x89: "RES 1, C",
+	0x8A: "RES 1, D",
+	0x8B: "RES 1, E",
+	0x8C: "RES 1, H",
+	0x8D: "RES 1, L",
+	0x8E: "RES 1, [HL]",
+	0x8F: "RES 1, A",
+	0x90: "RES 2, B",
+	0x91: "RES 2, C",
+	0x92: "RES 2, D",
+	0x93: "RES 2, E",
+	0x94: "RES 2, H",
+	0x95: "RES 2, L",
+	0x96: "RES 2, [HL]",
+	0x97: "RES 2, A",
+	0x98: "RES 3, B",
+	0x99: "RES 3, C",
+	0x9A: "RES 3, D",
+	0x9B: "RES 3, E",
+	0x9C: "RES 3, H",
+	0x9D: "RES 3, L",
+	0x9E: "RES 3, [HL]",
+	0x9F: "RES 3, A",
+	0xA0: "RES 4, B",
+	0xA1: "RES 4, C",
+	0xA2: "RES 4, D",
+	0xA3: "RES 4, E",
+	0xA4: "RES 4, H",
+	0xA5: "RES 4, L",
+	0xA6: "RES 4, [HL]",
+	0xA7: "RES 4, A",
+	0xA8: "RES 5, B",
+	0xA9: "RES 5, C",
+	0xAA: "RES 5, D",
+	0xAB: "RES 5, E",
+	0xAC: "RES 5, H",
+	0xAD: "RES 5, L",
+	0xAE: "RES 5, [HL]",
+	0xAF: "RES 5, A",
+	0xB0: "RES 6, B",
+	0xB1: "RES 6, C",
+	0xB2: "RES 6, D",
+	0xB3: "RES 6, E",
+	0xB4: "RES 6, H",
+	0xB5: "RES 6, L",
+	0xB6: "RES 6, [HL]",
+	0xB7: "RES 6, A",
+	0xB8: "RES 7, B",
+	0xB9: "RES 7, C",
+	0xBA: "RES 7, D",
+	0xBB: "RES 7, E",
+	0xBC: "RES 7, H",
+	0xBD: "RES 7, L",
+	0xBE: "RES 7, [HL]",
+	0xBF: "RES 7, A",
+	0xC0: "SET 0, B",
+	0xC1: "SET 0, C",
+	0xC2: "SET 0, D",
+	0xC3: "SET 0, E",
+	0xC4: "SET 0, H",
+	0xC5: "SET 0, L",
+	0xC6: "SET 0, [HL]",
+	0xC7: "SET 0, A",
+	0xC8: "SET 1, B",
+	0xC9: "SET 1, C",
+	0xCA: "SET 1, D",
+	0xCB: "SET 1, E",
+	0xCC: "SET 1, H",
+	0xCD: "SET 1, L",
+	0xCE: "SET 1, [HL]",
+	0xCF: "SET 1, A",
+	0xD0: "SET 2, B",
+	0xD1: "SET 2, C",
+	0xD2: "SET 2, D",
+	0xD3: "SET 2, E",
+	0xD4: "SET 2, H",
+	0xD5: "SET 2, L",
+	0xD6: "SET 2, [HL]",
+	0xD7: "SET 2, A",
+	0xD8: "SET 3, B",
+	0xD9: "SET 3, C",
+	0xDA: "SET 3, D",
+	0xDB: "SET 3, E",
+	0xDC: "SET 3, H",
+	0xDD: "SET 3, L",
+	0xDE: "SET 3, [HL]",
+	0xDF: "SET 3, A",
+	0xE0: "SET 4, B",
+	0xE1: "SET 4, C",
+	0xE2: "SET 4, D",
+	0xE3: "SET 4, E",
+	0xE4: "SET 4, H",
+	0xE5: "SET 4, L",
+	0xE6: "SET 4, [HL]",
+	0xE7: "SET 4, A",
+	0xE8: "SET 5, B",
+	0xE9: "SET 5, C",
+	0xEA: "SET 5, D",
+	0xEB: "SET 5, E",
+	0xEC: "SET 5, H",
+	0xED: "SET 5, L",
+	0xEE: "SET 5, [HL]",
+	0xEF: "SET 5, A",
+	0xF0: "SET 6, B",
+	0xF1: "SET 6, C",
+	0xF2: "SET 6, D",
+	0xF3: "SET 6, E",
+	0xF4: "SET 6, H",
+	0xF5: "SET 6, L",
+	0xF6: "SET 6, [HL]",
+	0xF7: "SET 6, A",
+	0xF8: "SET 7, B",
+	0xF9: "SET 7, C",
+	0xFA: "SET 7, D",
+	0xFB: "SET 7, E",
+	0xFC: "SET 7, H",
+	0xFD: "SET 7, L",
+	0xFE: "SET 7, [HL]",
+	0xFF: "SET 7, A",
+}
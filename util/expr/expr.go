@@ -0,0 +1,131 @@
+// Package expr implements the small expression language used by the debugger
+// for breakpoint conditions and tracepoint messages, e.g. `A == $3F && [wPlayerHP] < 10`.
+//
+// Operands are numbers (decimal, $FF or 0xFF hexadecimal, %1010 binary), variables
+// (registers and emulator state, resolved by the Env), symbols and memory reads ([addr]).
+// Operators, from lowest to highest precedence:
+//
+//	||
+//	&&
+//	== != < <= > >=
+//	|
+//	^
+//	&
+//	<< >>
+//	+ -
+//	* / %
+//	! - ~ (unary)
+//
+// Comparisons and logical operators evaluate to 1 (true) or 0 (false).
+package expr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Env provides the values referenced by an expression
+type Env interface {
+	// Variable returns the value of a variable (register or emulator state)
+	Variable(name string) (int, bool)
+	// Symbol returns the address of a symbol
+	Symbol(name string) (int, bool)
+	// Read returns the byte at addr
+	Read(addr uint16) uint8
+}
+
+var ErrDivisionByZero = errors.New("division by zero")
+
+// node evaluates a subexpression
+type node func(env Env) (int, error)
+
+// Expr is a parsed expression
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression
+func Parse(src string) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval evaluates the expression
+func (e *Expr) Eval(env Env) (int, error) {
+	return e.root(env)
+}
+
+// True evaluates the expression as a condition (true if not zero)
+func (e *Expr) True(env Env) (bool, error) {
+	v, err := e.root(env)
+	return v != 0, err
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// binaryOperators maps each operator to its implementation
+var binaryOperators = map[string]func(a, b int) (int, error){
+	"||": func(a, b int) (int, error) { return boolToInt(a != 0 || b != 0), nil },
+	"&&": func(a, b int) (int, error) { return boolToInt(a != 0 && b != 0), nil },
+	"==": func(a, b int) (int, error) { return boolToInt(a == b), nil },
+	"!=": func(a, b int) (int, error) { return boolToInt(a != b), nil },
+	"<":  func(a, b int) (int, error) { return boolToInt(a < b), nil },
+	"<=": func(a, b int) (int, error) { return boolToInt(a <= b), nil },
+	">":  func(a, b int) (int, error) { return boolToInt(a > b), nil },
+	">=": func(a, b int) (int, error) { return boolToInt(a >= b), nil },
+	"|":  func(a, b int) (int, error) { return a | b, nil },
+	"^":  func(a, b int) (int, error) { return a ^ b, nil },
+	"&":  func(a, b int) (int, error) { return a & b, nil },
+	"<<": func(a, b int) (int, error) { return a << (b & 63), nil },
+	">>": func(a, b int) (int, error) { return a >> (b & 63), nil },
+	"+":  func(a, b int) (int, error) { return a + b, nil },
+	"-":  func(a, b int) (int, error) { return a - b, nil },
+	"*":  func(a, b int) (int, error) { return a * b, nil },
+	"/": func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a / b, nil
+	},
+	"%": func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a % b, nil
+	},
+}
+
+// precedenceLevels lists binary operators from lowest to highest precedence
+var precedenceLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+type testEnv struct {
+	variables map[string]int
+	symbols   map[string]int
+	memory    map[uint16]uint8
+}
+
+func (env *testEnv) Variable(name string) (int, bool) {
+	v, ok := env.variables[strings.ToUpper(name)]
+	return v, ok
+}
+
+func (env *testEnv) Symbol(name string) (int, bool) {
+	v, ok := env.symbols[name]
+	return v, ok
+}
+
+func (env *testEnv) Read(addr uint16) uint8 {
+	return env.memory[addr]
+}
+
+func newTestEnv() *testEnv {
+	return &testEnv{
+		variables: map[string]int{"A": 0x3F, "HL": 0xC000, "ZF": 1, "LY": 144},
+		symbols:   map[string]int{"wPlayerHP": 0xC100, "Main.loop": 0x0150},
+		memory:    map[uint16]uint8{0xC000: 0x12, 0xC100: 7},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{"42", 42},
+		{"$FF", 0xFF},
+		{"0x1234", 0x1234},
+		{"%1010", 10},
+		{"A", 0x3F},
+		{"a == $3F", 1},
+		{"A == $3F && [wPlayerHP] < 10", 1},
+		{"A != $3F || [wPlayerHP] >= 10", 0},
+		{"[HL]", 0x12},
+		{"[$C000] + 1", 0x13},
+		{"[HL + $100]", 7},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 % 4", 2},
+		{"A %10", 0x3F % 10},
+		{"1 << 4 | 1", 0x11},
+		{"A & $0F ^ 1", 0x0E},
+		{"-1 + 2", 1},
+		{"!ZF", 0},
+		{"~0 & $FF", 0xFF},
+		{"LY >= 144 && LY <= 153", 1},
+		{"Main.loop", 0x0150},
+	}
+
+	env := newTestEnv()
+	for _, test := range tests {
+		e, err := Parse(test.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.src, err)
+			continue
+		}
+		got, err := e.Eval(env)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", test.src, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Eval(%q): expected %d, got %d", test.src, test.want, got)
+		}
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	// Right side would fail (unknown symbol) if evaluated
+	for _, src := range []string{"0 && missing", "1 || missing"} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		if _, err := e.Eval(newTestEnv()); err != nil {
+			t.Errorf("Eval(%q): %v", src, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "[HL", "1 2", "$", "A @ 1"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q): expected error", src)
+		}
+	}
+
+	for _, src := range []string{"missing", "1 / 0", "1 % (A - $3F)"} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", src, err)
+			continue
+		}
+		if _, err := e.Eval(newTestEnv()); err == nil {
+			t.Errorf("Eval(%q): expected error", src)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("HP={[wPlayerHP]:d} A={A} HL={HL} Z={ZF:b}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "HP=7 A=$3F HL=$C000 Z=%00000001"
+	if got := tmpl.Format(newTestEnv()); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := ParseTemplate("HP={[wPlayerHP]"); err == nil {
+		t.Error("expected error for missing '}'")
+	}
+}
@@ -0,0 +1,274 @@
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value int // Only for numbers
+	pos   int
+}
+
+// Operators sorted so that longer ones are matched first
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=", "<<", ">>",
+	"<", ">", "|", "^", "&", "+", "-", "*", "/", "%", "!", "~", "(", ")", "[", "]",
+}
+
+func isIdentStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_' || c == '.'
+}
+
+func isIdentChar(c rune) bool {
+	return isIdentStart(c) || unicode.IsDigit(c) || c == '@' || c == '#'
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+
+		// Hexadecimal ($FF, 0xFF), binary (%1010) and decimal numbers
+		case c == '$' || unicode.IsDigit(c) ||
+			c == '%' && i+1 < len(runes) && (runes[i+1] == '0' || runes[i+1] == '1') && !lastIsOperand(tokens):
+			base, digits := 10, i
+			switch {
+			case c == '$':
+				base, digits = 16, i+1
+			case c == '%':
+				base, digits = 2, i+1
+			case c == '0' && i+1 < len(runes) && (runes[i+1] == 'x' || runes[i+1] == 'X'):
+				base, digits = 16, i+2
+			}
+
+			i = digits
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			v, err := strconv.ParseInt(string(runes[digits:i]), base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", string(runes[start:i]), start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), value: int(v), pos: start})
+
+		case isIdentStart(c):
+			for i < len(runes) && isIdentChar(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			rest := string(runes[i:])
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(rest, o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+// lastIsOperand returns true if the last token ends an operand (so % is the modulo operator)
+func lastIsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenNumber || last.kind == tokenIdent || last.text == ")" || last.text == "]"
+}
+
+// parser is a recursive descent parser building the evaluation tree
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokenOperator || tok.text != op {
+		return fmt.Errorf("expected %q at %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(0)
+}
+
+// parseBinary parses operators with precedence level or higher (left associative)
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedenceLevels) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOperator || !slices.Contains(precedenceLevels[level], tok.text) {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = newBinaryNode(tok.text, left, right)
+	}
+}
+
+func newBinaryNode(op string, left, right node) node {
+	apply := binaryOperators[op]
+
+	// Short circuit logical operators
+	switch op {
+	case "&&", "||":
+		return func(env Env) (int, error) {
+			a, err := left(env)
+			if err != nil {
+				return 0, err
+			}
+			if (op == "&&") == (a == 0) {
+				return boolToInt(a != 0), nil
+			}
+			b, err := right(env)
+			if err != nil {
+				return 0, err
+			}
+			return boolToInt(b != 0), nil
+		}
+	}
+
+	return func(env Env) (int, error) {
+		a, err := left(env)
+		if err != nil {
+			return 0, err
+		}
+		b, err := right(env)
+		if err != nil {
+			return 0, err
+		}
+		return apply(a, b)
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokenOperator && (tok.text == "!" || tok.text == "-" || tok.text == "~") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(env Env) (int, error) {
+			v, err := operand(env)
+			switch tok.text {
+			case "!":
+				return boolToInt(v == 0), err
+			case "-":
+				return -v, err
+			default:
+				return ^v, err
+			}
+		}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		return func(Env) (int, error) { return tok.value, nil }, nil
+
+	case tokenIdent:
+		name := tok.text
+		return func(env Env) (int, error) {
+			if v, ok := env.Variable(name); ok {
+				return v, nil
+			}
+			if v, ok := env.Symbol(name); ok {
+				return v, nil
+			}
+			return 0, fmt.Errorf("unknown variable or symbol %q", name)
+		}, nil
+
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+
+		case "[": // Memory read
+			addr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return func(env Env) (int, error) {
+				a, err := addr(env)
+				if err != nil {
+					return 0, err
+				}
+				return int(env.Read(uint16(a))), nil
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
@@ -0,0 +1,93 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Template is a message with embedded expressions, e.g. "HP={[wPlayerHP]:d} at {PC}".
+// Expressions are printed in hexadecimal unless a format is specified after
+// a colon: d (decimal), x (hexadecimal) or b (binary)
+type Template struct {
+	src   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	text   string
+	expr   *Expr // nil for literal text
+	format byte
+}
+
+// ParseTemplate parses a message template
+func ParseTemplate(src string) (*Template, error) {
+	t := &Template{src: src}
+
+	rest := src
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		closing := strings.IndexByte(rest[open:], '}')
+		if closing < 0 {
+			return nil, fmt.Errorf("missing '}' in %q", rest[open:])
+		}
+		closing += open
+
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{text: rest[:open]})
+		}
+
+		exprStr, format := rest[open+1:closing], byte('x')
+		if i := strings.LastIndexByte(exprStr, ':'); i >= 0 {
+			f := strings.TrimSpace(exprStr[i+1:])
+			if f != "d" && f != "x" && f != "b" {
+				return nil, fmt.Errorf("invalid format %q", f)
+			}
+			exprStr, format = exprStr[:i], f[0]
+		}
+		e, err := Parse(exprStr)
+		if err != nil {
+			return nil, err
+		}
+		t.parts = append(t.parts, templatePart{expr: e, format: format})
+
+		rest = rest[closing+1:]
+	}
+	if rest != "" {
+		t.parts = append(t.parts, templatePart{text: rest})
+	}
+
+	return t, nil
+}
+
+// Format evaluates the expressions of the template (errors are printed inline)
+func (t *Template) Format(env Env) string {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if part.expr == nil {
+			sb.WriteString(part.text)
+			continue
+		}
+
+		v, err := part.expr.Eval(env)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&sb, "<%v>", err)
+		case part.format == 'd':
+			fmt.Fprintf(&sb, "%d", v)
+		case part.format == 'b':
+			fmt.Fprintf(&sb, "%%%08b", v)
+		case v >= 0 && v <= 0xFF:
+			fmt.Fprintf(&sb, "$%02X", v)
+		default:
+			fmt.Fprintf(&sb, "$%04X", v)
+		}
+	}
+	return sb.String()
+}
+
+func (t *Template) String() string {
+	return t.src
+}
@@ -0,0 +1,127 @@
+// Package romfile reads Game Boy ROMs, extracting them from .zip and .gz archives.
+// A zip archive can contain several ROMs: Entries lists them and Read extracts one
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNoROM is returned when an archive contains no Game Boy ROM
+var ErrNoROM = errors.New("no Game Boy ROM found")
+
+// IsROMName returns true if the file name has a Game Boy ROM extension
+func IsROMName(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".gb" || ext == ".gbc"
+}
+
+func isZip(romPath string) bool {
+	return strings.EqualFold(filepath.Ext(romPath), ".zip")
+}
+
+func isGzip(romPath string) bool {
+	return strings.EqualFold(filepath.Ext(romPath), ".gz")
+}
+
+// Entries returns the names of the ROMs of a zip archive, in the order they are stored.
+// Other files (ROMs and gzip archives) have a single entry, their base name
+func Entries(romPath string) ([]string, error) {
+	if !isZip(romPath) {
+		if _, err := os.Stat(romPath); err != nil {
+			return nil, err
+		}
+		return []string{filepath.Base(romPath)}, nil
+	}
+
+	r, err := zip.OpenReader(romPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []string
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && IsROMName(f.Name) {
+			entries = append(entries, f.Name)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: %w", romPath, ErrNoROM)
+	}
+	return entries, nil
+}
+
+// Read reads a ROM, extracting the entry (see Entries) if the file is an archive.
+// The returned path is where the ROM would be if it was not archived (next to the
+// archive, with the entry name), used to locate save files and patches
+func Read(romPath, entry string) ([]uint8, string, error) {
+	switch {
+	case isZip(romPath):
+		return readZip(romPath, entry)
+	case isGzip(romPath):
+		return readGzip(romPath)
+	default:
+		data, err := os.ReadFile(romPath)
+		return data, romPath, err
+	}
+}
+
+func readZip(archivePath, entry string) ([]uint8, string, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	i := slices.IndexFunc(r.File, func(f *zip.File) bool { return f.Name == entry })
+	if i < 0 || !IsROMName(entry) {
+		return nil, "", fmt.Errorf("%s: no ROM named %q", archivePath, entry)
+	}
+
+	f, err := r.File[i].Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, filepath.Join(filepath.Dir(archivePath), path.Base(entry)), nil
+}
+
+func readGzip(archivePath string) ([]uint8, string, error) {
+	compressed, err := os.ReadFile(archivePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Use the original file name if stored in the archive, otherwise remove .gz extension
+	name := path.Base(r.Name)
+	if r.Name == "" || !IsROMName(name) {
+		name = strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+	}
+	return data, filepath.Join(filepath.Dir(archivePath), name), nil
+}
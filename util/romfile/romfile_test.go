@@ -0,0 +1,115 @@
+package romfile
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string, order []string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, name := range order {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(files[name]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"readme.txt": "text", "roms/game.gb": "dmg", "game (color).GBC": "cgb"}
+
+	single := filepath.Join(dir, "single.zip")
+	writeZip(t, single, files, []string{"readme.txt", "roms/game.gb"})
+	entries, err := Entries(single)
+	if err != nil || !slices.Equal(entries, []string{"roms/game.gb"}) {
+		t.Fatalf("single: got %q, %v", entries, err)
+	}
+	data, romPath, err := Read(single, entries[0])
+	if err != nil || string(data) != "dmg" || romPath != filepath.Join(dir, "game.gb") {
+		t.Errorf("single: got %q at %s, %v", data, romPath, err)
+	}
+
+	multi := filepath.Join(dir, "multi.zip")
+	writeZip(t, multi, files, []string{"roms/game.gb", "readme.txt", "game (color).GBC"})
+	entries, err = Entries(multi)
+	if err != nil || !slices.Equal(entries, []string{"roms/game.gb", "game (color).GBC"}) {
+		t.Fatalf("multi: got %q, %v", entries, err)
+	}
+	data, romPath, err = Read(multi, entries[1])
+	if err != nil || string(data) != "cgb" || romPath != filepath.Join(dir, "game (color).GBC") {
+		t.Errorf("multi: got %q at %s, %v", data, romPath, err)
+	}
+	if _, _, err := Read(multi, "readme.txt"); err == nil {
+		t.Error("multi: read a file that is not a ROM")
+	}
+
+	none := filepath.Join(dir, "none.zip")
+	writeZip(t, none, files, []string{"readme.txt"})
+	if _, err := Entries(none); !errors.Is(err, ErrNoROM) {
+		t.Errorf("no ROM: got error %v", err)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	dir := t.TempDir()
+	for _, test := range []struct {
+		archive, name, expected string
+	}{
+		{"named.gz", "original.gbc", "original.gbc"},
+		{"game.gb.gz", "", "game.gb"},
+	} {
+		path := filepath.Join(dir, test.archive)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := gzip.NewWriter(f)
+		w.Name = test.name
+		w.Write([]byte("rom"))
+		w.Close()
+		f.Close()
+
+		entries, err := Entries(path)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("%s: got entries %q, %v", test.archive, entries, err)
+		}
+		data, romPath, err := Read(path, entries[0])
+		if err != nil || string(data) != "rom" || romPath != filepath.Join(dir, test.expected) {
+			t.Errorf("%s: got %q at %s, %v", test.archive, data, romPath, err)
+		}
+	}
+}
+
+func TestPlainROM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.gb")
+	if err := os.WriteFile(path, []byte("rom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Entries(path)
+	if err != nil || !slices.Equal(entries, []string{"game.gb"}) {
+		t.Fatalf("got entries %q, %v", entries, err)
+	}
+	if data, romPath, err := Read(path, entries[0]); err != nil || string(data) != "rom" || romPath != path {
+		t.Errorf("got %q at %s, %v", data, romPath, err)
+	}
+
+	if _, err := Entries(path + ".missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: got error %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+// Package snapshot takes in-memory copies of the emulator state and writes them back.
+//
+// Take deep-copies everything reachable from a pointer, unexported fields included.
+// Restore writes the copy back into the original objects instead of replacing them,
+// so pointers held elsewhere (closures, the UI, other components) stay valid.
+//
+// Funcs and channels are copied by reference. Struct fields tagged `snapshot:"-"`
+// are neither copied nor restored: they are meant for configuration (hooks, sockets)
+// and for data that never changes (ROM). Slices sharing a backing array from
+// different offsets are copied separately.
+package snapshot
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+type key struct {
+	addr unsafe.Pointer
+	t    reflect.Type
+}
+
+// pair is an object (pointed by a pointer or the backing array of a slice) and its copy
+type pair struct {
+	orig, copy reflect.Value
+}
+
+// Snapshot is a copy of the objects reachable from a root
+type Snapshot struct {
+	pairs []pair
+
+	// Copy address -> original object (pointers and full slices)
+	originals map[key]reflect.Value
+}
+
+// Take copies the objects reachable from root (a non-nil pointer)
+func Take(root any) *Snapshot {
+	v := reflect.ValueOf(root)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		panic("snapshot: root must be a non-nil pointer")
+	}
+
+	c := &cloner{copies: make(map[key]reflect.Value)}
+	c.clonePointer(v)
+
+	s := &Snapshot{
+		pairs:     c.pairs,
+		originals: make(map[key]reflect.Value, len(c.pairs)),
+	}
+	for _, p := range c.pairs {
+		s.originals[keyOf(p.copy)] = p.orig
+	}
+	return s
+}
+
+// Restore writes the snapshot back into the objects it was taken from.
+// The snapshot can be restored again later
+func (s *Snapshot) Restore() {
+	for _, p := range s.pairs {
+		if p.orig.Kind() == reflect.Pointer {
+			s.restore(p.orig.Elem(), p.copy.Elem())
+			continue
+		}
+
+		// Slice backing array
+		if isPlain(p.orig.Type().Elem()) {
+			reflect.Copy(p.orig, p.copy)
+			continue
+		}
+		for i := range p.orig.Len() {
+			s.restore(p.orig.Index(i), p.copy.Index(i))
+		}
+	}
+}
+
+func keyOf(v reflect.Value) key {
+	return key{v.UnsafePointer(), v.Type()}
+}
+
+type cloner struct {
+	copies map[key]reflect.Value // Original -> copy
+	pairs  []pair
+}
+
+func (c *cloner) clonePointer(v reflect.Value) reflect.Value {
+	k := keyOf(v)
+	if cp, ok := c.copies[k]; ok {
+		return cp
+	}
+
+	cp := reflect.New(v.Type().Elem())
+	c.copies[k] = cp
+	c.pairs = append(c.pairs, pair{orig: v, copy: cp})
+	c.copy(cp.Elem(), v.Elem())
+	return cp
+}
+
+// cloneSlice copies the slice up to its capacity and returns the copy with the same length
+func (c *cloner) cloneSlice(v reflect.Value) reflect.Value {
+	full := v.Slice3(0, v.Cap(), v.Cap())
+	k := keyOf(full)
+	if cp, ok := c.copies[k]; ok {
+		return cp.Slice(0, v.Len())
+	}
+
+	cp := reflect.MakeSlice(v.Type(), full.Len(), full.Len())
+	c.copies[k] = cp
+	c.pairs = append(c.pairs, pair{orig: full, copy: cp})
+	if isPlain(v.Type().Elem()) {
+		reflect.Copy(cp, full)
+	} else {
+		for i := range full.Len() {
+			c.copy(cp.Index(i), full.Index(i))
+		}
+	}
+	return cp.Slice(0, v.Len())
+}
+
+// copy deep-copies src into dst (both addressable values of the same type)
+func (c *cloner) copy(dst, src reflect.Value) {
+	t := src.Type()
+	if isPlain(t) {
+		dst.Set(src)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+		} else {
+			dst.Set(c.clonePointer(src))
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+		} else {
+			dst.Set(c.cloneSlice(src))
+		}
+
+	case reflect.Array:
+		for i := range src.Len() {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Struct:
+		for i := range src.NumField() {
+			if skipped(t.Field(i)) {
+				continue
+			}
+			c.copy(field(dst, i), field(src, i))
+		}
+
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+			return
+		}
+		elem := src.Elem()
+		if elem.Kind() == reflect.Pointer {
+			if !elem.IsNil() {
+				elem = c.clonePointer(elem)
+			}
+			dst.Set(elem)
+			return
+		}
+		v := reflect.New(elem.Type()).Elem()
+		c.copy(v, addressable(elem))
+		dst.Set(v)
+
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+			return
+		}
+		m := reflect.MakeMapWithSize(t, src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			v := reflect.New(t.Elem()).Elem()
+			c.copy(v, addressable(iter.Value()))
+			m.SetMapIndex(iter.Key(), v)
+		}
+		dst.Set(m)
+
+	default: // Func, Chan, UnsafePointer
+		dst.Set(src)
+	}
+}
+
+// restore writes the copy src into the original dst, translating copied pointers back to the originals
+func (s *Snapshot) restore(dst, src reflect.Value) {
+	t := src.Type()
+	if isPlain(t) {
+		dst.Set(src)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		dst.Set(s.original(src))
+
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+			return
+		}
+		full := s.original(src.Slice3(0, src.Cap(), src.Cap()))
+		dst.Set(full.Slice(0, src.Len()))
+
+	case reflect.Array:
+		for i := range src.Len() {
+			s.restore(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Struct:
+		for i := range src.NumField() {
+			if skipped(t.Field(i)) {
+				continue
+			}
+			s.restore(field(dst, i), field(src, i))
+		}
+
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+			return
+		}
+		elem := src.Elem()
+		if elem.Kind() == reflect.Pointer {
+			dst.Set(s.original(elem))
+			return
+		}
+		v := reflect.New(elem.Type()).Elem()
+		s.restore(v, addressable(elem))
+		dst.Set(v)
+
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(t))
+			return
+		}
+		m := reflect.MakeMapWithSize(t, src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			v := reflect.New(t.Elem()).Elem()
+			s.restore(v, addressable(iter.Value()))
+			m.SetMapIndex(iter.Key(), v)
+		}
+		dst.Set(m)
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// original returns the object a copied pointer or slice was taken from
+func (s *Snapshot) original(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return v
+	}
+	if orig, ok := s.originals[keyOf(v)]; ok {
+		return orig
+	}
+	return v
+}
+
+// field returns the i-th field of an addressable struct, unexported fields included
+func field(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+	if f.CanSet() {
+		return f
+	}
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+func addressable(v reflect.Value) reflect.Value {
+	a := reflect.New(v.Type()).Elem()
+	a.Set(v)
+	return a
+}
+
+func skipped(f reflect.StructField) bool {
+	return f.Tag.Get("snapshot") == "-"
+}
+
+// Types without pointers (and without skipped fields) are copied with a single assignment
+var plainTypes sync.Map
+
+func isPlain(t reflect.Type) bool {
+	if plain, ok := plainTypes.Load(t); ok {
+		return plain.(bool)
+	}
+
+	var plain bool
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		plain = true
+	case reflect.Array:
+		plain = isPlain(t.Elem())
+	case reflect.Struct:
+		plain = true
+		for i := range t.NumField() {
+			if skipped(t.Field(i)) || !isPlain(t.Field(i).Type) {
+				plain = false
+				break
+			}
+		}
+	}
+
+	plainTypes.Store(t, plain)
+	return plain
+}
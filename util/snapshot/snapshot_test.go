@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"testing"
+)
+
+type device interface {
+	Tick()
+}
+
+type counter struct {
+	n int
+}
+
+func (c *counter) Tick() { c.n++ }
+
+type machine struct {
+	regs    [4]uint8
+	ram     []uint8
+	devices []device
+	shared  *counter // Same object as devices[0]
+	pending func()
+
+	hook func()  `snapshot:"-"`
+	rom  []uint8 `snapshot:"-"`
+}
+
+func TestRestore(t *testing.T) {
+	c := &counter{n: 1}
+	m := &machine{
+		regs:    [4]uint8{1, 2, 3, 4},
+		ram:     []uint8{5, 6, 7},
+		devices: []device{c},
+		shared:  c,
+		rom:     []uint8{0xC3},
+	}
+	s := Take(m)
+
+	// Change everything
+	ram := m.ram
+	m.regs[0] = 0xFF
+	m.ram[1] = 0xFF
+	m.ram = append(m.ram, 8)
+	c.Tick()
+	m.shared = &counter{n: 10}
+	m.pending = func() {}
+	hookCalled := false
+	m.hook = func() { hookCalled = true }
+	m.rom[0] = 0xC9
+
+	s.Restore()
+
+	if m.regs != [4]uint8{1, 2, 3, 4} {
+		t.Errorf("registers: got %v", m.regs)
+	}
+	if len(m.ram) != 3 || m.ram[1] != 6 || &m.ram[0] != &ram[0] {
+		t.Errorf("RAM: got %v (original array %v)", m.ram, &m.ram[0] == &ram[0])
+	}
+	if m.shared != c || m.devices[0] != device(c) || c.n != 1 {
+		t.Errorf("shared pointer not restored: %p %p (n = %d)", m.shared, c, c.n)
+	}
+	if m.pending != nil {
+		t.Error("func field not restored")
+	}
+	if m.hook(); !hookCalled || m.rom[0] != 0xC9 {
+		t.Error("skipped fields restored")
+	}
+
+	// Snapshots can be restored more than once
+	c.Tick()
+	s.Restore()
+	if c.n != 1 {
+		t.Errorf("second restore: got %d, want 1", c.n)
+	}
+}
@@ -0,0 +1,363 @@
+// Package srcmap maps the lines of RGBDS assembly sources to the ROM locations
+// of the instructions they assemble to, for source-level debugging.
+//
+// RGBDS does not output line information, so lines are located from the addresses of
+// the labels (from the symbol file) and of the sections, counting the bytes of every
+// following instruction and data directive. After a macro, an INCLUDE or anything else
+// whose size is unknown, lines are not mapped until the next known label
+package srcmap
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+)
+
+// Resolver provides what the source does not say
+type Resolver interface {
+	// Label returns the location of a label (local labels have the
+	// global label as prefix, as in the symbol files: Main.loop)
+	Label(name string) (disasm.Location, bool)
+
+	// Read returns a ROM byte, used to know the length of the instructions
+	Read(loc disasm.Location) uint8
+}
+
+// Map maps the lines (starting from 1) of a source file with instructions to their location
+type Map struct {
+	locations map[int]disasm.Location
+	lines     map[disasm.Location]int
+	sorted    []int // Lines with an instruction
+}
+
+// Load parses the source file at path
+func Load(path string, res Resolver) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, res)
+}
+
+// Parse reads a source file and maps its instructions
+func Parse(r io.Reader, res Resolver) (*Map, error) {
+	p := &parser{
+		res: res,
+		m: &Map{
+			locations: make(map[int]disasm.Location),
+			lines:     make(map[disasm.Location]int),
+		},
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		p.parseLine(line, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for line := range p.m.locations {
+		p.m.sorted = append(p.m.sorted, line)
+	}
+	slices.Sort(p.m.sorted)
+	return p.m, nil
+}
+
+// Location returns the location of the instruction at line
+func (m *Map) Location(line int) (disasm.Location, bool) {
+	loc, ok := m.locations[line]
+	return loc, ok
+}
+
+// Line returns the line of the instruction at loc
+func (m *Map) Line(loc disasm.Location) (int, bool) {
+	line, ok := m.lines[loc]
+	return line, ok
+}
+
+// Resolve returns the first line with an instruction from line on (a breakpoint
+// set on a label, a comment or an empty line stops at the next instruction)
+func (m *Map) Resolve(line int) (int, disasm.Location, bool) {
+	i, _ := slices.BinarySearch(m.sorted, line)
+	if i == len(m.sorted) {
+		return 0, disasm.Location{}, false
+	}
+	return m.sorted[i], m.locations[m.sorted[i]], true
+}
+
+// SM83 instructions
+var mnemonics = map[string]bool{
+	"adc": true, "add": true, "and": true, "bit": true, "call": true, "ccf": true, "cp": true,
+	"cpl": true, "daa": true, "dec": true, "di": true, "ei": true, "halt": true, "inc": true,
+	"jp": true, "jr": true, "ld": true, "ldh": true, "ldi": true, "ldd": true, "nop": true,
+	"or": true, "pop": true, "push": true, "res": true, "ret": true, "reti": true, "rl": true,
+	"rla": true, "rlc": true, "rlca": true, "rr": true, "rra": true, "rrc": true, "rrca": true,
+	"rst": true, "sbc": true, "scf": true, "set": true, "sla": true, "sra": true, "srl": true,
+	"stop": true, "sub": true, "swap": true, "xor": true,
+}
+
+// Directives that do not output bytes
+var directives = map[string]bool{
+	"def": true, "redef": true, "export": true, "global": true, "purge": true, "opt": true,
+	"pusho": true, "popo": true, "assert": true, "static_assert": true, "print": true,
+	"println": true, "warn": true, "fail": true, "charmap": true, "newcharmap": true,
+	"setcharmap": true, "pushc": true, "popc": true, "rsreset": true, "rsset": true,
+	"endc": true, "align": true,
+}
+
+// Constant definitions in the form NAME EQU value
+var constants = map[string]bool{
+	"equ": true, "equs": true, "=": true, "set": true, "rb": true, "rw": true, "rl": true,
+}
+
+var (
+	sectionAddress = regexp.MustCompile(`(?i)\b(ROM0|ROMX)\s*\[\s*([^\]]+)\]`)
+	sectionBank    = regexp.MustCompile(`(?i)\bBANK\s*\[\s*([^\]]+)\]`)
+)
+
+type parser struct {
+	res Resolver
+	m   *Map
+
+	// Location of the next byte (unknown until a label or section gives it)
+	loc   disasm.Location
+	known bool
+
+	scope string // Last global label
+
+	// Inside a macro definition or a repeated block (until ENDM or ENDR)
+	skipUntil string
+}
+
+func (p *parser) parseLine(n int, line string) {
+	line = stripComment(line)
+
+	if p.skipUntil != "" {
+		if firstWord(line) == p.skipUntil {
+			p.skipUntil = ""
+		}
+		return
+	}
+
+	if name, rest, ok := cutLabel(strings.TrimLeft(line, " \t")); ok {
+		// Old style macro definition (NAME: MACRO)
+		if firstWord(rest) == "macro" {
+			p.skipUntil = "endm"
+			return
+		}
+		p.label(name)
+		line = rest
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	word := strings.ToLower(fields[0])
+	args := strings.TrimSpace(line[strings.Index(line, fields[0])+len(fields[0]):])
+
+	switch {
+	case mnemonics[word]:
+		if p.known {
+			p.m.locations[n] = p.loc
+			p.m.lines[p.loc] = n
+			p.loc.Addr += uint16(disasm.Opcodes[p.res.Read(p.loc)].Length)
+		}
+
+	case word == "db" || word == "dw" || word == "dl":
+		size := map[string]int{"db": 1, "dw": 2, "dl": 4}[word]
+		p.advance(dataLength(args, size))
+
+	case word == "ds":
+		count, _, _ := strings.Cut(args, ",")
+		n, err := parseNumber(count)
+		p.advance(n, err == nil)
+
+	case word == "section":
+		p.section(args)
+
+	case word == "macro":
+		p.skipUntil = "endm"
+
+	case word == "rept" || word == "for":
+		// Lines of repeated blocks are at more than one location
+		p.skipUntil = "endr"
+		p.known = false
+
+	case directives[word] || len(fields) > 1 && constants[strings.ToLower(fields[1])]:
+		// No bytes
+
+	default:
+		// Macro invocation, INCLUDE, INCBIN, conditional assembly, LOAD blocks...
+		p.known = false
+	}
+}
+
+// label moves to the location of a label (if it is in the symbol file)
+func (p *parser) label(name string) {
+	switch {
+	case strings.HasPrefix(name, "."):
+		name = p.scope + name
+	case !strings.Contains(name, "."):
+		p.scope = name
+	}
+
+	if loc, ok := p.res.Label(name); ok && loc.Addr < 0x8000 {
+		p.loc, p.known = loc, true
+	}
+}
+
+// section moves to the start of a ROM section with a fixed address
+func (p *parser) section(args string) {
+	p.known = false
+
+	match := sectionAddress.FindStringSubmatch(args)
+	if match == nil {
+		return
+	}
+	addr, err := parseNumber(match[2])
+	if err != nil {
+		return
+	}
+
+	bank := 0
+	if strings.EqualFold(match[1], "ROMX") {
+		m := sectionBank.FindStringSubmatch(args)
+		if m == nil {
+			return
+		}
+		if bank, err = parseNumber(m[1]); err != nil {
+			return
+		}
+	}
+	p.loc = disasm.Location{Bank: uint(bank), Addr: uint16(addr)}
+	p.known = true
+}
+
+func (p *parser) advance(n int, ok bool) {
+	if !ok {
+		p.known = false
+		return
+	}
+	p.loc.Addr += uint16(n)
+}
+
+// cutLabel splits a label definition (NAME:, NAME::, .local or .local:) from the rest of the line
+func cutLabel(line string) (name, rest string, ok bool) {
+	end := strings.IndexFunc(line, func(r rune) bool {
+		return !isLabelChar(r)
+	})
+	if end < 0 {
+		end = len(line)
+	}
+	name, rest = line[:end], line[end:]
+	if name == "" {
+		return "", line, false
+	}
+
+	if r, ok := strings.CutPrefix(rest, ":"); ok {
+		return name, strings.TrimPrefix(r, ":"), true
+	}
+	if strings.HasPrefix(name, ".") && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+		return name, rest, true
+	}
+	return "", line, false
+}
+
+func isLabelChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '.' || r == '#' || r == '@' || r == '$'
+}
+
+func firstWord(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// stripComment removes the comment at the end of the line (; outside of strings)
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			inString = !inString
+		case ';':
+			if !inString {
+				return strings.TrimRight(line[:i], " \t")
+			}
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
+
+// dataLength returns the bytes output by a data directive (strings output
+// a byte per character, charmaps are not supported)
+func dataLength(args string, size int) (int, bool) {
+	if args == "" {
+		return size, true
+	}
+
+	n := 0
+	for _, arg := range splitArgs(args) {
+		if s, ok := strings.CutPrefix(arg, `"`); ok && strings.HasSuffix(s, `"`) && size == 1 {
+			s = strings.TrimSuffix(s, `"`)
+			n += len(s) - strings.Count(s, `\`)
+			continue
+		}
+		n += size
+	}
+	return n, true
+}
+
+// splitArgs splits the arguments at the commas outside of strings
+func splitArgs(args string) []string {
+	var parts []string
+	inString := false
+	start := 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '\\':
+			i++
+		case '"':
+			inString = !inString
+		case ',':
+			if !inString {
+				parts = append(parts, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(args[start:]))
+}
+
+// parseNumber parses a number in RGBDS syntax ($hex, %binary, &octal, 0x.., decimal)
+func parseNumber(s string) (int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	base := 10
+	switch {
+	case strings.HasPrefix(s, "$"):
+		s, base = s[1:], 16
+	case strings.HasPrefix(s, "%"):
+		s, base = s[1:], 2
+	case strings.HasPrefix(s, "&"):
+		s, base = s[1:], 8
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0X"):
+		s, base = s[2:], 16
+	}
+	n, err := strconv.ParseUint(s, base, 16)
+	return int(n), err
+}
@@ -0,0 +1,103 @@
+package srcmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danielecanzoneri/lucky-boy/util/disasm"
+)
+
+type resolver struct {
+	labels map[string]disasm.Location
+	rom    map[disasm.Location]uint8 // NOP if not set
+}
+
+func (r resolver) Label(name string) (disasm.Location, bool) {
+	loc, ok := r.labels[name]
+	return loc, ok
+}
+
+func (r resolver) Read(loc disasm.Location) uint8 {
+	return r.rom[loc]
+}
+
+const source = `INCLUDE "hardware.inc"
+
+DEF SPEED EQU 3
+
+MACRO wait
+	REPT \1
+		nop
+	ENDR
+ENDM
+
+SECTION "Entry", ROM0[$100]
+	nop
+	jp Main ; Skip the header
+
+SECTION "Main", ROM0[$150]
+Main:
+	ld a, SPEED
+.loop: dec a
+	jr nz, .loop
+	db "Hi;", 0
+	dw $1234
+	ds 2, $FF
+	call Routine
+	wait 4
+	halt ; Unknown location after the macro
+
+SECTION "Bank 1", ROMX
+Routine:
+	ret
+`
+
+func TestParse(t *testing.T) {
+	res := resolver{
+		labels: map[string]disasm.Location{
+			"Main":      {Bank: 0, Addr: 0x150},
+			"Main.loop": {Bank: 0, Addr: 0x152},
+			"Routine":   {Bank: 1, Addr: 0x4321},
+		},
+		rom: map[disasm.Location]uint8{
+			{Bank: 0, Addr: 0x101}: 0xC3, // JP a16
+			{Bank: 0, Addr: 0x150}: 0x3E, // LD A, n8
+			{Bank: 0, Addr: 0x152}: 0x3D, // DEC A
+			{Bank: 0, Addr: 0x153}: 0x20, // JR NZ, e8
+			{Bank: 0, Addr: 0x15D}: 0xCD, // CALL a16
+		},
+	}
+	m, err := Parse(strings.NewReader(source), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]disasm.Location{
+		12: {Bank: 0, Addr: 0x100},
+		13: {Bank: 0, Addr: 0x101},
+		17: {Bank: 0, Addr: 0x150},
+		18: {Bank: 0, Addr: 0x152},
+		19: {Bank: 0, Addr: 0x153},
+		23: {Bank: 0, Addr: 0x15D},
+		29: {Bank: 1, Addr: 0x4321},
+	}
+	for line := 1; line <= strings.Count(source, "\n"); line++ {
+		loc, ok := m.Location(line)
+		wantLoc, wantOk := want[line]
+		if ok != wantOk || loc != wantLoc {
+			t.Errorf("line %d: got %v %v, want %v %v", line, loc, ok, wantLoc, wantOk)
+		}
+	}
+
+	if line, ok := m.Line(disasm.Location{Bank: 0, Addr: 0x153}); !ok || line != 19 {
+		t.Errorf("$0153: got line %d, want 19", line)
+	}
+
+	// Breakpoints on labels and comments move to the next instruction
+	if line, loc, ok := m.Resolve(16); !ok || line != 17 || loc.Addr != 0x150 {
+		t.Errorf("resolve line 16: got %d %v", line, loc)
+	}
+	if _, _, ok := m.Resolve(30); ok {
+		t.Error("resolve line 30: expected no instruction")
+	}
+}
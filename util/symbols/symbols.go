@@ -0,0 +1,179 @@
+// Package symbols reads symbol files in the RGBDS/no$gmb format, where each line is
+// "BB:AAAA Label" (bank and address in hexadecimal) and comments start with ';'
+package symbols
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Symbol is a label at a bank-qualified address
+type Symbol struct {
+	Name string
+	Bank uint
+	Addr uint16
+}
+
+type location struct {
+	bank uint
+	addr uint16
+}
+
+// Table is a collection of symbols that can be looked up by name or by address
+type Table struct {
+	symbols []Symbol // Sorted by bank and address
+	byName  map[string]Symbol
+	byAddr  map[location][]string
+
+	// Lines of the file that were skipped, with the reason
+	skipped []string
+}
+
+// Load reads a symbol file
+func Load(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads symbols from r. Invalid lines are skipped (see Skipped)
+func Parse(r io.Reader) (*Table, error) {
+	t := &Table{
+		byName: make(map[string]Symbol),
+		byAddr: make(map[location][]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), ";")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		s, err := parseSymbol(fields)
+		if err != nil {
+			t.skipped = append(t.skipped, fmt.Sprintf("line %d: %v", lineNumber, err))
+			continue
+		}
+		t.index(s)
+		t.symbols = append(t.symbols, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Sorted once (symbol files have tens of thousands of lines)
+	slices.SortStableFunc(t.symbols, compareSymbols)
+	return t, nil
+}
+
+// parseSymbol parses the fields of a line
+func parseSymbol(fields []string) (Symbol, error) {
+	if len(fields) != 2 {
+		return Symbol{}, fmt.Errorf("expected \"BB:AAAA Label\"")
+	}
+
+	bankStr, addrStr, ok := strings.Cut(fields[0], ":")
+	if !ok {
+		return Symbol{}, fmt.Errorf("invalid address %q", fields[0])
+	}
+	bank, err := strconv.ParseUint(bankStr, 16, 16)
+	if err != nil {
+		return Symbol{}, fmt.Errorf("invalid bank %q", bankStr)
+	}
+	addr, err := strconv.ParseUint(addrStr, 16, 16)
+	if err != nil {
+		return Symbol{}, fmt.Errorf("invalid address %q", addrStr)
+	}
+	return Symbol{Name: fields[1], Bank: uint(bank), Addr: uint16(addr)}, nil
+}
+
+// Skipped returns the invalid lines skipped while parsing, with the reason
+func (t *Table) Skipped() []string {
+	return t.skipped
+}
+
+// Add adds a symbol to the table
+func (t *Table) Add(s Symbol) {
+	t.index(s)
+	i, _ := slices.BinarySearchFunc(t.symbols, s, compareSymbols)
+	for i < len(t.symbols) && compareSymbols(t.symbols[i], s) == 0 {
+		i++ // After the symbols at the same address
+	}
+	t.symbols = slices.Insert(t.symbols, i, s)
+}
+
+// index makes the symbol searchable by name and address
+func (t *Table) index(s Symbol) {
+	t.byName[s.Name] = s
+
+	loc := location{s.Bank, s.Addr}
+	t.byAddr[loc] = append(t.byAddr[loc], s.Name)
+}
+
+func compareSymbols(a, b Symbol) int {
+	if a.Bank != b.Bank {
+		return int(a.Bank) - int(b.Bank)
+	}
+	return int(a.Addr) - int(b.Addr)
+}
+
+// Len returns the number of symbols
+func (t *Table) Len() int {
+	return len(t.symbols)
+}
+
+// Lookup returns the symbol with the given name
+func (t *Table) Lookup(name string) (Symbol, bool) {
+	s, ok := t.byName[name]
+	return s, ok
+}
+
+// At returns the labels at the given address (nil if there are none)
+func (t *Table) At(bank uint, addr uint16) []string {
+	return t.byAddr[location{bank, addr}]
+}
+
+// Label returns the first label at the given address
+func (t *Table) Label(bank uint, addr uint16) (string, bool) {
+	labels := t.byAddr[location{bank, addr}]
+	if len(labels) == 0 {
+		return "", false
+	}
+	return labels[0], true
+}
+
+// InRange returns the symbols with start <= address < end in the given bank
+func (t *Table) InRange(bank uint, start, end uint16) []Symbol {
+	i, _ := slices.BinarySearchFunc(t.symbols, Symbol{Bank: bank, Addr: start}, compareSymbols)
+	j := i
+	for j < len(t.symbols) && t.symbols[j].Bank == bank && t.symbols[j].Addr < end {
+		j++
+	}
+	return t.symbols[i:j]
+}
+
+// Search returns the symbols whose name contains query (case-insensitive), sorted by name
+func (t *Table) Search(query string) []Symbol {
+	query = strings.ToLower(query)
+
+	var found []Symbol
+	for _, s := range t.symbols {
+		if strings.Contains(strings.ToLower(s.Name), query) {
+			found = append(found, s)
+		}
+	}
+	slices.SortFunc(found, func(a, b Symbol) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return found
+}
@@ -0,0 +1,90 @@
+package symbols
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const symFile = `; File generated by rgblink
+00:0150 Main
+00:0158 Main.loop
+01:4a3c UpdatePlayer
+02:4a3c DrawHUD
+00:c100 wPlayerHP
+00:c101 wPlayerMP
+00:c101 wPlayerStats
+`
+
+func TestParse(t *testing.T) {
+	table, err := Parse(strings.NewReader(symFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.Len() != 7 {
+		t.Errorf("expected 7 symbols, got %d", table.Len())
+	}
+
+	s, ok := table.Lookup("UpdatePlayer")
+	if !ok || s.Bank != 1 || s.Addr != 0x4A3C {
+		t.Errorf("Lookup(UpdatePlayer): got %+v, %v", s, ok)
+	}
+
+	// Same address in different banks
+	if label, _ := table.Label(2, 0x4A3C); label != "DrawHUD" {
+		t.Errorf("Label(02:4A3C): expected DrawHUD, got %q", label)
+	}
+	if _, ok := table.Label(3, 0x4A3C); ok {
+		t.Error("Label(03:4A3C): expected no label")
+	}
+
+	if labels := table.At(0, 0xC101); len(labels) != 2 {
+		t.Errorf("At(00:C101): expected 2 labels, got %v", labels)
+	}
+
+	inRange := table.InRange(0, 0xC100, 0xC110)
+	if len(inRange) != 3 || inRange[0].Name != "wPlayerHP" {
+		t.Errorf("InRange(00:C100-C110): got %v", inRange)
+	}
+
+	found := table.Search("player")
+	if len(found) != 4 || found[0].Name != "UpdatePlayer" {
+		t.Errorf("Search(player): got %v", found)
+	}
+}
+
+func TestParseInvalidLines(t *testing.T) {
+	for _, src := range []string{"0150 Main", "00:0150", "XX:0150 Main", "00:ZZZZ Main", "00:0150 Main extra"} {
+		table, err := Parse(strings.NewReader(src + "\n00:0200 Valid\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skipped := table.Skipped(); len(skipped) != 1 || !strings.HasPrefix(skipped[0], "line 1: ") {
+			t.Errorf("Parse(%q): skipped %q", src, skipped)
+		}
+		if _, ok := table.Lookup("Valid"); !ok || table.Len() != 1 {
+			t.Errorf("Parse(%q): valid line not loaded", src)
+		}
+	}
+}
+
+func TestParseLarge(t *testing.T) {
+	// Unsorted, as the sections of a large ROM
+	var sb strings.Builder
+	const n = 50000
+	for i := range n {
+		fmt.Fprintf(&sb, "%02X:%04X Label%d\n", (n-i)%0x80, 0x4000+i%0x4000, i)
+	}
+	table, err := Parse(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Len() != n {
+		t.Fatalf("got %d symbols", table.Len())
+	}
+	if !slices.IsSortedFunc(table.symbols, compareSymbols) {
+		t.Error("symbols not sorted")
+	}
+}